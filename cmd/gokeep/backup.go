@@ -0,0 +1,100 @@
+//go:build !js
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/internal/backup"
+	"github.com/Smil3MoreGH/gokeep/internal/config"
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+)
+
+// runBackup implements "gokeep backup [--format sqlite|json] [--out path]"
+func runBackup(args []string) {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	format := fs.String("format", "sqlite", "backup format: sqlite (exact copy) or json (portable dump)")
+	out := fs.String("out", "", "file to write (default gokeep-<timestamp>.db or .json)")
+	flags := config.RegisterFlags(fs, dbKeys...)
+	fs.Parse(args)
+
+	if *format != "sqlite" && *format != "json" {
+		log.Fatalf("unknown backup format %q", *format)
+	}
+	if *out == "" {
+		*out = backup.FileName(time.Now())
+		if *format == "json" {
+			*out = strings.TrimSuffix(*out, ".db") + ".json"
+		}
+	}
+
+	db := openDB(loadConfig(flags))
+	defer db.Close()
+
+	if *format == "sqlite" {
+		if err := backup.Create(context.Background(), db, *out); err != nil {
+			log.Fatalf("backup failed: %v", err)
+		}
+	} else {
+		if err := writeDumpFile(db, *out); err != nil {
+			log.Fatalf("backup failed: %v", err)
+		}
+	}
+	fmt.Printf("Backup written to %s\n", *out)
+}
+
+// writeDumpFile writes a JSON dump of db to path
+func writeDumpFile(db *database.DB, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := backup.WriteDump(context.Background(), f, database.NewNoteRepository(db, nil)); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// runRestore implements "gokeep restore <backup.db|dump.json>". The server
+// must be stopped while the database is replaced.
+func runRestore(args []string) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	flags := config.RegisterFlags(fs, dbKeys...)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		log.Fatal("usage: gokeep restore <backup.db|dump.json>")
+	}
+	src := fs.Arg(0)
+	dbPath := loadConfig(flags).DBPath()
+
+	dump, err := backup.IsDump(src)
+	if err != nil {
+		log.Fatalf("restore failed: %v", err)
+	}
+
+	var kept string
+	if dump {
+		var n int
+		n, kept, err = backup.RestoreDump(context.Background(), src, dbPath)
+		if err != nil {
+			log.Fatalf("restore failed: %v", err)
+		}
+		fmt.Printf("Restored %d notes from %s\n", n, src)
+	} else {
+		if kept, err = backup.Restore(context.Background(), src, dbPath); err != nil {
+			log.Fatalf("restore failed: %v", err)
+		}
+		fmt.Printf("Restored %s from %s\n", dbPath, src)
+	}
+	if kept != "" {
+		fmt.Printf("The previous database was kept as %s\n", kept)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build !js
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Smil3MoreGH/gokeep/internal/config"
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+)
+
+// dbKeys are the settings the offline commands accept as flags; everything
+// else comes from the config file and the environment
+var dbKeys = []string{"data_dir", "database.path"}
+
+// loadConfig builds the effective configuration or exits
+func loadConfig(flags *config.Flags) *config.Config {
+	cfg, err := config.Load(flags)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cfg
+}
+
+// openDB opens the database the configuration points at, creating the data
+// directory if needed
+func openDB(cfg *config.Config) *database.DB {
+	if err := os.MkdirAll(cfg.DataDir, 0o755); err != nil {
+		log.Fatalf("failed to create data directory: %v", err)
+	}
+	db, err := database.NewDBWithOptions(cfg.DBPath(), database.PoolOptions{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+	})
+	if err != nil {
+		log.Fatalf("failed to initialise database: %v", err)
+	}
+	return db
+}
+
+// runConfig implements "gokeep config print", showing the configuration
+// serve would run with given the same file, environment and flags. Secrets
+// are redacted.
+func runConfig(args []string) {
+	if len(args) == 0 || args[0] != "print" {
+		log.Fatal("usage: gokeep config print [--config file] [serve flags]")
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ExitOnError)
+	flags := config.RegisterFlags(fs)
+	fs.Parse(args[1:])
+
+	cfg := loadConfig(flags)
+	if cfg.Source != "" {
+		fmt.Printf("# loaded from %s\n", cfg.Source)
+	}
+	if err := cfg.Redacted().WriteYAML(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
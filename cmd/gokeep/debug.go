@@ -0,0 +1,120 @@
+//go:build !js
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/internal/config"
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/internal/handlers"
+)
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3";
+// otherwise the module version is reported
+var version string
+
+// buildVersion returns the version this binary reports
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		return bi.Main.Version
+	}
+	return "(devel)"
+}
+
+// startedAt is when the process started, for the uptime
+var startedAt = time.Now()
+
+// debugInfo is the body of /debug/info
+type debugInfo struct {
+	Version   string            `json:"version"`
+	GoVersion string            `json:"go_version"`
+	Platform  string            `json:"platform"`
+	Build     map[string]string `json:"build,omitempty"`
+	StartedAt time.Time         `json:"started_at"`
+	Uptime    string            `json:"uptime"`
+	Config    map[string]string `json:"config"`
+	Database  debugDatabase     `json:"database"`
+}
+
+type debugDatabase struct {
+	Path                string `json:"path"`
+	SizeBytes           int64  `json:"size_bytes"`
+	SchemaVersion       int    `json:"schema_version"`
+	LatestSchemaVersion int    `json:"latest_schema_version"`
+	Error               string `json:"error,omitempty"`
+}
+
+// debugInfoHandler serves GET /debug/info: what is running, how it is
+// configured (with secrets redacted) and the state of the database
+func debugInfoHandler(cfg *config.Config, db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		info := debugInfo{
+			Version:   buildVersion(),
+			GoVersion: runtime.Version(),
+			Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+			StartedAt: startedAt.UTC(),
+			Uptime:    time.Since(startedAt).Round(time.Second).String(),
+			Config:    cfg.Redacted().Settings(),
+			Database: debugDatabase{
+				Path:                cfg.DBPath(),
+				LatestSchemaVersion: database.LatestSchemaVersion(),
+			},
+		}
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			info.Build = make(map[string]string)
+			for _, s := range bi.Settings {
+				if strings.HasPrefix(s.Key, "vcs.") || s.Key == "CGO_ENABLED" || s.Key == "-tags" {
+					info.Build[s.Key] = s.Value
+				}
+			}
+		}
+
+		var err error
+		if info.Database.SizeBytes, err = db.Size(r.Context()); err == nil {
+			info.Database.SchemaVersion, err = db.SchemaVersion(r.Context())
+		}
+		if err != nil {
+			info.Database.Error = err.Error()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(info)
+	}
+}
+
+// requireAdmin lets through only requests carrying the admin token as a
+// bearer token. Clients that keep guessing are locked out for longer and
+// longer.
+func requireAdmin(token string, lim *limits) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !lim.allowAttempt(w, r) {
+				return
+			}
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				lockout := lim.failedAttempt(r)
+				slog.WarnContext(r.Context(), "admin authentication failed", "remote_addr", r.RemoteAddr, "lockout", lockout)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="gokeep admin"`)
+				handlers.Unauthorized(w, r)
+				return
+			}
+			lim.succeededAttempt(r)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !js
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/Smil3MoreGH/gokeep/internal/config"
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/internal/exporter"
+)
+
+const exportUsage = "usage: gokeep export <out.zip|dir>"
+
+// runExport implements "gokeep export <out.zip|dir>", writing one Markdown
+// file per note
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	flags := config.RegisterFlags(fs, dbKeys...)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		log.Fatal(exportUsage)
+	}
+
+	db := openDB(loadConfig(flags))
+	defer db.Close()
+
+	repo := database.NewNoteRepository(db, nil)
+	n, err := exporter.ExportMarkdownTo(context.Background(), repo, fs.Arg(0))
+	if err != nil {
+		log.Fatalf("export failed: %v", err)
+	}
+	fmt.Printf("Exported %d notes to %s\n", n, fs.Arg(0))
+}
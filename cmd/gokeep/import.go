@@ -0,0 +1,102 @@
+//go:build !js
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Smil3MoreGH/gokeep/internal/config"
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/internal/importer"
+)
+
+const importUsage = "usage: gokeep import keep|markdown|enex [--dry-run] [--json] <file|dir>"
+
+// runImport implements "gokeep import keep <takeout.zip>",
+// "gokeep import markdown <export.zip|dir>" and "gokeep import enex <file.enex>"
+func runImport(args []string) {
+	if len(args) == 0 || (args[0] != "keep" && args[0] != "markdown" && args[0] != "enex") {
+		log.Fatal(importUsage)
+	}
+	format := args[0]
+
+	fs := flag.NewFlagSet("import "+format, flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without writing anything")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	flags := config.RegisterFlags(fs, dbKeys...)
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		log.Fatal(importUsage)
+	}
+
+	db := openDB(loadConfig(flags))
+	defer db.Close()
+
+	// No event bus: a running server picks the notes up through /api/sync
+	repo := database.NewNoteRepository(db, nil)
+
+	opts := importer.Options{DryRun: *dryRun}
+	var report *importer.Report
+	var err error
+	switch format {
+	case "keep":
+		report, err = importer.ImportKeepFile(context.Background(), fs.Arg(0), repo, opts)
+	case "markdown":
+		report, err = importer.ImportMarkdownPath(context.Background(), fs.Arg(0), repo, opts)
+	case "enex":
+		report, err = importer.ImportENEXFile(context.Background(), fs.Arg(0), repo, opts)
+	}
+	if report != nil {
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(report)
+		} else {
+			printImportReport(report)
+		}
+	}
+	if err != nil {
+		log.Fatalf("import failed: %v", err)
+	}
+}
+
+// printImportReport writes a human readable summary of report
+func printImportReport(report *importer.Report) {
+	for _, entry := range report.Notes {
+		flags := ""
+		if entry.Pinned {
+			flags += " pinned"
+		}
+		if entry.Archived {
+			flags += " archived"
+		}
+		if entry.Trashed {
+			flags += " trashed"
+		}
+		if entry.Action != "" {
+			flags += " " + entry.Action
+		}
+		if entry.DuplicateOf != 0 {
+			flags += fmt.Sprintf(" of #%d", entry.DuplicateOf)
+		}
+		fmt.Printf("%-40q labels=%v attachments=%d%s\n", entry.Title, entry.Labels, entry.Attachments, flags)
+		for _, warning := range entry.Warnings {
+			fmt.Printf("    warning: %s\n", warning)
+		}
+	}
+	for _, warning := range report.Warnings {
+		fmt.Printf("warning: %s\n", warning)
+	}
+
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Would import"
+	}
+	fmt.Printf("%s %d notes with %d attachments, skipped %d files\n",
+		verb, report.Imported, report.Attachments, report.Skipped)
+}
@@ -0,0 +1,76 @@
+//go:build !js
+
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/internal/config"
+	"github.com/Smil3MoreGH/gokeep/internal/handlers"
+	"github.com/Smil3MoreGH/gokeep/internal/ratelimit"
+)
+
+// limits holds the rate limiters of the server. With rate limiting off
+// they are nil and let everything through.
+type limits struct {
+	writes  *ratelimit.Limiter
+	search  *ratelimit.Limiter
+	auth    *ratelimit.Limiter
+	lockout *ratelimit.Lockout
+}
+
+func newLimits(cfg config.RateLimitConfig) *limits {
+	if !cfg.Enabled {
+		return &limits{}
+	}
+	return &limits{
+		writes:  ratelimit.NewLimiter(cfg.WriteRate, cfg.WriteBurst),
+		search:  ratelimit.NewLimiter(cfg.SearchRate, cfg.SearchBurst),
+		auth:    ratelimit.NewLimiter(cfg.AuthRate, cfg.AuthBurst),
+		lockout: ratelimit.NewLockout(cfg.Lockout, cfg.MaxLockout),
+	}
+}
+
+// middleware spends the budget of limiter on the requests match selects,
+// or on every request if match is nil
+func (l *limits) middleware(limiter *ratelimit.Limiter, match func(*http.Request) bool) func(http.Handler) http.Handler {
+	if limiter == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return ratelimit.Limit(limiter, match, handlers.RateLimited)
+}
+
+// allowAttempt refuses an authentication attempt from a client that is
+// locked out or has used up its budget of attempts. Refused requests have
+// been answered when it returns false.
+func (l *limits) allowAttempt(w http.ResponseWriter, r *http.Request) bool {
+	if l.lockout == nil {
+		return true
+	}
+	if wait := l.lockout.Locked(ratelimit.ClientIP(r)); wait > 0 {
+		ratelimit.Refuse(w, r, wait, handlers.RateLimited)
+		return false
+	}
+	if ok, wait := l.auth.Allow(ratelimit.ClientKeys(r)...); !ok {
+		ratelimit.Refuse(w, r, wait, handlers.RateLimited)
+		return false
+	}
+	return true
+}
+
+// failedAttempt locks the client out for longer with every failure and
+// returns for how long
+func (l *limits) failedAttempt(r *http.Request) time.Duration {
+	if l.lockout == nil {
+		return 0
+	}
+	return l.lockout.Fail(ratelimit.ClientIP(r))
+}
+
+// succeededAttempt forgets the failures of the client
+func (l *limits) succeededAttempt(r *http.Request) {
+	if l.lockout != nil {
+		l.lockout.Succeed(ratelimit.ClientIP(r))
+	}
+}
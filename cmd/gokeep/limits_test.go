@@ -0,0 +1,74 @@
+//go:build !js
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Smil3MoreGH/gokeep/internal/config"
+	"github.com/Smil3MoreGH/gokeep/internal/handlers"
+	"github.com/Smil3MoreGH/gokeep/internal/ratelimit"
+	"github.com/Smil3MoreGH/gokeep/internal/security"
+)
+
+// spoofed sends a request from the same connection address every time,
+// claiming another client address in its headers each time
+func spoofed(h http.Handler, method, target string, i int, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	r.RemoteAddr = "203.0.113.7:4000"
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	fake := "198.51.100." + strconv.Itoa(i)
+	r.Header.Set("X-Forwarded-For", fake)
+	r.Header.Set("X-Real-IP", fake)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestSpoofedAddressKeepsRateLimit(t *testing.T) {
+	cfg := config.Default().RateLimit
+	lim := newLimits(cfg)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := security.RealIP(nil)(lim.middleware(lim.writes, ratelimit.IsWrite)(ok))
+
+	for i := range cfg.WriteBurst {
+		if w := spoofed(h, "POST", "/api/notes", i, nil); w.Code != http.StatusOK {
+			t.Fatalf("write %d refused with %d", i, w.Code)
+		}
+	}
+	if w := spoofed(h, "POST", "/api/notes", cfg.WriteBurst, nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("a new X-Forwarded-For got a fresh budget: status %d", w.Code)
+	}
+}
+
+func TestSpoofedAddressKeepsLockout(t *testing.T) {
+	lim := newLimits(config.Default().RateLimit)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := security.RealIP(nil)(requireAdmin("secret", lim)(ok))
+	wrong := http.Header{"Authorization": {"Bearer guess"}}
+
+	w := spoofed(h, "GET", "/debug/info", 0, wrong)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token answered with %d", w.Code)
+	}
+	if w.Header().Get("WWW-Authenticate") == "" {
+		t.Error("401 without WWW-Authenticate")
+	}
+	var problem handlers.Problem
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("401 sent as %q", ct)
+	} else if err := json.NewDecoder(w.Body).Decode(&problem); err != nil || problem.Code != handlers.CodeUnauthorized {
+		t.Errorf("401 body has code %q, %v", problem.Code, err)
+	}
+
+	right := http.Header{"Authorization": {"Bearer secret"}}
+	if w := spoofed(h, "GET", "/debug/info", 1, right); w.Code != http.StatusTooManyRequests {
+		t.Errorf("a new X-Forwarded-For escaped the lockout: status %d", w.Code)
+	}
+}
@@ -1,12 +1,19 @@
+//go:build !js
+
 package main
 
 import (
 	"context"
 	"embed"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -14,80 +21,235 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
 
+	"github.com/Smil3MoreGH/gokeep/internal/backup"
+	"github.com/Smil3MoreGH/gokeep/internal/config"
 	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/internal/events"
 	"github.com/Smil3MoreGH/gokeep/internal/handlers"
-	"github.com/Smil3MoreGH/gokeep/internal/ui"
+	"github.com/Smil3MoreGH/gokeep/internal/health"
+	"github.com/Smil3MoreGH/gokeep/internal/logging"
+	"github.com/Smil3MoreGH/gokeep/internal/metrics"
+	"github.com/Smil3MoreGH/gokeep/internal/ratelimit"
+	"github.com/Smil3MoreGH/gokeep/internal/security"
+	"github.com/Smil3MoreGH/gokeep/internal/tracing"
+	"github.com/Smil3MoreGH/gokeep/pkg/collab"
 )
 
 //go:embed web/*
 var webFS embed.FS
 
 func main() {
-	// Initialise SQLite database (creates file if it does not exist)
-	files, err := webFS.ReadDir("web")
+	// The app handler prerenders the same routes the browser runs
+	registerRoutes()
+
+	var args []string
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			args = os.Args[2:]
+		case "backup":
+			runBackup(os.Args[2:])
+			return
+		case "restore":
+			runRestore(os.Args[2:])
+			return
+		case "import":
+			runImport(os.Args[2:])
+			return
+		case "export":
+			runExport(os.Args[2:])
+			return
+		case "publish":
+			runPublish(os.Args[2:])
+			return
+		case "config":
+			runConfig(os.Args[2:])
+			return
+		case "note":
+			runNote(os.Args[2:])
+			return
+		case "tui":
+			runTUI(os.Args[2:])
+			return
+		default:
+			// Flags without a command are for serve
+			if strings.HasPrefix(os.Args[1], "-") {
+				args = os.Args[1:]
+				break
+			}
+			log.Fatalf("unknown command %q (expected serve, backup, restore, import, export, publish, config, note or tui)", os.Args[1])
+		}
+	}
+
+	serve(args)
+}
+
+// serve runs the HTTP server until it receives Ctrl‑C / SIGTERM
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	flags := config.RegisterFlags(fs)
+	fs.Parse(args)
+	cfg := loadConfig(flags)
+
+	// Structured logs; the log package is routed through the same handler
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
 	if err != nil {
-		log.Fatal("webFS.ReadDir:", err)
+		log.Fatal(err)
 	}
-	for _, f := range files {
-		log.Println("Embedded file:", f.Name())
+	slog.SetDefault(logger)
+	if cfg.Source != "" {
+		slog.Info("configuration loaded", "path", cfg.Source)
 	}
 
-	db, err := database.NewDB("gokeep.db")
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		files, err := webFS.ReadDir("web")
+		if err != nil {
+			fatal("failed to read embedded web assets", "error", err)
+		}
+		for _, f := range files {
+			slog.Debug("embedded file", "name", f.Name())
+		}
+	}
+
+	// Traces of requests, repository operations and background jobs
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		Version:     buildVersion(),
+	})
 	if err != nil {
-		log.Fatalf("failed to initialise database: %v", err)
+		fatal("failed to set up tracing", "error", err)
 	}
+
+	// Initialise SQLite database (creates file if it does not exist)
+	db := openDB(cfg)
 	defer db.Close()
 
+	// Background tasks run until the server stops
+	tasksCtx, stopTasks := context.WithCancel(context.Background())
+	defer stopTasks()
+
+	// Scheduled backups with rotation
+	if dir := cfg.BackupDir(); dir != "" {
+		scheduler := &backup.Scheduler{DB: db, Dir: dir, Interval: cfg.Backup.Interval, Keep: cfg.Backup.Keep}
+		go scheduler.Run(tasksCtx)
+		slog.Info("scheduled backups enabled", "dir", dir, "interval", cfg.Backup.Interval, "keep", cfg.Backup.Keep)
+	}
+
+	// Event bus for live updates, fed by the repository
+	bus := events.NewBus(events.DefaultHistorySize)
+
 	// Repository & REST handler layer
-	repo := database.NewNoteRepository(db)
-	api := handlers.NewAPIHandler(repo)
+	repo := database.NewNoteRepository(db, bus)
+	repo.SetSlowQuery(cfg.Database.SlowQuery)
+	api := handlers.NewAPIHandler(repo, bus)
+	api.SetENEXMaxSize(int64(cfg.Import.ENEXMaxSize) << 20)
+
+	// Prometheus metrics, including repository timings and database stats
+	var m *metrics.Metrics
+	if cfg.Features.Metrics {
+		m = metrics.New()
+		repo.SetObserver(m)
+		m.Register(metrics.NewDatabaseCollector(db, repo))
+	}
+
+	// Liveness and readiness probes
+	probes := health.New()
+	probes.Add("database", db.Ping)
+	probes.Add("migrations", db.CheckMigrations)
+	probes.Add("search_index", health.Periodic(tasksCtx, db.CheckFTS, cfg.Admin.IndexCheck))
+	probes.Add("disk_space", health.DiskSpace(filepath.Dir(cfg.DBPath()), uint64(cfg.Admin.MinFreeSpace)<<20))
 
-	// Register UI route for client‑side Go‑app components when running in the browser
-	app.Route("/", func() app.Composer { return &ui.App{} })
-	app.RunWhenOnBrowser()
+	// Simultaneous editing sessions, persisted through the repository
+	hub := collab.NewHub(repo, collab.DefaultSaveDelay)
+	collabAPI := handlers.NewCollabHandler(hub)
 
 	// Router / middleware stack
 	r := chi.NewRouter()
+	if m != nil {
+		r.Use(m.Middleware)
+	}
+	r.Use(tracing.Middleware)
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
+	r.Use(security.RealIP(cfg.Security.Proxies()))
+	r.Use(logging.Requests(logger))
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
+	if cfg.TLSEnabled() && cfg.TLS.HSTS {
+		r.Use(middleware.SetHeader("Strict-Transport-Security", fmt.Sprintf("max-age=%d", int64(cfg.TLS.HSTSMaxAge.Seconds()))))
+	}
+	r.Use(security.Headers(cfg.Security.ContentSecurityPolicy))
+	var csrf *security.CSRF
+	if cfg.Security.CSRF {
+		csrf = security.NewCSRF(cfg.TLSEnabled(), handlers.CSRFFailed)
+		r.Use(csrf.Issue)
+	}
 
-	// Serve the UI (root path)
-	r.Handle("/", &app.Handler{
-		Name:        "Gokeep",
-		Title:       "Gokeep",
-		Description: "A minimalist note‑taking app written 100 % in Go",
-		Styles: []string{
-			"/web/app.css", // optional – remove if you do not ship CSS yet
-		},
-		Scripts: []string{
-			"/web/app.js", // optional – remove if you do not ship JS yet
-		},
-		CacheableResources: []string{
-			"/web/app.css",
-			"/web/app.js",
-		},
-	})
+	// Wire up JSON API underneath /api, throttled per client
+	lim := newLimits(cfg.RateLimit)
+	setupAPIRoutes(r, cfg, lim, csrf, api, collabAPI)
 
-	// Serve static assets that the Go‑app bundle references (favicon, CSS, etc.)
-	r.Handle("/web/*", http.FileServer(http.FS(webFS)))
+	if m != nil {
+		r.Method(http.MethodGet, "/metrics", m.Handler())
+	}
+	r.Get("/healthz", probes.Live)
+	r.Get("/readyz", probes.Ready)
+	if cfg.Admin.Token != "" {
+		r.With(requireAdmin(cfg.Admin.Token, lim)).Get("/debug/info", debugInfoHandler(cfg, db))
+	}
 
-	// Wire up JSON API underneath /api
-	setupAPIRoutes(r, api)
+	// Serve the UI (root path) and its static assets
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(cfg.Timeouts.Request))
+		setupUIRoutes(r)
+	})
 
 	// HTTP server with graceful shutdown
 	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: r,
+		Addr:              cfg.Listen,
+		Handler:           r,
+		ReadHeaderTimeout: cfg.Timeouts.ReadHeader,
+		ReadTimeout:       cfg.Timeouts.Read,
+		WriteTimeout:      cfg.Timeouts.Write,
+		IdleTimeout:       cfg.Timeouts.Idle,
+	}
+	if cfg.TLSEnabled() {
+		tlsConfig, err := setupTLS(tasksCtx, cfg)
+		if err != nil {
+			fatal("failed to set up TLS", "error", err)
+		}
+		srv.TLSConfig = tlsConfig
+	}
+
+	// Plain HTTP listener that only redirects to HTTPS
+	var redirectSrv *http.Server
+	if cfg.TLS.RedirectListen != "" {
+		redirectSrv = &http.Server{
+			Addr:              cfg.TLS.RedirectListen,
+			Handler:           redirectToHTTPS(cfg.Listen),
+			ReadHeaderTimeout: cfg.Timeouts.ReadHeader,
+		}
+		go func() {
+			slog.Info("redirecting to HTTPS", "url", "http://"+displayAddr(redirectSrv.Addr))
+			if err := redirectSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("redirect server stopped unexpectedly", "error", err)
+			}
+		}()
 	}
 
 	// Run server in background goroutine so we can listen for OS signals
 	go func() {
-		log.Printf("Gokeep listening on http://localhost%s", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("http server stopped unexpectedly: %v", err)
+		var err error
+		if cfg.TLSEnabled() {
+			slog.Info("Gokeep listening", "url", "https://"+displayAddr(srv.Addr))
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			slog.Info("Gokeep listening", "url", "http://"+displayAddr(srv.Addr))
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("http server stopped unexpectedly", "error", err)
 		}
 	}()
 
@@ -96,32 +258,126 @@ func main() {
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 	<-sig
 
-	log.Println("shutdown signal received – stopping …")
+	slog.Info("shutdown signal received – stopping …")
+
+	// Fail readiness first, so traffic is moved away before connections close
+	probes.Drain()
+	if cfg.Timeouts.Drain > 0 {
+		slog.Info("draining", "duration", cfg.Timeouts.Drain)
+		time.Sleep(cfg.Timeouts.Drain)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Shutdown)
 	defer cancel()
+	if redirectSrv != nil {
+		redirectSrv.Shutdown(ctx)
+	}
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("graceful shutdown failed: %v", err)
+		slog.Error("graceful shutdown failed", "error", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
 	}
 }
 
+// fatal logs an error that stops the server and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// setupUIRoutes registers the go-app page and the embedded web assets.
+func setupUIRoutes(r chi.Router) {
+	r.Handle("/", &app.Handler{
+		Name:        "Gokeep",
+		Title:       "Gokeep",
+		Description: "A minimalist note‑taking app written 100 % in Go",
+		Styles: []string{
+			"/web/app.css", // optional – remove if you do not ship CSS yet
+		},
+		Scripts: []string{
+			"/web/app.js", // optional – remove if you do not ship JS yet
+		},
+		CacheableResources: []string{
+			"/web/app.css",
+			"/web/app.js",
+		},
+	})
+
+	// Serve static assets that the Go‑app bundle references (favicon, CSS, etc.)
+	r.Handle("/web/*", http.FileServer(http.FS(webFS)))
+}
+
 // setupAPIRoutes registers /api/... endpoints backed by the API handler.
-func setupAPIRoutes(r chi.Router, h *handlers.APIHandler) {
+// Writes from browsers need a CSRF token unless csrf is nil.
+func setupAPIRoutes(r chi.Router, cfg *config.Config, lim *limits, csrf *security.CSRF, h *handlers.APIHandler, c *handlers.CollabHandler) {
 	r.Route("/api", func(r chi.Router) {
-		r.Use(middleware.SetHeader("Content‑Type", "application/json"))
+		r.NotFound(handlers.NotFound)
+		r.MethodNotAllowed(handlers.MethodNotAllowed)
+		if csrf != nil {
+			r.Use(csrf.Verify)
+		}
+		r.Use(lim.middleware(lim.writes, ratelimit.IsWrite))
 
-		r.Route("/notes", func(r chi.Router) {
-			r.Get("/", h.GetAllNotes)
-			r.Post("/", h.CreateNote)
+		// Long-lived connections, so no request timeout:
+		// live change stream (Server-Sent Events) and collaborative editing (WebSocket)
+		if cfg.Features.Events {
+			r.Get("/events", h.Events)
+		}
+		if cfg.Features.Collab {
+			r.Get("/notes/{id}/collab", c.Edit)
+		}
 
-			// Search endpoint: /api/notes/search?q=foo
-			r.Get("/search", h.SearchNotes)
+		// Imports and exports may take longer than the usual timeout
+		if cfg.Features.Import {
+			r.Post("/import", h.ImportMarkdown)
+			r.Post("/import/keep", h.ImportKeep)
+			r.Post("/import/enex", h.ImportENEX)
+		}
+		if cfg.Features.Export {
+			r.Get("/export", h.Export)
+			r.Get("/export.pdf", h.ExportPDF)
+		}
 
-			r.Route("/{id}", func(r chi.Router) {
-				r.Get("/", h.GetNote)
-				r.Put("/", h.UpdateNote)
-				r.Delete("/", h.DeleteNote)
-			})
+		// Attachment files carry their own content type
+		r.Get("/attachments/{id}", h.GetAttachment)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Timeout(cfg.Timeouts.Request))
+			r.Use(middleware.SetHeader("Content‑Type", "application/json"))
+			setupNoteRoutes(r, cfg, lim, h)
+
+			// Delta sync: /api/sync?since=<seq>
+			r.Get("/sync", h.Sync)
 		})
 	})
 }
+
+// setupNoteRoutes registers the /api/notes resource.
+func setupNoteRoutes(r chi.Router, cfg *config.Config, lim *limits, h *handlers.APIHandler) {
+	r.Route("/notes", func(r chi.Router) {
+		r.Get("/", h.GetAllNotes)
+		r.Post("/", h.CreateNote)
+
+		// Search endpoint: /api/notes/search?q=foo
+		r.With(lim.middleware(lim.search, nil)).Get("/search", h.SearchNotes)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.GetNote)
+			r.Put("/", h.UpdateNote)
+			r.Delete("/", h.DeleteNote)
+			if cfg.Features.Export {
+				r.Get("/export.pdf", h.ExportNotePDF)
+			}
+		})
+	})
+}
+
+// displayAddr turns a listen address into one that can be opened in a
+// browser, e.g. ":8080" into "localhost:8080"
+func displayAddr(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
@@ -0,0 +1,10 @@
+package main
+
+import "github.com/maxence-charriere/go-app/v10/pkg/app"
+
+// main runs the UI in the browser. The server and the other commands are
+// left out of the wasm build.
+func main() {
+	registerRoutes()
+	app.RunWhenOnBrowser()
+}
@@ -0,0 +1,492 @@
+//go:build !js
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/internal/exporter"
+	"github.com/Smil3MoreGH/gokeep/pkg/client"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+const noteUsage = `usage: gokeep note <command> [flags]
+
+commands:
+  list   [--all]                                    list notes
+  show   <id>                                       print a note
+  add    [--title t] [--color c] [--label l] [text] create a note, reading stdin if no text is given
+  edit   <id>                                       edit a note in $EDITOR
+  rm     [--trash] <id>...                          delete notes or move them to the trash
+  search <query>                                    full-text search
+
+flags of every command:
+  --server url        server to talk to (default $GOKEEP_SERVER or http://localhost:8080)
+  --ca-file file      CA certificate to trust, e.g. tls/ca.pem of a self-signed server
+  --output format     table, json or markdown`
+
+// noteFlags are the flags shared by the note commands
+type noteFlags struct {
+	server string
+	caFile string
+	output string
+}
+
+func newNoteFlagSet(name, output string) (*flag.FlagSet, *noteFlags) {
+	server := os.Getenv("GOKEEP_SERVER")
+	if server == "" {
+		server = "http://localhost:8080"
+	}
+
+	nf := &noteFlags{}
+	fs := flag.NewFlagSet("note "+name, flag.ExitOnError)
+	fs.StringVar(&nf.server, "server", server, "server to talk to")
+	fs.StringVar(&nf.caFile, "ca-file", "", "CA certificate to trust")
+	fs.StringVar(&nf.output, "output", output, "output format: table, json or markdown")
+	return fs, nf
+}
+
+// client returns an API client for the chosen server
+func (nf *noteFlags) client() *client.Client {
+	switch nf.output {
+	case "table", "json", "markdown":
+	default:
+		log.Fatalf("unknown output format %q (expected table, json or markdown)", nf.output)
+	}
+
+	return apiClient(nf.server, nf.caFile)
+}
+
+// apiClient returns a client for server that also trusts the CA in caFile,
+// if one is given
+func apiClient(server, caFile string) *client.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			log.Fatalf("failed to read CA certificate: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			log.Fatalf("no certificates found in %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return client.New(server, client.WithHTTPClient(&http.Client{Transport: transport, Timeout: 30 * time.Second}))
+}
+
+// stringList collects a flag that may be given several times
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// runNote implements "gokeep note list|show|add|edit|rm|search", which work
+// against a running server
+func runNote(args []string) {
+	if len(args) == 0 {
+		log.Fatal(noteUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch args[0] {
+	case "list", "ls":
+		err = runNoteList(ctx, args[1:])
+	case "show":
+		err = runNoteShow(ctx, args[1:])
+	case "add":
+		err = runNoteAdd(ctx, args[1:])
+	case "edit":
+		err = runNoteEdit(ctx, args[1:])
+	case "rm":
+		err = runNoteRemove(ctx, args[1:])
+	case "search":
+		err = runNoteSearch(ctx, args[1:])
+	default:
+		log.Fatal(noteUsage)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runNoteList(ctx context.Context, args []string) error {
+	fs, nf := newNoteFlagSet("list", "table")
+	all := fs.Bool("all", false, "include archived and trashed notes")
+	fs.Parse(args)
+	c := nf.client()
+
+	notes, err := c.ListNotes(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Same order and selection as the web UI unless --all is given
+	visible := notes[:0]
+	for _, note := range notes {
+		if *all || (!note.Archived && !note.Trashed) {
+			visible = append(visible, note)
+		}
+	}
+	sort.SliceStable(visible, func(i, j int) bool {
+		return visible[i].Pinned && !visible[j].Pinned
+	})
+	return printNotes(os.Stdout, visible, nf.output)
+}
+
+func runNoteShow(ctx context.Context, args []string) error {
+	fs, nf := newNoteFlagSet("show", "markdown")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: gokeep note show <id>")
+	}
+	c := nf.client()
+
+	id, err := parseNoteID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	note, err := c.GetNote(ctx, id)
+	if err != nil {
+		return err
+	}
+	return printNote(os.Stdout, *note, nf.output)
+}
+
+func runNoteAdd(ctx context.Context, args []string) error {
+	fs, nf := newNoteFlagSet("add", "table")
+	title := fs.String("title", "", "title of the note")
+	color := fs.String("color", "", "color name or value, e.g. yellow or #fff475")
+	pinned := fs.Bool("pin", false, "pin the note")
+	var labels stringList
+	fs.Var(&labels, "label", "label to add (repeatable)")
+	fs.Parse(args)
+	c := nf.client()
+
+	var note models.Note
+	if fs.NArg() > 0 {
+		note.Content = strings.Join(fs.Args(), " ")
+	} else {
+		if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+			return fmt.Errorf("no text given; pass it as arguments or pipe it on stdin")
+		}
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		// Markdown with front matter, e.g. from "gokeep note show"
+		if bytes.HasPrefix(data, []byte("---\n")) {
+			parsed, _, err := exporter.ParseNote("stdin", data)
+			if err != nil {
+				return err
+			}
+			// A new note, even when the text came from an existing one
+			parsed.ID = 0
+			parsed.CreatedAt, parsed.UpdatedAt = time.Time{}, time.Time{}
+			note = parsed
+		} else {
+			note.Content = string(data)
+		}
+	}
+
+	if *title != "" {
+		note.Title = *title
+	}
+	if *color != "" {
+		value, ok := models.ParseColor(*color)
+		if !ok {
+			return fmt.Errorf("unknown color %q", *color)
+		}
+		note.Color = value
+	}
+	if *pinned {
+		note.Pinned = true
+	}
+	note.Labels = append(note.Labels, labels...)
+
+	created, err := c.CreateNote(ctx, &note)
+	if err != nil {
+		return err
+	}
+	return printNote(os.Stdout, *created, nf.output)
+}
+
+func runNoteEdit(ctx context.Context, args []string) error {
+	fs, nf := newNoteFlagSet("edit", "table")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: gokeep note edit <id>")
+	}
+	c := nf.client()
+
+	id, err := parseNoteID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	note, err := c.GetNote(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	original, err := exporter.MarshalNote(*note)
+	if err != nil {
+		return err
+	}
+	edited, path, err := editInEditor(id, original)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(edited, original) {
+		os.Remove(path)
+		fmt.Println("No changes")
+		return nil
+	}
+
+	if bytes.HasPrefix(edited, []byte("---\n")) {
+		parsed, _, err := exporter.ParseNote(path, edited)
+		if err != nil {
+			return fmt.Errorf("%w (your changes are kept in %s)", err, path)
+		}
+		note.Title = parsed.Title
+		note.Content = parsed.Content
+		if parsed.Color != "" {
+			note.Color = parsed.Color
+		}
+		note.Labels = parsed.Labels
+		note.Pinned = parsed.Pinned
+		note.Archived = parsed.Archived
+		note.Trashed = parsed.Trashed
+	} else {
+		// The front matter was removed, so only the text changed
+		note.Content = string(edited)
+	}
+
+	updated, err := c.UpdateNote(ctx, note)
+	if err != nil {
+		return fmt.Errorf("%w (your changes are kept in %s)", err, path)
+	}
+	os.Remove(path)
+	return printNote(os.Stdout, *updated, nf.output)
+}
+
+// editInEditor opens data in $VISUAL or $EDITOR and returns the edited text
+// together with the temporary file holding it
+func editInEditor(id int64, data []byte) ([]byte, string, error) {
+	f, err := os.CreateTemp("", fmt.Sprintf("gokeep-%d-*.md", id))
+	if err != nil {
+		return nil, "", err
+	}
+	path := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return nil, "", err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// The editor may come with arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(path)
+		return nil, "", fmt.Errorf("editor failed: %w", err)
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return edited, path, nil
+}
+
+func runNoteRemove(ctx context.Context, args []string) error {
+	fs, nf := newNoteFlagSet("rm", "table")
+	trash := fs.Bool("trash", false, "move the notes to the trash instead of deleting them")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: gokeep note rm [--trash] <id>...")
+	}
+	c := nf.client()
+
+	for _, arg := range fs.Args() {
+		id, err := parseNoteID(arg)
+		if err != nil {
+			return err
+		}
+
+		if !*trash {
+			if err := c.DeleteNote(ctx, id); err != nil {
+				return fmt.Errorf("note %d: %w", id, err)
+			}
+			fmt.Printf("Deleted note %d\n", id)
+			continue
+		}
+
+		note, err := c.GetNote(ctx, id)
+		if err != nil {
+			return fmt.Errorf("note %d: %w", id, err)
+		}
+		note.Trashed = true
+		if _, err := c.UpdateNote(ctx, note); err != nil {
+			return fmt.Errorf("note %d: %w", id, err)
+		}
+		fmt.Printf("Moved note %d to the trash\n", id)
+	}
+	return nil
+}
+
+func runNoteSearch(ctx context.Context, args []string) error {
+	fs, nf := newNoteFlagSet("search", "table")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: gokeep note search <query>")
+	}
+	c := nf.client()
+
+	notes, err := c.SearchNotes(ctx, strings.Join(fs.Args(), " "))
+	if err != nil {
+		return err
+	}
+	return printNotes(os.Stdout, notes, nf.output)
+}
+
+func parseNoteID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid note ID %q", s)
+	}
+	return id, nil
+}
+
+// printNotes writes notes in the chosen output format
+func printNotes(w io.Writer, notes []models.Note, output string) error {
+	switch output {
+	case "json":
+		return writeJSON(w, notes)
+	case "markdown":
+		for i, note := range notes {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			if err := writeMarkdown(w, note); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return writeTable(w, notes)
+	}
+}
+
+// printNote writes a single note; JSON output is an object, not a list
+func printNote(w io.Writer, note models.Note, output string) error {
+	if output == "json" {
+		return writeJSON(w, note)
+	}
+	return printNotes(w, []models.Note{note}, output)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeMarkdown(w io.Writer, note models.Note) error {
+	data, err := exporter.MarshalNote(note)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func writeTable(w io.Writer, notes []models.Note) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTITLE\tLABELS\tSTATUS\tUPDATED")
+	for _, note := range notes {
+		title := note.Title
+		if title == "" {
+			title = firstLine(note.Content)
+		}
+
+		var status []string
+		if note.Pinned {
+			status = append(status, "pinned")
+		}
+		if note.Archived {
+			status = append(status, "archived")
+		}
+		if note.Trashed {
+			status = append(status, "trashed")
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n",
+			note.ID,
+			truncate(title, 40),
+			truncate(strings.Join(note.Labels, ", "), 30),
+			strings.Join(status, ","),
+			note.UpdatedAt.Local().Format("2006-01-02 15:04"),
+		)
+	}
+	return tw.Flush()
+}
+
+// firstLine returns the first non-empty line of text
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,40 @@
+//go:build !js
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/Smil3MoreGH/gokeep/internal/config"
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/internal/exporter"
+)
+
+const publishUsage = "usage: gokeep publish [--label public] [--title gokeep] [--out site]"
+
+// runPublish implements "gokeep publish", rendering the notes with a label
+// as a static website
+func runPublish(args []string) {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	label := fs.String("label", "public", "publish the notes with this label")
+	title := fs.String("title", "gokeep", "title of the site")
+	out := fs.String("out", "site", "directory to write the site to")
+	flags := config.RegisterFlags(fs, dbKeys...)
+	fs.Parse(args)
+	if fs.NArg() != 0 || *label == "" || *out == "" {
+		log.Fatal(publishUsage)
+	}
+
+	db := openDB(loadConfig(flags))
+	defer db.Close()
+
+	repo := database.NewNoteRepository(db, nil)
+	n, err := exporter.Publish(context.Background(), repo, exporter.DirWriter(*out), exporter.SiteOptions{Label: *label, Title: *title})
+	if err != nil {
+		log.Fatalf("publish failed: %v", err)
+	}
+	fmt.Printf("Published %d notes labelled %q to %s\n", n, *label, *out)
+}
@@ -0,0 +1,77 @@
+//go:build !js
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/Smil3MoreGH/gokeep/internal/certs"
+	"github.com/Smil3MoreGH/gokeep/internal/config"
+)
+
+// setupTLS returns the server's TLS configuration, generating a local CA
+// and certificate first if asked to. The certificate is reloaded whenever its
+// files change, and a self-signed one renewed before it expires, until ctx
+// is cancelled.
+func setupTLS(ctx context.Context, cfg *config.Config) (*tls.Config, error) {
+	certFile, keyFile := cfg.TLS.CertFile, cfg.TLS.KeyFile
+	var hosts []string
+	if cfg.TLS.SelfSigned {
+		hosts = certs.DefaultHosts()
+		for _, host := range strings.Split(cfg.TLS.Hosts, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+
+		var err error
+		certFile, keyFile, err = certs.EnsureSelfSigned(cfg.TLSDir(), hosts)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("using a self-signed certificate; install the CA on your devices to trust it",
+			"hosts", strings.Join(hosts, ", "), "ca", filepath.Join(cfg.TLSDir(), certs.CAFile))
+	}
+
+	reloader, err := certs.NewReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := reloader.Watch(ctx); err != nil {
+			slog.Warn("certificate changes will not be picked up", "error", err)
+		}
+	}()
+	if cfg.TLS.SelfSigned {
+		go certs.RenewSelfSigned(ctx, cfg.TLSDir(), hosts, certs.RenewCheckInterval, reloader.Reload)
+	}
+
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}, nil
+}
+
+// redirectToHTTPS sends plain HTTP requests to the same URL on the HTTPS
+// listener at httpsAddr
+func redirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
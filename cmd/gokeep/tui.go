@@ -0,0 +1,55 @@
+//go:build !js
+
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Smil3MoreGH/gokeep/internal/config"
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/internal/tui"
+	"github.com/gdamore/tcell/v2"
+)
+
+const tuiUsage = "usage: gokeep tui [--server url [--ca-file file]] [--config file] [--data-dir dir] [--database-path file]"
+
+// runTUI implements "gokeep tui", a full-screen terminal UI that works on
+// the local database or, with --server, through the API of a running server
+func runTUI(args []string) {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	server := fs.String("server", "", "server to talk to instead of opening the database")
+	caFile := fs.String("ca-file", "", "CA certificate to trust, e.g. tls/ca.pem of a self-signed server")
+	flags := config.RegisterFlags(fs, dbKeys...)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		log.Fatal(tuiUsage)
+	}
+
+	var store tui.Store
+	if *server != "" {
+		store = tui.NewRemoteStore(apiClient(*server, *caFile), *server)
+	} else {
+		cfg := loadConfig(flags)
+		db := openDB(cfg)
+		defer db.Close()
+		store = tui.NewLocalStore(database.NewNoteRepository(db, nil), cfg.DBPath())
+	}
+
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		log.Fatalf("failed to open terminal: %v", err)
+	}
+
+	// The note being edited is saved before the UI is stopped
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := tui.New(store).Run(ctx, screen); err != nil {
+		log.Fatal(err)
+	}
+}
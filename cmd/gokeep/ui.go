@@ -0,0 +1,13 @@
+package main
+
+import (
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+
+	"github.com/Smil3MoreGH/gokeep/internal/ui"
+)
+
+// registerRoutes maps paths to UI components, for the browser as well as
+// for the server's app handler
+func registerRoutes() {
+	app.Route("/", func() app.Composer { return &ui.App{} })
+}
@@ -0,0 +1,178 @@
+// internal/backup/backup.go
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+)
+
+// filePrefix and fileSuffix frame the names of scheduled backups, e.g.
+// gokeep-20240101-120000.db
+const (
+	filePrefix = "gokeep-"
+	fileSuffix = ".db"
+)
+
+// FileName returns the name of a backup taken at t
+func FileName(t time.Time) string {
+	return filePrefix + t.Format("20060102-150405") + fileSuffix
+}
+
+// Create writes a snapshot of db to path and verifies it
+func Create(ctx context.Context, db *database.DB, path string) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+	}
+	if err := db.BackupTo(ctx, path); err != nil {
+		return err
+	}
+	if _, err := database.VerifyFile(ctx, path); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("backup failed verification: %w", err)
+	}
+	return nil
+}
+
+// now is the clock naming the copies kept by restores
+var now = time.Now
+
+// journalSuffixes name the files SQLite keeps next to a database
+var journalSuffixes = []string{"-wal", "-shm", "-journal"}
+
+// Restore replaces the database at dbPath with the SQLite backup at src,
+// after checking the backup's integrity. The server must not be running.
+// The replaced database is kept next to it under the returned name, with a
+// .pre-restore suffix and the time of the restore; kept is "" if there was
+// no database yet.
+func Restore(ctx context.Context, src, dbPath string) (kept string, err error) {
+	if _, err := database.VerifyFile(ctx, src); err != nil {
+		return "", fmt.Errorf("refusing to restore %s: %w", src, err)
+	}
+
+	tmp := dbPath + ".restore-tmp"
+	if err := copyFile(src, tmp); err != nil {
+		return "", fmt.Errorf("failed to copy backup: %w", err)
+	}
+
+	// Bring the copy up to the current schema before it goes live, with its
+	// changes numbered after those clients have already synced
+	db, err := database.NewDB(tmp)
+	if err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to open backup: %w", err)
+	}
+	err = db.ContinueSync(ctx, dbPath)
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	return swap(tmp, dbPath)
+}
+
+// swap moves the database at tmp into place at dbPath. The current
+// database and its journal files are moved aside together, so that nothing
+// written to the WAL is lost, and never over the copy kept by an earlier
+// restore. It returns the name the current database was kept under.
+func swap(tmp, dbPath string) (string, error) {
+	var kept string
+	if _, err := os.Stat(dbPath); err == nil {
+		kept = dbPath + ".pre-restore-" + now().Format("20060102-150405")
+		if err := moveAside(dbPath, kept); err != nil {
+			os.Remove(tmp)
+			return "", fmt.Errorf("failed to move current database aside: %w", err)
+		}
+	} else {
+		// Journal files without a database belong to nothing
+		for _, suffix := range journalSuffixes {
+			os.Remove(dbPath + suffix)
+		}
+	}
+
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return kept, fmt.Errorf("failed to move restored database into place: %w", err)
+	}
+	return kept, nil
+}
+
+// moveAside renames the database at path and its journal files to dst,
+// refusing to replace anything already there
+func moveAside(path, dst string) error {
+	for _, suffix := range append([]string{""}, journalSuffixes...) {
+		if _, err := os.Lstat(dst + suffix); err == nil {
+			return fmt.Errorf("%s already exists", dst+suffix)
+		}
+	}
+	if err := os.Rename(path, dst); err != nil {
+		return err
+	}
+	for _, suffix := range journalSuffixes {
+		if err := os.Rename(path+suffix, dst+suffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rotate deletes the oldest scheduled backups in dir so that at most keep
+// remain. Other files are left alone.
+func Rotate(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	// Names embed the timestamp, so ReadDir's sorted order is oldest first
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, fileSuffix) {
+			backups = append(backups, name)
+		}
+	}
+
+	for len(backups) > keep {
+		if err := os.Remove(filepath.Join(dir, backups[0])); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
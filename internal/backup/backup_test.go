@@ -0,0 +1,160 @@
+// internal/backup/backup_test.go
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/internal/database/dbtest"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// newDatabase creates a database at path holding one note titled title
+func newDatabase(t *testing.T, path, title string) {
+	t.Helper()
+	db := dbtest.OpenFile(t, path)
+	defer db.Close()
+	if err := database.NewNoteRepository(db, nil).Create(context.Background(), &models.Note{Title: title}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func title(t *testing.T, path string) string {
+	t.Helper()
+	db := dbtest.OpenFile(t, path)
+	defer db.Close()
+	notes, err := database.NewNoteRepository(db, nil).GetAll(context.Background())
+	if err != nil || len(notes) != 1 {
+		t.Fatalf("%s: %d notes, %v", path, len(notes), err)
+	}
+	return notes[0].Title
+}
+
+func TestRestoreKeepsEveryReplacedDatabase(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "gokeep.db")
+	newDatabase(t, dbPath, "original")
+
+	src := filepath.Join(dir, "backup.db")
+	newDatabase(t, src, "backup")
+
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+
+	// A stray WAL must move along with the database it belongs to
+	os.WriteFile(dbPath+"-wal", []byte("wal"), 0o644)
+
+	first, err := Restore(context.Background(), src, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dbPath + "-wal"); err == nil {
+		t.Error("the WAL of the replaced database is still next to the restored one")
+	}
+	if data, _ := os.ReadFile(first + "-wal"); string(data) != "wal" {
+		t.Error("the WAL was not kept with the replaced database")
+	}
+
+	// Restoring again at the same time must not replace the first copy
+	if _, err := Restore(context.Background(), src, dbPath); err == nil {
+		t.Error("second restore replaced the copy kept by the first")
+	}
+
+	clock = clock.Add(time.Minute)
+	second, err := Restore(context.Background(), src, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatalf("both restores kept the database as %s", first)
+	}
+	if got := title(t, first); got != "original" {
+		t.Errorf("first kept copy holds %q, want the original database", got)
+	}
+	if got := title(t, second); got != "backup" {
+		t.Errorf("second kept copy holds %q, want the first restore", got)
+	}
+	if got := title(t, dbPath); got != "backup" {
+		t.Errorf("restored database holds %q", got)
+	}
+}
+
+func TestRestoreContinuesSync(t *testing.T) {
+	ctx := context.Background()
+	restores := map[string]func(t *testing.T, src, dbPath string) error{
+		"backup": func(t *testing.T, src, dbPath string) error {
+			_, err := Restore(ctx, src, dbPath)
+			return err
+		},
+		"dump": func(t *testing.T, src, dbPath string) error {
+			db := dbtest.OpenFile(t, src)
+			defer db.Close()
+			dump := src + ".json"
+			f, err := os.Create(dump)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if err := WriteDump(ctx, f, database.NewNoteRepository(db, nil)); err != nil {
+				return err
+			}
+			_, _, err = RestoreDump(ctx, dump, dbPath)
+			return err
+		},
+	}
+	for name, restore := range restores {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "backup.db")
+			newDatabase(t, src, "backup")
+
+			// The live database has moved on well past the backup
+			dbPath := filepath.Join(dir, "gokeep.db")
+			db := dbtest.OpenFile(t, dbPath)
+			repo := database.NewNoteRepository(db, nil)
+			kept := &models.Note{Title: "kept"}
+			gone := &models.Note{Title: "gone"}
+			for _, note := range []*models.Note{kept, gone} {
+				if err := repo.Create(ctx, note); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for range 5 {
+				if err := repo.Update(ctx, gone); err != nil {
+					t.Fatal(err)
+				}
+			}
+			synced, err := repo.Changes(ctx, 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			db.Close()
+
+			if err := restore(t, src, dbPath); err != nil {
+				t.Fatal(err)
+			}
+
+			// A client that synced with the replaced database
+			db = dbtest.OpenFile(t, dbPath)
+			defer db.Close()
+			changes, err := database.NewNoteRepository(db, nil).Changes(ctx, synced.Seq, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changes.Seq <= synced.Seq {
+				t.Errorf("sequence went back from %d to %d", synced.Seq, changes.Seq)
+			}
+			if len(changes.Changed) != 1 || changes.Changed[0].ID != kept.ID || changes.Changed[0].Title != "backup" {
+				t.Errorf("changed %+v, want the restored note", changes.Changed)
+			}
+			if len(changes.Deleted) != 1 || changes.Deleted[0].ID != gone.ID {
+				t.Errorf("deleted %+v, want note %d", changes.Deleted, gone.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// internal/backup/dump.go
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/internal/exporter"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// DumpFormat and DumpVersion identify gokeep JSON dumps. The dump describes
+// notes rather than tables, so it does not change with the schema.
+const (
+	DumpFormat  = "gokeep-dump"
+	DumpVersion = 1
+)
+
+// dumpNote is a note together with the contents of its attachments
+type dumpNote struct {
+	models.Note
+	Attachments []dumpAttachment `json:"attachments,omitempty"`
+}
+
+type dumpAttachment struct {
+	models.Attachment
+	Data []byte `json:"data"`
+}
+
+// WriteDump writes every note of src as a JSON dump. Notes are encoded one
+// at a time, so the dump is never held in memory as a whole.
+func WriteDump(ctx context.Context, w io.Writer, src exporter.NoteSource) error {
+	notes, err := src.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	created, err := json.Marshal(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `{"format":%q,"version":%d,"created_at":%s,"notes":[`, DumpFormat, DumpVersion, created)
+
+	for i, note := range notes {
+		entry := dumpNote{Note: note}
+		for _, ref := range note.Attachments {
+			attachment, err := src.GetAttachment(ctx, ref.ID)
+			if err != nil {
+				return fmt.Errorf("failed to dump attachment %s: %w", ref.ID, err)
+			}
+			entry.Attachments = append(entry.Attachments, dumpAttachment{Attachment: *attachment, Data: attachment.Data})
+		}
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to dump note %d: %w", note.ID, err)
+		}
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteString("\n")
+		bw.Write(data)
+	}
+
+	bw.WriteString("\n]}\n")
+	return bw.Flush()
+}
+
+// ReadDump decodes a JSON dump note by note and passes each note, with its
+// attachments' data, to fn
+func ReadDump(r io.Reader, fn func(note *models.Note) error) (int, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	if err := expectDelim(dec, '{'); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	format := ""
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return count, fmt.Errorf("invalid dump: %w", err)
+		}
+		key, _ := tok.(string)
+
+		switch key {
+		case "format":
+			if err := dec.Decode(&format); err != nil || format != DumpFormat {
+				return count, fmt.Errorf("not a gokeep dump")
+			}
+		case "version":
+			var version int
+			if err := dec.Decode(&version); err != nil {
+				return count, fmt.Errorf("invalid dump version: %w", err)
+			}
+			if version > DumpVersion {
+				return count, fmt.Errorf("dump version %d is newer than this build supports (%d)", version, DumpVersion)
+			}
+		case "notes":
+			if format == "" {
+				return count, fmt.Errorf("not a gokeep dump")
+			}
+			if err := expectDelim(dec, '['); err != nil {
+				return count, err
+			}
+			for dec.More() {
+				var entry dumpNote
+				if err := dec.Decode(&entry); err != nil {
+					return count, fmt.Errorf("invalid note in dump: %w", err)
+				}
+				note := entry.Note
+				note.Attachments = nil
+				for _, a := range entry.Attachments {
+					attachment := a.Attachment
+					attachment.Data = a.Data
+					note.Attachments = append(note.Attachments, attachment)
+				}
+				if err := fn(&note); err != nil {
+					return count, err
+				}
+				count++
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return count, err
+			}
+		default:
+			// Fields added by later versions
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return count, fmt.Errorf("invalid dump: %w", err)
+			}
+		}
+	}
+
+	if format == "" {
+		return count, fmt.Errorf("not a gokeep dump")
+	}
+	return count, nil
+}
+
+// RestoreDump replaces the database at dbPath with a fresh one holding the
+// notes of the JSON dump at src. The server must not be running. The
+// replaced database is kept as by Restore.
+func RestoreDump(ctx context.Context, src, dbPath string) (count int, kept string, err error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	tmp := dbPath + ".restore-tmp"
+	os.Remove(tmp)
+	db, err := database.NewDB(tmp)
+	if err != nil {
+		return 0, "", err
+	}
+	repo := database.NewNoteRepository(db, nil)
+
+	count, err = ReadDump(f, func(note *models.Note) error {
+		return repo.Restore(ctx, note)
+	})
+	if err == nil {
+		err = db.ContinueSync(ctx, dbPath)
+	}
+	if err == nil {
+		err = db.IntegrityCheck(ctx)
+	}
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return count, "", fmt.Errorf("failed to restore dump: %w", err)
+	}
+
+	kept, err = swap(tmp, dbPath)
+	return count, kept, err
+}
+
+// IsDump reports whether the file at path looks like a JSON dump rather than
+// a SQLite database
+func IsDump(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return false, nil
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		default:
+			return b == '{', nil
+		}
+	}
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("invalid dump: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("invalid dump: expected %q", want)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// internal/backup/scheduler.go
+package backup
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts a span for every scheduled backup
+var tracer = otel.Tracer("github.com/Smil3MoreGH/gokeep/internal/backup")
+
+// Scheduler takes a backup at a fixed interval and keeps the newest ones
+type Scheduler struct {
+	DB       *database.DB
+	Dir      string
+	Interval time.Duration
+	Keep     int
+}
+
+// Run takes backups until ctx is cancelled. Failures are logged and retried
+// at the next interval.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			s.runOnce(ctx, t)
+		}
+	}
+}
+
+// runOnce takes one backup and rotates, in a span of its own
+func (s *Scheduler) runOnce(ctx context.Context, t time.Time) {
+	path := filepath.Join(s.Dir, FileName(t))
+	ctx, span := tracer.Start(ctx, "backup.scheduled", trace.WithAttributes(attribute.String("backup.path", path)))
+	defer span.End()
+
+	if err := Create(ctx, s.DB, path); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "scheduled backup failed", "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "backup written", "path", path)
+
+	if err := Rotate(s.Dir, s.Keep); err != nil {
+		span.RecordError(err)
+		slog.ErrorContext(ctx, "failed to rotate backups", "error", err)
+	}
+}
@@ -0,0 +1,243 @@
+// internal/certs/selfsigned.go
+package certs
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Files written by EnsureSelfSigned. CAFile is the one to install in the
+// browsers and devices that should trust the server.
+const (
+	CAFile    = "ca.pem"
+	CAKeyFile = "ca-key.pem"
+	CertFile  = "cert.pem"
+	KeyFile   = "key.pem"
+)
+
+const (
+	caValidity = 10 * 365 * 24 * time.Hour
+	// Browsers reject leaf certificates valid for more than 398 days
+	leafValidity = 397 * 24 * time.Hour
+	renewBefore  = 30 * 24 * time.Hour
+)
+
+// RenewCheckInterval is how often a running server checks whether its
+// self-signed certificate is due for renewal
+const RenewCheckInterval = 24 * time.Hour
+
+// EnsureSelfSigned makes sure dir holds a local CA and a certificate signed
+// by it for hosts, and returns the certificate and key files. The CA is
+// created once and kept, so it only has to be trusted once; the certificate
+// is issued again when it nears expiry or does not cover all hosts.
+func EnsureSelfSigned(dir string, hosts []string) (string, string, error) {
+	if _, err := ensureSelfSigned(dir, hosts); err != nil {
+		return "", "", err
+	}
+	return filepath.Join(dir, CertFile), filepath.Join(dir, KeyFile), nil
+}
+
+// RenewSelfSigned checks the certificate in dir every interval until ctx is
+// cancelled, and calls reload after issuing a new one. Failures are logged
+// and retried at the next interval.
+func RenewSelfSigned(ctx context.Context, dir string, hosts []string, interval time.Duration, reload func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			renewed, err := ensureSelfSigned(dir, hosts)
+			if err != nil {
+				slog.Error("failed to renew the self-signed certificate", "error", err)
+				continue
+			}
+			if !renewed {
+				continue
+			}
+			if err := reload(); err != nil {
+				slog.Error("keeping the current certificate", "error", err)
+				continue
+			}
+			slog.Info("renewed the self-signed certificate", "path", filepath.Join(dir, CertFile))
+		}
+	}
+}
+
+// ensureSelfSigned does the work of EnsureSelfSigned and reports whether a
+// new certificate was issued
+func ensureSelfSigned(dir string, hosts []string) (bool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return false, fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+
+	ca, caKey, err := loadOrCreateCA(dir)
+	if err != nil {
+		return false, err
+	}
+
+	certFile, keyFile := filepath.Join(dir, CertFile), filepath.Join(dir, KeyFile)
+	if leafUsable(certFile, keyFile, ca, hosts) {
+		return false, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return false, err
+	}
+	template, err := newTemplate("gokeep", leafValidity)
+	if err != nil {
+		return false, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, key.Public(), caKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to issue certificate: %w", err)
+	}
+	// Key first: a reload triggered by the certificate must find a matching key
+	if err := writeKey(keyFile, key); err != nil {
+		return false, err
+	}
+	if err := writePEM(certFile, "CERTIFICATE", der, 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DefaultHosts are the names a local server is usually reached by
+func DefaultHosts() []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if name, err := os.Hostname(); err == nil && name != "" && name != "localhost" {
+		hosts = append(hosts, name, name+".local")
+	}
+	return hosts
+}
+
+// loadOrCreateCA reads the CA from dir, creating it on first use
+func loadOrCreateCA(dir string) (*x509.Certificate, crypto.Signer, error) {
+	caFile, caKeyFile := filepath.Join(dir, CAFile), filepath.Join(dir, CAKeyFile)
+
+	if pair, err := tls.LoadX509KeyPair(caFile, caKeyFile); err == nil {
+		signer, ok := pair.PrivateKey.(crypto.Signer)
+		if !ok {
+			return nil, nil, fmt.Errorf("CA key in %s cannot sign", caKeyFile)
+		}
+		ca, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		return ca, signer, nil
+	} else if !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to load CA: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template, err := newTemplate("gokeep local CA", caValidity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CA: %w", err)
+	}
+	if err := writeKey(caKeyFile, key); err != nil {
+		return nil, nil, err
+	}
+	if err := writePEM(caFile, "CERTIFICATE", der, 0o644); err != nil {
+		return nil, nil, err
+	}
+
+	ca, err := x509.ParseCertificate(der)
+	return ca, key, err
+}
+
+// leafUsable reports whether the certificate in certFile was issued by ca,
+// matches its key, stays valid for a while and covers every host
+func leafUsable(certFile, keyFile string, ca *x509.Certificate, hosts []string) bool {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return false
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil || leaf.CheckSignatureFrom(ca) != nil {
+		return false
+	}
+	if time.Until(leaf.NotAfter) < renewBefore {
+		return false
+	}
+	for _, host := range hosts {
+		if leaf.VerifyHostname(host) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func newTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"gokeep"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+func writeKey(path string, key *ecdsa.PrivateKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	return writePEM(path, "PRIVATE KEY", der, 0o600)
+}
+
+// writePEM replaces path atomically, so a watcher never reads half a file
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// internal/certs/selfsigned_test.go
+package certs
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+func TestRenewSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, err := EnsureSelfSigned(dir, []string{"old.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloader, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renew := func(hosts []string, wait time.Duration) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), wait)
+		defer cancel()
+		reloaded := make(chan struct{}, 1)
+		go RenewSelfSigned(ctx, dir, hosts, time.Millisecond, func() error {
+			err := reloader.Reload()
+			reloaded <- struct{}{}
+			return err
+		})
+		select {
+		case <-reloaded:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// A certificate that is still good is left alone
+	if renew([]string{"old.test"}, 50*time.Millisecond) {
+		t.Error("renewed a certificate that is still good")
+	}
+
+	// One that no longer fits is issued again and served without a restart
+	if !renew([]string{"new.test"}, 5*time.Second) {
+		t.Fatal("certificate was not renewed")
+	}
+	cert, err := reloader.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := leaf.VerifyHostname("new.test"); err != nil {
+		t.Errorf("serving the old certificate: %v", err)
+	}
+}
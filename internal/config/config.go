@@ -0,0 +1,338 @@
+// internal/config/config.go
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config is the effective server configuration. Every field has a key made
+// of its yaml tags, e.g. "database.path", which also names its environment
+// variable (GOKEEP_DATABASE_PATH) and its flag (--database-path).
+type Config struct {
+	Listen   string         `yaml:"listen" toml:"listen" usage:"address the HTTP server listens on"`
+	DataDir  string         `yaml:"data_dir" toml:"data_dir" usage:"directory that relative data paths are resolved against"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Timeouts TimeoutConfig  `yaml:"timeouts" toml:"timeouts"`
+	Log      LogConfig      `yaml:"log" toml:"log"`
+	TLS      TLSConfig      `yaml:"tls" toml:"tls"`
+	Backup   BackupConfig   `yaml:"backup" toml:"backup"`
+	Features FeatureConfig  `yaml:"features" toml:"features"`
+	Admin    AdminConfig    `yaml:"admin" toml:"admin"`
+	Tracing  TracingConfig  `yaml:"tracing" toml:"tracing"`
+	Import   ImportConfig   `yaml:"import" toml:"import"`
+
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Security  SecurityConfig  `yaml:"security" toml:"security"`
+
+	// Source is the config file that was read, if any
+	Source string `yaml:"-" toml:"-"`
+}
+
+// DatabaseConfig locates the SQLite database and sizes its connection pool
+type DatabaseConfig struct {
+	Path            string        `yaml:"path" toml:"path" usage:"SQLite database file"`
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns" usage:"maximum number of open database connections"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns" usage:"maximum number of idle database connections"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" usage:"maximum time a database connection is reused"`
+	SlowQuery       time.Duration `yaml:"slow_query" toml:"slow_query" usage:"log database operations that take longer than this (0 = never)"`
+}
+
+// TimeoutConfig bounds requests and connections. Write stays zero by
+// default because the event stream, collaborative editing and large imports
+// and exports hold connections open.
+type TimeoutConfig struct {
+	Request    time.Duration `yaml:"request" toml:"request" usage:"time limit for API and page requests"`
+	ReadHeader time.Duration `yaml:"read_header" toml:"read_header" usage:"time limit for reading request headers"`
+	Read       time.Duration `yaml:"read" toml:"read" usage:"time limit for reading a whole request (0 = none)"`
+	Write      time.Duration `yaml:"write" toml:"write" usage:"time limit for writing a response (0 = none)"`
+	Idle       time.Duration `yaml:"idle" toml:"idle" usage:"time an idle keep-alive connection is kept open"`
+	Shutdown   time.Duration `yaml:"shutdown" toml:"shutdown" usage:"time allowed for open requests to finish on shutdown"`
+	Drain      time.Duration `yaml:"drain" toml:"drain" usage:"time /readyz fails before shutdown starts, so load balancers stop sending traffic"`
+}
+
+// AdminConfig protects the diagnostics endpoints
+type AdminConfig struct {
+	Token        string        `yaml:"token" toml:"token" secret:"true" usage:"bearer token for /debug/info (disabled if empty)"`
+	MinFreeSpace int           `yaml:"min_free_space" toml:"min_free_space" usage:"free disk space in MiB below which /readyz fails"`
+	IndexCheck   time.Duration `yaml:"index_check" toml:"index_check" usage:"how often the search index is compared with the notes for /readyz"`
+}
+
+// RateLimitConfig throttles API clients with token buckets, one per IP
+// address and one per bearer token, with separate budgets for writes,
+// searches and authentication attempts
+type RateLimitConfig struct {
+	Enabled     bool          `yaml:"enabled" toml:"enabled" usage:"limit how fast clients may call the API"`
+	WriteRate   float64       `yaml:"write_rate" toml:"write_rate" usage:"writes per second allowed per client"`
+	WriteBurst  int           `yaml:"write_burst" toml:"write_burst" usage:"writes a client may send at once"`
+	SearchRate  float64       `yaml:"search_rate" toml:"search_rate" usage:"searches per second allowed per client"`
+	SearchBurst int           `yaml:"search_burst" toml:"search_burst" usage:"searches a client may send at once"`
+	AuthRate    float64       `yaml:"auth_rate" toml:"auth_rate" usage:"authentication attempts per second allowed per client"`
+	AuthBurst   int           `yaml:"auth_burst" toml:"auth_burst" usage:"authentication attempts a client may make at once"`
+	Lockout     time.Duration `yaml:"lockout" toml:"lockout" usage:"lockout after a failed authentication, doubled with every further failure"`
+	MaxLockout  time.Duration `yaml:"max_lockout" toml:"max_lockout" usage:"longest lockout after failed authentications"`
+}
+
+// SecurityConfig hardens the browser UI against injected content and
+// requests forged by other sites
+type SecurityConfig struct {
+	ContentSecurityPolicy string `yaml:"content_security_policy" toml:"content_security_policy" usage:"Content-Security-Policy header of every response (not sent if empty)"`
+	CSRF                  bool   `yaml:"csrf" toml:"csrf" usage:"require a CSRF token on API writes from browsers"`
+	TrustedProxies        string `yaml:"trusted_proxies" toml:"trusted_proxies" usage:"comma-separated addresses or CIDR ranges of reverse proxies whose X-Forwarded-For and X-Real-IP headers are believed"`
+}
+
+// Proxies returns the trusted proxies; Validate has checked that they parse
+func (s SecurityConfig) Proxies() []netip.Prefix {
+	proxies, _ := parseProxies(s.TrustedProxies)
+	return proxies
+}
+
+// parseProxies reads a comma-separated list of addresses and CIDR ranges
+func parseProxies(list string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if strings.Contains(s, "/") {
+			p, err := netip.ParsePrefix(s)
+			if err != nil {
+				return nil, err
+			}
+			proxies = append(proxies, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies, nil
+}
+
+// TracingConfig exports OpenTelemetry traces. The standard OTEL_EXPORTER_OTLP_*
+// variables apply where the settings are left empty.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter" usage:"trace exporter: none, otlp or stdout"`
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint" usage:"OTLP/HTTP collector address, e.g. localhost:4318"`
+	Insecure    bool    `yaml:"insecure" toml:"insecure" usage:"send OTLP traces over plain HTTP"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" usage:"fraction of new traces to record, 0 to 1"`
+}
+
+// LogConfig controls logging
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level" usage:"log level: debug, info, warn or error"`
+	Format string `yaml:"format" toml:"format" usage:"log format: json or text"`
+}
+
+// TLSConfig enables HTTPS, either with a given certificate and key or with
+// a generated local CA
+type TLSConfig struct {
+	CertFile       string        `yaml:"cert_file" toml:"cert_file" usage:"TLS certificate file (enables HTTPS, reloaded on change)"`
+	KeyFile        string        `yaml:"key_file" toml:"key_file" usage:"TLS private key file"`
+	SelfSigned     bool          `yaml:"self_signed" toml:"self_signed" usage:"serve HTTPS with a certificate from a generated local CA"`
+	Dir            string        `yaml:"dir" toml:"dir" usage:"directory for the generated CA and certificate"`
+	Hosts          string        `yaml:"hosts" toml:"hosts" usage:"comma-separated extra host names and IPs for the generated certificate"`
+	RedirectListen string        `yaml:"redirect_listen" toml:"redirect_listen" usage:"address of a plain HTTP listener that redirects to HTTPS (disabled if empty)"`
+	HSTS           bool          `yaml:"hsts" toml:"hsts" usage:"send Strict-Transport-Security over HTTPS"`
+	HSTSMaxAge     time.Duration `yaml:"hsts_max_age" toml:"hsts_max_age" usage:"how long browsers should insist on HTTPS"`
+}
+
+// BackupConfig schedules backups
+type BackupConfig struct {
+	Dir      string        `yaml:"dir" toml:"dir" usage:"directory for scheduled backups (disabled if empty)"`
+	Interval time.Duration `yaml:"interval" toml:"interval" usage:"time between scheduled backups"`
+	Keep     int           `yaml:"keep" toml:"keep" usage:"number of scheduled backups to keep"`
+}
+
+// ImportConfig bounds uploads to /api/import. Zip archives are spooled
+// to disk and always capped at 1 GiB; ENEX exports are streamed, so they
+// may be as large as the notes they hold.
+type ImportConfig struct {
+	ENEXMaxSize int `yaml:"enex_max_size" toml:"enex_max_size" usage:"largest ENEX upload in MiB (0 = unlimited)"`
+}
+
+// FeatureConfig switches optional parts of the API on or off
+type FeatureConfig struct {
+	Events  bool `yaml:"events" toml:"events" usage:"serve the live change stream at /api/events"`
+	Collab  bool `yaml:"collab" toml:"collab" usage:"allow collaborative editing over WebSocket"`
+	Import  bool `yaml:"import" toml:"import" usage:"accept imports at /api/import"`
+	Export  bool `yaml:"export" toml:"export" usage:"serve exports at /api/export"`
+	Metrics bool `yaml:"metrics" toml:"metrics" usage:"serve Prometheus metrics at /metrics"`
+}
+
+// Default returns the configuration used when nothing else is set
+func Default() *Config {
+	return &Config{
+		Listen:  ":8080",
+		DataDir: ".",
+		Database: DatabaseConfig{
+			Path:            "gokeep.db",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 5 * time.Minute,
+			SlowQuery:       200 * time.Millisecond,
+		},
+		Timeouts: TimeoutConfig{
+			Request:    60 * time.Second,
+			ReadHeader: 10 * time.Second,
+			Idle:       120 * time.Second,
+			Shutdown:   5 * time.Second,
+		},
+		Log: LogConfig{Level: "info", Format: "json"},
+		TLS: TLSConfig{
+			Dir:        "tls",
+			HSTS:       true,
+			HSTSMaxAge: 180 * 24 * time.Hour,
+		},
+		Backup: BackupConfig{
+			Interval: 24 * time.Hour,
+			Keep:     7,
+		},
+		Features: FeatureConfig{
+			Events:  true,
+			Collab:  true,
+			Import:  true,
+			Export:  true,
+			Metrics: true,
+		},
+		Admin: AdminConfig{
+			MinFreeSpace: 100,
+			IndexCheck:   5 * time.Minute,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:     true,
+			WriteRate:   5,
+			WriteBurst:  30,
+			SearchRate:  5,
+			SearchBurst: 20,
+			AuthRate:    0.2,
+			AuthBurst:   5,
+			Lockout:     time.Second,
+			MaxLockout:  15 * time.Minute,
+		},
+		Security: SecurityConfig{
+			ContentSecurityPolicy: DefaultContentSecurityPolicy,
+			CSRF:                  true,
+		},
+	}
+}
+
+// DefaultContentSecurityPolicy allows only what the go-app page needs:
+// scripts and styles from the server, compiling the WebAssembly binary,
+// the style attributes go-app sets for note colors, fetches and WebSockets
+// to the server, and go-app's default icons
+const DefaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'wasm-unsafe-eval'; " +
+	"style-src 'self'; style-src-attr 'unsafe-inline'; " +
+	"img-src 'self' data: https://raw.githubusercontent.com; " +
+	"connect-src 'self'; worker-src 'self'; manifest-src 'self'; " +
+	"object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
+// Validate reports the first setting that cannot work
+func (c *Config) Validate() error {
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("log.level must be debug, info, warn or error, not %q", c.Log.Level)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		return fmt.Errorf("log.format must be json or text, not %q", c.Log.Format)
+	}
+	if c.Listen == "" {
+		return fmt.Errorf("listen must not be empty")
+	}
+	if c.Database.Path == "" {
+		return fmt.Errorf("database.path must not be empty")
+	}
+	if c.Database.MaxOpenConns < 1 {
+		return fmt.Errorf("database.max_open_conns must be at least 1")
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return fmt.Errorf("tls.cert_file and tls.key_file must be set together")
+	}
+	if c.TLS.SelfSigned && c.TLS.CertFile != "" {
+		return fmt.Errorf("tls.self_signed cannot be combined with tls.cert_file")
+	}
+	if c.TLS.RedirectListen != "" && !c.TLSEnabled() {
+		return fmt.Errorf("tls.redirect_listen needs a certificate or tls.self_signed")
+	}
+	if c.Admin.MinFreeSpace < 0 {
+		return fmt.Errorf("admin.min_free_space must not be negative")
+	}
+	if c.Import.ENEXMaxSize < 0 {
+		return fmt.Errorf("import.enex_max_size must not be negative")
+	}
+	if c.Admin.IndexCheck <= 0 {
+		return fmt.Errorf("admin.index_check must be positive")
+	}
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	default:
+		return fmt.Errorf("tracing.exporter must be none, otlp or stdout, not %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sample_ratio must be between 0 and 1")
+	}
+	if _, err := parseProxies(c.Security.TrustedProxies); err != nil {
+		return fmt.Errorf("security.trusted_proxies: %w", err)
+	}
+	if c.RateLimit.Enabled {
+		rl := c.RateLimit
+		if rl.WriteRate <= 0 || rl.SearchRate <= 0 || rl.AuthRate <= 0 {
+			return fmt.Errorf("rate_limit rates must be positive")
+		}
+		if rl.WriteBurst < 1 || rl.SearchBurst < 1 || rl.AuthBurst < 1 {
+			return fmt.Errorf("rate_limit bursts must be at least 1")
+		}
+		if rl.Lockout <= 0 || rl.MaxLockout < rl.Lockout {
+			return fmt.Errorf("rate_limit.lockout must be positive and at most rate_limit.max_lockout")
+		}
+	}
+	if c.Backup.Dir != "" && c.Backup.Interval <= 0 {
+		return fmt.Errorf("backup.interval must be positive")
+	}
+	return nil
+}
+
+// TLSEnabled reports whether the server speaks HTTPS
+func (c *Config) TLSEnabled() bool {
+	return c.TLS.CertFile != "" || c.TLS.SelfSigned
+}
+
+// TLSDir returns the directory of the generated certificates, resolved
+// against the data directory
+func (c *Config) TLSDir() string {
+	return c.resolve(c.TLS.Dir)
+}
+
+// DBPath returns the database file, resolved against the data directory
+func (c *Config) DBPath() string {
+	return c.resolve(c.Database.Path)
+}
+
+// BackupDir returns the backup directory, resolved against the data
+// directory, or "" if scheduled backups are disabled
+func (c *Config) BackupDir() string {
+	if c.Backup.Dir == "" {
+		return ""
+	}
+	return c.resolve(c.Backup.Dir)
+}
+
+func (c *Config) resolve(path string) string {
+	if filepath.IsAbs(path) || c.DataDir == "" {
+		return path
+	}
+	return filepath.Join(c.DataDir, path)
+}
@@ -0,0 +1,309 @@
+// internal/config/load.go
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the environment variables that override settings
+const EnvPrefix = "GOKEEP_"
+
+// EnvFile names the configuration file when --config is not given
+const EnvFile = EnvPrefix + "CONFIG"
+
+// DefaultFile is read if it exists and no other file is named
+const DefaultFile = "gokeep.yaml"
+
+// field is one setting of a Config
+type field struct {
+	key    string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// fields lists the settings of c in declaration order
+func (c *Config) fields() []field {
+	var fields []field
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if sf.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), prefix+name+".")
+				continue
+			}
+			fields = append(fields, field{
+				key:    prefix + name,
+				usage:  sf.Tag.Get("usage"),
+				secret: sf.Tag.Get("secret") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(c).Elem(), "")
+	return fields
+}
+
+// Set changes the setting with the given key, parsing value like a flag
+func (c *Config) Set(key, value string) error {
+	for _, f := range c.fields() {
+		if f.key == key {
+			if err := setValue(f.value, value); err != nil {
+				return fmt.Errorf("invalid value %q for %s: %w", value, key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown setting %q", key)
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// LoadFile merges the YAML or TOML file at path into c, picking the format
+// by extension. Unknown keys are rejected so that typos do not go unnoticed.
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("failed to parse %s: unknown setting %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("config file %s must end in .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+// ApplyEnv overrides settings from GOKEEP_* variables in environ, which is
+// in the form returned by os.Environ
+func (c *Config) ApplyEnv(environ []string) error {
+	values := make(map[string]string)
+	for _, kv := range environ {
+		if name, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(name, EnvPrefix) {
+			values[name] = value
+		}
+	}
+
+	for _, f := range c.fields() {
+		name := EnvName(f.key)
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", value, name, err)
+		}
+	}
+	return nil
+}
+
+// EnvName returns the environment variable of a setting, e.g.
+// GOKEEP_DATABASE_PATH for database.path
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// FlagName returns the flag of a setting, e.g. database-path for
+// database.path
+func FlagName(key string) string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(key)
+}
+
+// Flags records the settings given on the command line, so they can be
+// applied after the file and the environment
+type Flags struct {
+	File string
+	set  []flagSetting
+}
+
+type flagSetting struct {
+	key, value string
+}
+
+// flagValue is the flag.Value of one setting
+type flagValue struct {
+	flags  *Flags
+	key    string
+	def    string
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.def
+}
+
+func (v *flagValue) Set(s string) error {
+	// Parse now, so a bad value is reported against its flag
+	if err := Default().Set(v.key, s); err != nil {
+		return err
+	}
+	v.flags.set = append(v.flags.set, flagSetting{key: v.key, value: s})
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// RegisterFlags adds --config and a flag for each of the given settings to
+// fs, or for every setting if no keys are given
+func RegisterFlags(fs *flag.FlagSet, keys ...string) *Flags {
+	flags := &Flags{}
+	fs.StringVar(&flags.File, "config", "", "configuration file, YAML or TOML (default $"+EnvFile+" or ./"+DefaultFile+" if present)")
+
+	for _, f := range Default().fields() {
+		if len(keys) > 0 && !slices.Contains(keys, f.key) {
+			continue
+		}
+		fs.Var(&flagValue{
+			flags:  flags,
+			key:    f.key,
+			def:    fmt.Sprint(f.value.Interface()),
+			isBool: f.value.Kind() == reflect.Bool,
+		}, FlagName(f.key), f.usage)
+	}
+	return flags
+}
+
+// Load builds the effective configuration from the defaults, the config
+// file, GOKEEP_* environment variables and finally the flags. flags may be
+// nil.
+func Load(flags *Flags) (*Config, error) {
+	if flags == nil {
+		flags = &Flags{}
+	}
+
+	c := Default()
+	path := flags.File
+	if path == "" {
+		path = os.Getenv(EnvFile)
+	}
+	if path == "" {
+		if _, err := os.Stat(DefaultFile); err == nil {
+			path = DefaultFile
+		}
+	}
+	if path != "" {
+		if err := c.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.ApplyEnv(os.Environ()); err != nil {
+		return nil, err
+	}
+	for _, s := range flags.set {
+		if err := c.Set(s.key, s.value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	c.Source = path
+	return c, nil
+}
+
+// Redacted returns a copy of c with secrets such as tokens replaced, for
+// showing the configuration
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	for _, f := range redacted.fields() {
+		if f.secret && f.value.String() != "" {
+			f.value.SetString("REDACTED")
+		}
+	}
+	return &redacted
+}
+
+// Settings returns every setting by key, formatted like a flag value
+func (c *Config) Settings() map[string]string {
+	settings := make(map[string]string)
+	for _, f := range c.fields() {
+		settings[f.key] = fmt.Sprint(f.value.Interface())
+	}
+	return settings
+}
+
+// WriteYAML writes c in the format of a config file
+func (c *Config) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return enc.Close()
+}
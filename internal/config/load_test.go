@@ -0,0 +1,144 @@
+// internal/config/load_test.go
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// load parses args like the serve command and loads the configuration
+func load(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return Load(flags)
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	files := map[string]string{
+		"gokeep.yaml": `
+listen: ":9000"
+log:
+  level: debug
+database:
+  path: file.db
+  slow_query: 1s
+rate_limit:
+  write_rate: 9
+`,
+		"gokeep.toml": `
+listen = ":9000"
+
+[log]
+level = "debug"
+
+[database]
+path = "file.db"
+slow_query = "1s"
+
+[rate_limit]
+write_rate = 9.0
+`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := writeFile(t, name, content)
+			t.Setenv("GOKEEP_LOG_LEVEL", "warn")
+			t.Setenv("GOKEEP_DATABASE_PATH", "env.db")
+
+			c, err := load(t, "-config", path, "-database-path", "flag.db")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]string{
+				"listen":                ":9000",   // file over default
+				"log.level":             "warn",    // environment over file
+				"database.path":         "flag.db", // flag over environment
+				"database.slow_query":   "1s",
+				"rate_limit.write_rate": "9",
+
+				// Defaults
+				"log.format":  "json",
+				"backup.keep": "7",
+			}
+			settings := c.Settings()
+			for key, value := range want {
+				if settings[key] != value {
+					t.Errorf("%s = %q, want %q", key, settings[key], value)
+				}
+			}
+			if c.Source != path {
+				t.Errorf("source %q, want %q", c.Source, path)
+			}
+		})
+	}
+}
+
+func TestLoadFileFromEnvironment(t *testing.T) {
+	t.Setenv(EnvFile, writeFile(t, "gokeep.yaml", "timeouts:\n  shutdown: 30s\n"))
+	c, err := load(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Timeouts.Shutdown != 30*time.Second {
+		t.Errorf("timeouts.shutdown = %v", c.Timeouts.Shutdown)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{name: "typo in yaml", file: "lisen: \":9000\"\n", want: "lisen"},
+		{name: "typo in toml", file: "[log]\nlevl = \"debug\"\n", want: "levl"},
+		{name: "bad environment value", env: map[string]string{"GOKEEP_BACKUP_KEEP": "many"}, want: "GOKEEP_BACKUP_KEEP"},
+		{name: "invalid result", args: []string{"-log-level", "loud"}, want: "log.level"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				name := "gokeep.yaml"
+				if strings.Contains(tt.name, "toml") {
+					name = "gokeep.toml"
+				}
+				args = append([]string{"-config", writeFile(t, name, tt.file)}, args...)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := load(t, args...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+
+	// A bad flag value is refused while parsing, against its flag
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(new(strings.Builder))
+	RegisterFlags(fs)
+	if err := fs.Parse([]string{"-backup-keep", "many"}); err == nil {
+		t.Error("accepted -backup-keep many")
+	}
+}
@@ -0,0 +1,175 @@
+// internal/database/backup.go
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// BackupTo writes a consistent copy of the live database to path. VACUUM INTO
+// reads inside a transaction, so writers are not interrupted.
+func (db *DB) BackupTo(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup target %s already exists", path)
+	}
+	if _, err := db.conn.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
+
+// IntegrityCheck runs SQLite's integrity check on the database
+func (db *DB) IntegrityCheck(ctx context.Context) error {
+	return integrityCheck(ctx, db.conn)
+}
+
+// VerifyFile checks the integrity of the database file at path without
+// modifying it and returns its schema version. Files written by a newer
+// gokeep, with migrations this build does not know, are rejected.
+func VerifyFile(ctx context.Context, path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+
+	dsn := (&url.URL{Scheme: "file", Opaque: path, RawQuery: "mode=ro"}).String()
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer conn.Close()
+
+	if err := integrityCheck(ctx, conn); err != nil {
+		return 0, err
+	}
+
+	var version int
+	if err := conn.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return version, fmt.Errorf("schema version %d is newer than this build supports (%d)", version, len(migrations))
+	}
+
+	return version, nil
+}
+
+func integrityCheck(ctx context.Context, conn *sql.DB) error {
+	rows, err := conn.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return fmt.Errorf("failed to run integrity check: %w", err)
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// ContinueSync renumbers the changes in db to follow those of the database
+// at prev, which db is about to replace. Clients keep the sequence number
+// of their last sync, so the restored notes must all come after it: every
+// note in db is then sent to them as changed, and every note only prev had
+// as deleted. Nothing is done if there is no database at prev or it
+// predates sync.
+func (db *DB) ContinueSync(ctx context.Context, prev string) error {
+	if _, err := os.Stat(prev); err != nil {
+		return nil
+	}
+
+	dsn := (&url.URL{Scheme: "file", Opaque: prev, RawQuery: "mode=ro"}).String()
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", prev, err)
+	}
+	defer conn.Close()
+
+	var version int
+	if err := conn.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version of %s: %w", prev, err)
+	}
+	if version < syncSchemaVersion {
+		return nil
+	}
+	var seq int64
+	if err := conn.QueryRowContext(ctx, `SELECT seq FROM sync_state WHERE id = 1`).Scan(&seq); err != nil {
+		return fmt.Errorf("failed to read sync state of %s: %w", prev, err)
+	}
+	ids, err := noteIDs(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("failed to read notes of %s: %w", prev, err)
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to renumber changes: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Changing seq itself does not fire the sequence triggers
+	for _, query := range []string{
+		`UPDATE notes SET seq = seq + ?`,
+		`UPDATE note_tombstones SET seq = seq + ?`,
+		`UPDATE sync_state SET seq = seq + ? WHERE id = 1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, seq); err != nil {
+			return fmt.Errorf("failed to renumber changes: %w", err)
+		}
+	}
+	for _, id := range ids {
+		result, err := tx.ExecContext(ctx, `
+            INSERT OR REPLACE INTO note_tombstones (note_id, seq, deleted_at)
+            SELECT ?, seq + 1, CURRENT_TIMESTAMP FROM sync_state
+            WHERE id = 1 AND NOT EXISTS (SELECT 1 FROM notes WHERE id = ?)
+        `, id, id)
+		if err != nil {
+			return fmt.Errorf("failed to record deleted note %d: %w", id, err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			if _, err := tx.ExecContext(ctx, `UPDATE sync_state SET seq = seq + 1 WHERE id = 1`); err != nil {
+				return fmt.Errorf("failed to record deleted note %d: %w", id, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// syncSchemaVersion is the migration that added the change sequence
+const syncSchemaVersion = 2
+
+func noteIDs(ctx context.Context, conn *sql.DB) ([]int64, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT id FROM notes`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
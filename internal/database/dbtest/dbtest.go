@@ -0,0 +1,31 @@
+// internal/database/dbtest/dbtest.go
+package dbtest
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+)
+
+// Open creates an empty database in a temporary directory
+func Open(t testing.TB) *database.DB {
+	t.Helper()
+	return OpenFile(t, filepath.Join(t.TempDir(), "gokeep.db"))
+}
+
+// OpenFile opens the database at path, creating it if needed, and closes it
+// when the test ends. Tests are skipped if SQLite was built without FTS5.
+func OpenFile(t testing.TB, path string) *database.DB {
+	t.Helper()
+	db, err := database.NewDB(path)
+	if err != nil && strings.Contains(err.Error(), "fts5") {
+		t.Skip("SQLite lacks FTS5; run the tests with -tags sqlite_fts5")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
@@ -0,0 +1,83 @@
+// internal/database/errors.go
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// Kinds of errors the repository returns, to be matched with errors.Is.
+// Any other error is a failure of the database itself.
+var (
+	// ErrNotFound means the note or attachment does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the change clashes with what is already stored
+	ErrConflict = errors.New("conflict")
+	// ErrValidation means the input cannot be stored or understood
+	ErrValidation = errors.New("invalid input")
+)
+
+// NotFoundError names the note or attachment that does not exist. It
+// matches ErrNotFound.
+type NotFoundError struct {
+	// Resource is "note" or "attachment"
+	Resource string
+	ID       string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Resource + " not found"
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// noteNotFound reports that note id does not exist
+func noteNotFound(id int64) error {
+	return &NotFoundError{Resource: "note", ID: fmt.Sprint(id)}
+}
+
+// ValidationError says why the input was refused. It matches
+// ErrValidation, and its messages are meant for the user.
+type ValidationError struct {
+	Message string
+	// Fields lists the invalid fields of the input, if it has any
+	Fields models.FieldErrors
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	return e.Message + ": " + e.Fields.Error()
+}
+
+// invalidField reports a single invalid field
+func invalidField(message, field, problem string) error {
+	return &ValidationError{Message: message, Fields: models.FieldErrors{{Field: field, Message: problem}}}
+}
+
+// validateNote checks note before it is stored
+func validateNote(note *models.Note) error {
+	var fields models.FieldErrors
+	if errors.As(note.Validate(), &fields) {
+		return &ValidationError{Message: "invalid note", Fields: fields}
+	}
+	return nil
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// conflictError marks violated uniqueness constraints as ErrConflict, e.g.
+// an attachment restored under an ID that is already taken
+func conflictError(err error) error {
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+	return err
+}
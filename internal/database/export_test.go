@@ -0,0 +1,9 @@
+// internal/database/export_test.go
+package database
+
+import "database/sql"
+
+// Conn lets tests write rows the repository would refuse
+func Conn(db *DB) *sql.DB {
+	return db.conn
+}
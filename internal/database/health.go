@@ -0,0 +1,64 @@
+// internal/database/health.go
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// Ping checks that the database can still be reached
+func (db *DB) Ping(ctx context.Context) error {
+	if err := db.conn.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
+}
+
+// LatestSchemaVersion is the schema version this build migrates to
+func LatestSchemaVersion() int {
+	return len(migrations)
+}
+
+// CheckMigrations reports an error unless every migration of this build has
+// been applied, and no migration it does not know
+func (db *DB) CheckMigrations(ctx context.Context) error {
+	version, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version != len(migrations) {
+		return fmt.Errorf("schema version is %d, expected %d", version, len(migrations))
+	}
+	return nil
+}
+
+// CheckFTS compares the full-text index with the notes it is kept in sync
+// with by triggers. It scans both, so it is too slow for every readiness
+// probe on a large database; the server runs it in the background.
+func (db *DB) CheckFTS(ctx context.Context) error {
+	var missing, orphaned int
+	err := db.conn.QueryRowContext(ctx, `
+        SELECT (SELECT COUNT(*) FROM notes WHERE id NOT IN (SELECT rowid FROM notes_fts)),
+               (SELECT COUNT(*) FROM notes_fts WHERE rowid NOT IN (SELECT id FROM notes))
+    `).Scan(&missing, &orphaned)
+	if err != nil {
+		return fmt.Errorf("failed to check search index: %w", err)
+	}
+	if missing > 0 || orphaned > 0 {
+		return fmt.Errorf("search index is out of sync: %d notes missing, %d stale entries", missing, orphaned)
+	}
+	return nil
+}
+
+// Size returns the size of the database in bytes, not counting the
+// write-ahead log
+func (db *DB) Size(ctx context.Context) (int64, error) {
+	var pages, pageSize int64
+	if err := db.conn.QueryRowContext(ctx, `PRAGMA page_count`).Scan(&pages); err != nil {
+		return 0, fmt.Errorf("failed to read database size: %w", err)
+	}
+	if err := db.conn.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to read database size: %w", err)
+	}
+	return pages * pageSize, nil
+}
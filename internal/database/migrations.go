@@ -0,0 +1,147 @@
+// internal/database/migrations.go
+package database
+
+// migrations holds the schema changes in order. The schema version stored in
+// PRAGMA user_version is the number of migrations that have been applied.
+// Never edit a released migration; append a new one instead.
+var migrations = []string{
+	// 1: notes with full-text search
+	`
+    CREATE TABLE IF NOT EXISTS notes (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        title TEXT NOT NULL,
+        content TEXT,
+        color TEXT DEFAULT '#ffffff',
+        created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+        updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_notes_created_at ON notes(created_at);
+    CREATE INDEX IF NOT EXISTS idx_notes_updated_at ON notes(updated_at);
+    
+    -- Full-text search table
+    CREATE VIRTUAL TABLE IF NOT EXISTS notes_fts USING fts5(
+        title, 
+        content, 
+        content_rowid=id
+    );
+
+    -- Triggers to keep FTS table in sync
+    CREATE TRIGGER IF NOT EXISTS notes_ai AFTER INSERT ON notes
+    BEGIN
+        INSERT INTO notes_fts(rowid, title, content) 
+        VALUES (new.id, new.title, new.content);
+    END;
+
+    CREATE TRIGGER IF NOT EXISTS notes_ad AFTER DELETE ON notes
+    BEGIN
+        DELETE FROM notes_fts WHERE rowid = old.id;
+    END;
+
+    CREATE TRIGGER IF NOT EXISTS notes_au AFTER UPDATE ON notes
+    BEGIN
+        UPDATE notes_fts 
+        SET title = new.title, content = new.content 
+        WHERE rowid = new.id;
+    END;
+    `,
+
+	// 2: change sequence and tombstones for delta sync
+	`
+    CREATE TABLE sync_state (
+        id INTEGER PRIMARY KEY CHECK (id = 1),
+        seq INTEGER NOT NULL
+    );
+    INSERT INTO sync_state (id, seq) VALUES (1, (SELECT COALESCE(MAX(id), 0) FROM notes));
+
+    ALTER TABLE notes ADD COLUMN seq INTEGER NOT NULL DEFAULT 0;
+    UPDATE notes SET seq = id;
+    CREATE INDEX idx_notes_seq ON notes(seq);
+
+    CREATE TABLE note_tombstones (
+        note_id INTEGER PRIMARY KEY,
+        seq INTEGER NOT NULL,
+        deleted_at DATETIME NOT NULL
+    );
+    CREATE INDEX idx_note_tombstones_seq ON note_tombstones(seq);
+
+    -- Only reindex when searchable columns change
+    DROP TRIGGER notes_au;
+    CREATE TRIGGER notes_au AFTER UPDATE OF title, content ON notes
+    BEGIN
+        UPDATE notes_fts
+        SET title = new.title, content = new.content
+        WHERE rowid = new.id;
+    END;
+
+    -- Every write takes the next sequence number
+    CREATE TRIGGER notes_seq_ai AFTER INSERT ON notes
+    BEGIN
+        UPDATE sync_state SET seq = seq + 1 WHERE id = 1;
+        UPDATE notes SET seq = (SELECT seq FROM sync_state WHERE id = 1) WHERE id = new.id;
+        DELETE FROM note_tombstones WHERE note_id = new.id;
+    END;
+
+    -- The WHEN clause skips the trigger's own seq update
+    CREATE TRIGGER notes_seq_au AFTER UPDATE ON notes
+    WHEN new.seq = old.seq
+    BEGIN
+        UPDATE sync_state SET seq = seq + 1 WHERE id = 1;
+        UPDATE notes SET seq = (SELECT seq FROM sync_state WHERE id = 1) WHERE id = new.id;
+    END;
+
+    CREATE TRIGGER notes_seq_ad AFTER DELETE ON notes
+    BEGIN
+        UPDATE sync_state SET seq = seq + 1 WHERE id = 1;
+        INSERT OR REPLACE INTO note_tombstones (note_id, seq, deleted_at)
+        VALUES (old.id, (SELECT seq FROM sync_state WHERE id = 1), CURRENT_TIMESTAMP);
+    END;
+    `,
+
+	// 3: pinned/archived/trashed flags, labels and attachments
+	`
+    ALTER TABLE notes ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0;
+    ALTER TABLE notes ADD COLUMN archived INTEGER NOT NULL DEFAULT 0;
+    ALTER TABLE notes ADD COLUMN trashed INTEGER NOT NULL DEFAULT 0;
+
+    CREATE TABLE labels (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        name TEXT NOT NULL UNIQUE COLLATE NOCASE
+    );
+
+    CREATE TABLE note_labels (
+        note_id INTEGER NOT NULL,
+        label_id INTEGER NOT NULL,
+        PRIMARY KEY (note_id, label_id)
+    );
+    CREATE INDEX idx_note_labels_label_id ON note_labels(label_id);
+
+    CREATE TABLE attachments (
+        id TEXT PRIMARY KEY,
+        note_id INTEGER NOT NULL,
+        filename TEXT NOT NULL,
+        mime_type TEXT NOT NULL,
+        size INTEGER NOT NULL,
+        data BLOB NOT NULL,
+        created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    );
+    CREATE INDEX idx_attachments_note_id ON attachments(note_id);
+
+    -- Remove labels and files together with their note
+    CREATE TRIGGER notes_cleanup_ad AFTER DELETE ON notes
+    BEGIN
+        DELETE FROM note_labels WHERE note_id = old.id;
+        DELETE FROM attachments WHERE note_id = old.id;
+    END;
+    `,
+
+	// 4: colors outside the palette, stored before colors were checked,
+	// turn white so the notes can be saved again
+	`
+    UPDATE notes SET color = '#ffffff'
+    WHERE color IS NULL OR color NOT IN (
+        '#ffffff', '#fff475', '#fbbc04', '#f28b82',
+        '#d7aefb', '#aecbfa', '#ccff90', '#e8eaed'
+    );
+    `,
+}
@@ -2,104 +2,379 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
-	"github.com/Smil3MoreGH/gokeep/internal/models"
+	"github.com/Smil3MoreGH/gokeep/internal/events"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// NoteRepository handles all database operations for notes
+// tracer starts a span for every repository operation
+var tracer = otel.Tracer("github.com/Smil3MoreGH/gokeep/internal/database")
+
+// noteColumns lists the columns read by scanNote, in order. Labels and
+// attachment metadata are aggregated as JSON arrays.
+const noteColumns = `notes.id, notes.title, notes.content, notes.color,
+        notes.created_at, notes.updated_at, notes.seq,
+        notes.pinned, notes.archived, notes.trashed,
+        (SELECT json_group_array(name) FROM (
+            SELECT l.name FROM note_labels nl JOIN labels l ON l.id = nl.label_id
+            WHERE nl.note_id = notes.id ORDER BY l.name
+        )),
+        (SELECT json_group_array(json_object(
+            'id', a.id, 'note_id', a.note_id, 'filename', a.filename,
+            'mime_type', a.mime_type, 'size', a.size,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', a.created_at)
+        )) FROM attachments a WHERE a.note_id = notes.id)`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanNote reads a note selected with noteColumns
+func scanNote(row rowScanner) (models.Note, error) {
+	var note models.Note
+	var labels, attachments string
+	err := row.Scan(
+		&note.ID,
+		&note.Title,
+		&note.Content,
+		&note.Color,
+		&note.CreatedAt,
+		&note.UpdatedAt,
+		&note.Seq,
+		&note.Pinned,
+		&note.Archived,
+		&note.Trashed,
+		&labels,
+		&attachments,
+	)
+	if err != nil {
+		return note, err
+	}
+
+	if err := json.Unmarshal([]byte(labels), &note.Labels); err != nil {
+		return note, fmt.Errorf("failed to decode labels: %w", err)
+	}
+	if err := json.Unmarshal([]byte(attachments), &note.Attachments); err != nil {
+		return note, fmt.Errorf("failed to decode attachments: %w", err)
+	}
+
+	return note, nil
+}
+
+// NoteRepository handles all database operations for notes. Every
+// operation runs under the caller's context, so a cancelled request or an
+// expired timeout interrupts its SQL. Notes are checked with
+// models.Note.Validate before they are written.
 type NoteRepository struct {
-	db *DB
+	db        *DB
+	bus       *events.Bus
+	observer  Observer
+	slowQuery time.Duration
+}
+
+// Observer is told how long each repository operation took and whether it
+// failed, e.g. to export metrics
+type Observer interface {
+	ObserveOperation(operation string, duration time.Duration, err error)
+}
+
+// NewNoteRepository creates a new note repository. Changes are published on
+// bus if it is not nil.
+func NewNoteRepository(db *DB, bus *events.Bus) *NoteRepository {
+	return &NoteRepository{db: db, bus: bus}
+}
+
+// SetObserver reports every operation to o. It must be called before the
+// repository is shared.
+func (r *NoteRepository) SetObserver(o Observer) {
+	r.observer = o
+}
+
+// SetSlowQuery logs operations that take longer than threshold at warn
+// level; zero turns this off. It must be called before the repository is
+// shared.
+func (r *NoteRepository) SetSlowQuery(threshold time.Duration) {
+	r.slowQuery = threshold
 }
 
-// NewNoteRepository creates a new note repository
-func NewNoteRepository(db *DB) *NoteRepository {
-	return &NoteRepository{db: db}
+// begin starts the span of an operation. The returned function ends it; it
+// is deferred with a pointer to the operation's error result.
+func (r *NoteRepository) begin(ctx context.Context, operation string) (context.Context, func(*error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "db."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameSQLite, semconv.DBOperationName(operation)),
+	)
+	return ctx, func(err *error) {
+		failure := operationError(*err)
+		if failure != nil {
+			span.RecordError(failure)
+			span.SetStatus(codes.Error, failure.Error())
+		}
+		span.End()
+		r.observe(ctx, operation, time.Since(start), failure)
+	}
+}
+
+// statement records the main SQL statement of an operation on its span
+func statement(ctx context.Context, query string) {
+	trace.SpanFromContext(ctx).SetAttributes(semconv.DBQueryText(strings.Join(strings.Fields(query), " ")))
+}
+
+// operationError returns err unless it is one of ErrNotFound, ErrConflict
+// or ErrValidation, which are answers about the input, not failures
+func operationError(err error) error {
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) || errors.Is(err, ErrValidation) {
+		return nil
+	}
+	return err
 }
 
-// Create inserts a new note into the database
-func (r *NoteRepository) Create(note *models.Note) error {
+// observe logs and reports an operation that has ended. The log records
+// carry the request ID of ctx.
+func (r *NoteRepository) observe(ctx context.Context, operation string, duration time.Duration, failure error) {
+	switch {
+	case failure != nil:
+		slog.DebugContext(ctx, "database operation failed", "operation", operation, "duration", duration, "error", failure)
+	case r.slowQuery > 0 && duration > r.slowQuery:
+		slog.WarnContext(ctx, "slow database operation", "operation", operation, "duration", duration, "threshold", r.slowQuery)
+	default:
+		slog.DebugContext(ctx, "database operation", "operation", operation, "duration", duration)
+	}
+
+	if r.observer != nil {
+		r.observer.ObserveOperation(operation, duration, failure)
+	}
+}
+
+// publish sends a change event to subscribers of the event bus
+func (r *NoteRepository) publish(eventType models.NoteEventType, noteID int64, note *models.Note) {
+	if r.bus == nil {
+		return
+	}
+	r.bus.Publish(eventType, noteID, note)
+}
+
+// Create inserts a new note together with its labels and attachments
+func (r *NoteRepository) Create(ctx context.Context, note *models.Note) (err error) {
+	ctx, end := r.begin(ctx, "create")
+	defer end(&err)
+
+	if err := validateNote(note); err != nil {
+		return err
+	}
 	note.SetDefaults()
 
+	tx, err := r.db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create note: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-        INSERT INTO notes (title, content, color, created_at, updated_at)
-        VALUES (?, ?, ?, ?, ?)
+        INSERT INTO notes (title, content, color, created_at, updated_at, pinned, archived, trashed)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
         RETURNING id
     `
 
-	err := r.db.conn.QueryRow(
+	statement(ctx, query)
+	err = tx.QueryRowContext(
+		ctx,
 		query,
 		note.Title,
 		note.Content,
 		note.Color,
 		note.CreatedAt,
 		note.UpdatedAt,
+		note.Pinned,
+		note.Archived,
+		note.Trashed,
 	).Scan(&note.ID)
 
 	if err != nil {
 		return fmt.Errorf("failed to create note: %w", err)
 	}
 
+	if err := setLabels(ctx, tx, note.ID, note.Labels); err != nil {
+		return err
+	}
+	for i := range note.Attachments {
+		note.Attachments[i].NoteID = note.ID
+		if err := insertAttachment(ctx, tx, &note.Attachments[i]); err != nil {
+			return err
+		}
+	}
+
+	// The sequence number is assigned by a trigger after the insert
+	if err := tx.QueryRowContext(ctx, `SELECT seq FROM notes WHERE id = ?`, note.ID).Scan(&note.Seq); err != nil {
+		return fmt.Errorf("failed to read note sequence: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to create note: %w", err)
+	}
+
+	// File contents are not needed once stored
+	note.Attachments = withoutData(note.Attachments)
+	note.Labels = normalizeLabels(note.Labels)
+
+	r.publish(models.EventNoteCreated, note.ID, note)
+	return nil
+}
+
+// Restore writes note under its own ID with its own timestamps, replacing
+// the note stored under that ID if there is one. Imports use it to round-trip
+// notes exactly. Attachments that are already stored are left untouched.
+func (r *NoteRepository) Restore(ctx context.Context, note *models.Note) (err error) {
+	ctx, end := r.begin(ctx, "restore")
+	defer end(&err)
+
+	if note.ID <= 0 {
+		return invalidField("invalid note", "id", "a restored note needs an ID")
+	}
+	for _, a := range note.Attachments {
+		if !models.ValidAttachmentID(a.ID) {
+			return invalidField("invalid note", "attachments", fmt.Sprintf("attachment ID %q is not a UUID", a.ID))
+		}
+	}
+	// Backups from before colors were checked may hold any color; such
+	// notes come back white, as migration 4 does with stored ones
+	if !models.ValidateColor(note.Color) {
+		note.Color = ""
+	}
+	if err := validateNote(note); err != nil {
+		return err
+	}
+	note.SetDefaults()
+
+	tx, err := r.db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to restore note: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM notes WHERE id = ?)`, note.ID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to restore note: %w", err)
+	}
+
+	query := `
+        INSERT INTO notes (title, content, color, created_at, updated_at, pinned, archived, trashed, id)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `
+	if exists {
+		query = `
+        UPDATE notes
+        SET title = ?, content = ?, color = ?, created_at = ?, updated_at = ?,
+            pinned = ?, archived = ?, trashed = ?
+        WHERE id = ?
+    `
+	}
+
+	statement(ctx, query)
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		note.Title,
+		note.Content,
+		note.Color,
+		note.CreatedAt,
+		note.UpdatedAt,
+		note.Pinned,
+		note.Archived,
+		note.Trashed,
+		note.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to restore note: %w", err)
+	}
+
+	if err := setLabels(ctx, tx, note.ID, note.Labels); err != nil {
+		return err
+	}
+	for i := range note.Attachments {
+		a := &note.Attachments[i]
+		var stored bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM attachments WHERE id = ?)`, a.ID).Scan(&stored); err != nil {
+			return fmt.Errorf("failed to restore attachment: %w", err)
+		}
+		if stored {
+			continue
+		}
+		a.NoteID = note.ID
+		if err := insertAttachment(ctx, tx, a); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to restore note: %w", err)
+	}
+
+	restored, err := r.GetByID(ctx, note.ID)
+	if err != nil {
+		return err
+	}
+	*note = *restored
+
+	eventType := models.EventNoteCreated
+	if exists {
+		eventType = models.EventNoteUpdated
+	}
+	r.publish(eventType, note.ID, note)
 	return nil
 }
 
 // GetAll retrieves all notes from the database
-func (r *NoteRepository) GetAll() ([]models.Note, error) {
+func (r *NoteRepository) GetAll(ctx context.Context) (_ []models.Note, err error) {
+	ctx, end := r.begin(ctx, "get_all")
+	defer end(&err)
+
 	query := `
-        SELECT id, title, content, color, created_at, updated_at
+        SELECT ` + noteColumns + `
         FROM notes
         ORDER BY updated_at DESC
     `
 
-	rows, err := r.db.conn.Query(query)
+	statement(ctx, query)
+	rows, err := r.db.conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all notes: %w", err)
 	}
 	defer rows.Close()
 
-	var notes []models.Note
-	for rows.Next() {
-		var note models.Note
-		err := rows.Scan(
-			&note.ID,
-			&note.Title,
-			&note.Content,
-			&note.Color,
-			&note.CreatedAt,
-			&note.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan note: %w", err)
-		}
-		notes = append(notes, note)
-	}
-
-	return notes, rows.Err()
+	return scanNotes(rows)
 }
 
 // GetByID retrieves a single note by its ID
-func (r *NoteRepository) GetByID(id int64) (*models.Note, error) {
+func (r *NoteRepository) GetByID(ctx context.Context, id int64) (_ *models.Note, err error) {
+	ctx, end := r.begin(ctx, "get")
+	defer end(&err)
+
 	query := `
-        SELECT id, title, content, color, created_at, updated_at
+        SELECT ` + noteColumns + `
         FROM notes
         WHERE id = ?
     `
 
-	var note models.Note
-	err := r.db.conn.QueryRow(query, id).Scan(
-		&note.ID,
-		&note.Title,
-		&note.Content,
-		&note.Color,
-		&note.CreatedAt,
-		&note.UpdatedAt,
-	)
+	statement(ctx, query)
+	note, err := scanNote(r.db.conn.QueryRowContext(ctx, query, id))
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("note not found")
+		return nil, noteNotFound(id)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get note: %w", err)
@@ -108,22 +383,45 @@ func (r *NoteRepository) GetByID(id int64) (*models.Note, error) {
 	return &note, nil
 }
 
-// Update updates an existing note
-func (r *NoteRepository) Update(note *models.Note) error {
+// Update updates an existing note and replaces its labels. Attachments are
+// managed separately.
+func (r *NoteRepository) Update(ctx context.Context, note *models.Note) (err error) {
+	ctx, end := r.begin(ctx, "update")
+	defer end(&err)
+
+	if err := validateNote(note); err != nil {
+		return err
+	}
+	// A client that leaves the color out gets the default, as on create
+	if note.Color == "" {
+		note.Color = string(models.ColorWhite)
+	}
 	note.UpdatedAt = time.Now()
 
+	tx, err := r.db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update note: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
         UPDATE notes 
-        SET title = ?, content = ?, color = ?, updated_at = ?
+        SET title = ?, content = ?, color = ?, updated_at = ?,
+            pinned = ?, archived = ?, trashed = ?
         WHERE id = ?
     `
 
-	result, err := r.db.conn.Exec(
+	statement(ctx, query)
+	result, err := tx.ExecContext(
+		ctx,
 		query,
 		note.Title,
 		note.Content,
 		note.Color,
 		note.UpdatedAt,
+		note.Pinned,
+		note.Archived,
+		note.Trashed,
 		note.ID,
 	)
 
@@ -137,17 +435,38 @@ func (r *NoteRepository) Update(note *models.Note) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("note not found")
+		return noteNotFound(note.ID)
+	}
+
+	if err := setLabels(ctx, tx, note.ID, note.Labels); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update note: %w", err)
 	}
 
+	// Reload the stored row for the new sequence number and the fields the
+	// caller did not send
+	stored, err := r.GetByID(ctx, note.ID)
+	if err != nil {
+		return err
+	}
+	*note = *stored
+
+	r.publish(models.EventNoteUpdated, note.ID, note)
 	return nil
 }
 
 // Delete removes a note from the database
-func (r *NoteRepository) Delete(id int64) error {
+func (r *NoteRepository) Delete(ctx context.Context, id int64) (err error) {
+	ctx, end := r.begin(ctx, "delete")
+	defer end(&err)
+
 	query := `DELETE FROM notes WHERE id = ?`
 
-	result, err := r.db.conn.Exec(query, id)
+	statement(ctx, query)
+	result, err := r.db.conn.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete note: %w", err)
 	}
@@ -158,64 +477,366 @@ func (r *NoteRepository) Delete(id int64) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("note not found")
+		return noteNotFound(id)
 	}
 
+	r.publish(models.EventNoteDeleted, id, nil)
 	return nil
 }
 
 // Search performs a full-text search on notes
-func (r *NoteRepository) Search(query string) ([]models.Note, error) {
+func (r *NoteRepository) Search(ctx context.Context, query string) (_ []models.Note, err error) {
+	ctx, end := r.begin(ctx, "search")
+	defer end(&err)
+
 	// Clean and prepare search query
 	searchQuery := strings.TrimSpace(query)
 	if searchQuery == "" {
-		return r.GetAll()
+		return r.GetAll(ctx)
 	}
 
 	// Use FTS5 for search
 	sqlQuery := `
-        SELECT n.id, n.title, n.content, n.color, n.created_at, n.updated_at
-        FROM notes n
-        JOIN notes_fts fts ON n.id = fts.rowid
+        SELECT ` + noteColumns + `
+        FROM notes
+        JOIN notes_fts fts ON notes.id = fts.rowid
         WHERE notes_fts MATCH ?
         ORDER BY rank
     `
 
-	rows, err := r.db.conn.Query(sqlQuery, searchQuery)
+	statement(ctx, sqlQuery)
+	rows, err := r.db.conn.QueryContext(ctx, sqlQuery, searchQuery)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search notes: %w", err)
+		return nil, searchError(err)
 	}
 	defer rows.Close()
 
-	var notes []models.Note
-	for rows.Next() {
-		var note models.Note
-		err := rows.Scan(
-			&note.ID,
-			&note.Title,
-			&note.Content,
-			&note.Color,
-			&note.CreatedAt,
-			&note.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan note: %w", err)
-		}
-		notes = append(notes, note)
+	notes, err := scanNotes(rows)
+	if err != nil {
+		return nil, searchError(err)
 	}
+	return notes, nil
+}
 
-	return notes, rows.Err()
+// searchError reports a malformed search query as a ValidationError. What
+// SQLite says about it is left out: any other error is a database failure.
+func searchError(err error) error {
+	if isQuerySyntaxError(err) {
+		return invalidField("invalid search query", "q", "is not a valid search: check quotes, brackets and operators")
+	}
+	return fmt.Errorf("failed to search notes: %w", err)
 }
 
 // Count returns the total number of notes
-func (r *NoteRepository) Count() (int, error) {
+func (r *NoteRepository) Count(ctx context.Context) (_ int, err error) {
+	ctx, end := r.begin(ctx, "count")
+	defer end(&err)
+
 	var count int
 	query := `SELECT COUNT(*) FROM notes`
 
-	err := r.db.conn.QueryRow(query).Scan(&count)
+	statement(ctx, query)
+	err = r.db.conn.QueryRowContext(ctx, query).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count notes: %w", err)
 	}
 
 	return count, nil
 }
+
+// Stats counts what the database holds
+type Stats struct {
+	Notes    int
+	Pinned   int
+	Archived int
+	Trashed  int
+	// Labels counts the labels used by at least one note
+	Labels          int
+	Attachments     int
+	AttachmentBytes int64
+}
+
+// Stats counts notes by state, labels and attachments
+func (r *NoteRepository) Stats(ctx context.Context) (_ *Stats, err error) {
+	ctx, end := r.begin(ctx, "stats")
+	defer end(&err)
+
+	var stats Stats
+	err = r.db.conn.QueryRowContext(ctx, `
+        SELECT COUNT(*),
+               COALESCE(SUM(pinned), 0),
+               COALESCE(SUM(archived AND NOT trashed), 0),
+               COALESCE(SUM(trashed), 0)
+        FROM notes
+    `).Scan(&stats.Notes, &stats.Pinned, &stats.Archived, &stats.Trashed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notes: %w", err)
+	}
+
+	err = r.db.conn.QueryRowContext(ctx, `SELECT COUNT(DISTINCT label_id) FROM note_labels`).Scan(&stats.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count labels: %w", err)
+	}
+
+	err = r.db.conn.QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(SUM(size), 0) FROM attachments`).Scan(&stats.Attachments, &stats.AttachmentBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count attachments: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// Changes returns the notes written and deleted after sequence number since.
+// With a positive limit at most limit entries are returned and HasMore tells
+// whether the caller should sync again from the returned Seq.
+func (r *NoteRepository) Changes(ctx context.Context, since int64, limit int) (_ *models.SyncResult, err error) {
+	ctx, end := r.begin(ctx, "changes")
+	defer end(&err)
+
+	tx, err := r.db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start sync: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &models.SyncResult{
+		Changed: []models.Note{},
+		Deleted: []models.Tombstone{},
+	}
+
+	// The high-water mark is read in the same transaction as the changes
+	if err := tx.QueryRowContext(ctx, `SELECT seq FROM sync_state WHERE id = 1`).Scan(&result.Seq); err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	// With a limit, one extra row per table tells us whether there is more
+	fetch := -1
+	if limit > 0 {
+		fetch = limit + 1
+	}
+
+	query := `
+        SELECT ` + noteColumns + `
+        FROM notes
+        WHERE seq > ?
+        ORDER BY seq
+        LIMIT ?
+    `
+	statement(ctx, query)
+	rows, err := tx.QueryContext(ctx, query, since, fetch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed notes: %w", err)
+	}
+	changed, err := scanNotes(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, `
+        SELECT note_id, seq, deleted_at
+        FROM note_tombstones
+        WHERE seq > ?
+        ORDER BY seq
+        LIMIT ?
+    `, since, fetch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted notes: %w", err)
+	}
+	var deleted []models.Tombstone
+	for rows.Next() {
+		var t models.Tombstone
+		if err := rows.Scan(&t.ID, &t.Seq, &t.DeletedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan tombstone: %w", err)
+		}
+		deleted = append(deleted, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Merge both lists in sequence order up to the limit
+	i, j := 0, 0
+	for i < len(changed) || j < len(deleted) {
+		if limit > 0 && len(result.Changed)+len(result.Deleted) == limit {
+			result.HasMore = true
+			break
+		}
+		if j >= len(deleted) || (i < len(changed) && changed[i].Seq < deleted[j].Seq) {
+			result.Changed = append(result.Changed, changed[i])
+			i++
+		} else {
+			result.Deleted = append(result.Deleted, deleted[j])
+			j++
+		}
+	}
+
+	if result.HasMore {
+		// Resume right after the last entry returned
+		last := int64(0)
+		if n := len(result.Changed); n > 0 {
+			last = result.Changed[n-1].Seq
+		}
+		if n := len(result.Deleted); n > 0 && result.Deleted[n-1].Seq > last {
+			last = result.Deleted[n-1].Seq
+		}
+		result.Seq = last
+	}
+
+	return result, nil
+}
+
+// AddAttachment stores a file with an existing note
+func (r *NoteRepository) AddAttachment(ctx context.Context, attachment *models.Attachment) (err error) {
+	ctx, end := r.begin(ctx, "add_attachment")
+	defer end(&err)
+
+	tx, err := r.db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add attachment: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Touch the note so the change shows up in sync and events
+	query := `UPDATE notes SET updated_at = ? WHERE id = ?`
+	statement(ctx, query)
+	result, err := tx.ExecContext(ctx, query, time.Now(), attachment.NoteID)
+	if err != nil {
+		return fmt.Errorf("failed to add attachment: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return noteNotFound(attachment.NoteID)
+	}
+
+	if err := insertAttachment(ctx, tx, attachment); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to add attachment: %w", err)
+	}
+
+	if note, err := r.GetByID(ctx, attachment.NoteID); err == nil {
+		r.publish(models.EventNoteUpdated, note.ID, note)
+	}
+	return nil
+}
+
+// GetAttachment retrieves an attachment including its data
+func (r *NoteRepository) GetAttachment(ctx context.Context, id string) (_ *models.Attachment, err error) {
+	ctx, end := r.begin(ctx, "get_attachment")
+	defer end(&err)
+
+	query := `
+        SELECT id, note_id, filename, mime_type, size, data, created_at
+        FROM attachments
+        WHERE id = ?
+    `
+
+	var a models.Attachment
+	statement(ctx, query)
+	err = r.db.conn.QueryRowContext(ctx, query, id).Scan(
+		&a.ID,
+		&a.NoteID,
+		&a.Filename,
+		&a.MimeType,
+		&a.Size,
+		&a.Data,
+		&a.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "attachment", ID: id}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return &a, nil
+}
+
+// insertAttachment writes a single attachment row
+func insertAttachment(ctx context.Context, tx *sql.Tx, a *models.Attachment) error {
+	if a.ID == "" {
+		*a = models.NewAttachment(a.Filename, a.MimeType, a.Data)
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	a.Size = int64(len(a.Data))
+
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO attachments (id, note_id, filename, mime_type, size, data, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `, a.ID, a.NoteID, a.Filename, a.MimeType, a.Size, a.Data, a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store attachment %q: %w", a.Filename, conflictError(err))
+	}
+	return nil
+}
+
+// setLabels replaces the labels of a note, creating missing labels
+func setLabels(ctx context.Context, tx *sql.Tx, noteID int64, labels []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM note_labels WHERE note_id = ?`, noteID); err != nil {
+		return fmt.Errorf("failed to clear labels: %w", err)
+	}
+
+	for _, label := range normalizeLabels(labels) {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO labels (name) VALUES (?) ON CONFLICT(name) DO NOTHING`, label); err != nil {
+			return fmt.Errorf("failed to create label %q: %w", label, err)
+		}
+		_, err := tx.ExecContext(ctx, `
+            INSERT OR IGNORE INTO note_labels (note_id, label_id)
+            SELECT ?, id FROM labels WHERE name = ?
+        `, noteID, label)
+		if err != nil {
+			return fmt.Errorf("failed to set label %q: %w", label, err)
+		}
+	}
+
+	return nil
+}
+
+// normalizeLabels trims labels and drops empty and duplicate ones
+func normalizeLabels(labels []string) []string {
+	seen := make(map[string]bool, len(labels))
+	result := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		key := strings.ToLower(label)
+		if label == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, label)
+	}
+	return result
+}
+
+// withoutData returns attachment metadata without file contents
+func withoutData(attachments []models.Attachment) []models.Attachment {
+	if len(attachments) == 0 {
+		return nil
+	}
+	result := make([]models.Attachment, len(attachments))
+	for i, a := range attachments {
+		a.Data = nil
+		result[i] = a
+	}
+	return result
+}
+
+// scanNotes reads all rows selected with noteColumns
+func scanNotes(rows *sql.Rows) ([]models.Note, error) {
+	var notes []models.Note
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, rows.Err()
+}
@@ -0,0 +1,91 @@
+// internal/database/repository_test.go
+package database_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/internal/database/dbtest"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+func TestSearchStopsWhenCancelled(t *testing.T) {
+	repo := database.NewNoteRepository(dbtest.Open(t), nil)
+	ctx := context.Background()
+	if err := repo.Create(ctx, &models.Note{Title: "Shopping", Content: "shopping list"}); err != nil {
+		t.Fatal(err)
+	}
+	if notes, err := repo.Search(ctx, "shopping"); err != nil || len(notes) != 1 {
+		t.Fatalf("search found %d notes, %v", len(notes), err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := repo.Search(cancelled, "shopping"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled search returned %v, want context.Canceled", err)
+	}
+}
+
+func TestLegacyColors(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := database.NewNoteRepository(db, nil)
+	ctx := context.Background()
+
+	// A note stored before colors were checked, in a database migrated
+	// only that far
+	if _, err := database.Conn(db).Exec(`INSERT INTO notes (id, title, content, color) VALUES (1, 'old', '', 'red'); PRAGMA user_version = 3`); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	note, err := repo.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note.Color != "#ffffff" {
+		t.Errorf("migrated note has color %q", note.Color)
+	}
+	note.Content = "edited"
+	if err := repo.Update(ctx, note); err != nil {
+		t.Errorf("failed to save the migrated note: %v", err)
+	}
+
+	// The same note restored from an old backup
+	restored := &models.Note{ID: 2, Title: "old", Color: "red"}
+	if err := repo.Restore(ctx, restored); err != nil {
+		t.Fatalf("failed to restore a note with a legacy color: %v", err)
+	}
+	if restored.Color != "#ffffff" {
+		t.Errorf("restored note has color %q", restored.Color)
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := database.NewNoteRepository(db, nil)
+	ctx := context.Background()
+
+	for _, query := range []string{`"shopping`, `shopping AND`, `(shopping`, `-shopping`, `*shopping`} {
+		_, err := repo.Search(ctx, query)
+		var invalid *database.ValidationError
+		if !errors.As(err, &invalid) {
+			t.Errorf("%s: got %v, want a validation error", query, err)
+			continue
+		}
+		if strings.Contains(invalid.Error(), "fts5") || strings.Contains(invalid.Error(), "column") {
+			t.Errorf("%s: SQLite's message reaches the user: %v", query, invalid)
+		}
+	}
+
+	// A broken database is not the user's fault
+	if _, err := database.Conn(db).Exec(`DROP TABLE notes_fts`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Search(ctx, "shopping"); err == nil || errors.Is(err, database.ErrValidation) {
+		t.Errorf("got %v, want a database failure", err)
+	}
+}
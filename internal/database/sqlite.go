@@ -2,6 +2,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -14,17 +15,37 @@ type DB struct {
 	conn *sql.DB
 }
 
+// PoolOptions size the connection pool
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// DefaultPoolOptions are used by NewDB
+var DefaultPoolOptions = PoolOptions{
+	MaxOpenConns:    25,
+	MaxIdleConns:    5,
+	ConnMaxLifetime: 5 * time.Minute,
+}
+
 // NewDB creates a new database connection
 func NewDB(dataSourceName string) (*DB, error) {
+	return NewDBWithOptions(dataSourceName, DefaultPoolOptions)
+}
+
+// NewDBWithOptions creates a new database connection with the given pool
+// sizes
+func NewDBWithOptions(dataSourceName string, opts PoolOptions) (*DB, error) {
 	conn, err := sql.Open("sqlite3", dataSourceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	// Configure connection pool
-	conn.SetMaxOpenConns(25)
-	conn.SetMaxIdleConns(5)
-	conn.SetConnMaxLifetime(5 * time.Minute)
+	conn.SetMaxOpenConns(opts.MaxOpenConns)
+	conn.SetMaxIdleConns(opts.MaxIdleConns)
+	conn.SetConnMaxLifetime(opts.ConnMaxLifetime)
 
 	// Test connection
 	if err := conn.Ping(); err != nil {
@@ -34,7 +55,7 @@ func NewDB(dataSourceName string) (*DB, error) {
 	db := &DB{conn: conn}
 
 	// Run migrations
-	if err := db.Migrate(); err != nil {
+	if err := db.Migrate(context.Background()); err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
@@ -46,53 +67,45 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
-// Migrate creates the necessary tables
-func (db *DB) Migrate() error {
-	query := `
-    CREATE TABLE IF NOT EXISTS notes (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        title TEXT NOT NULL,
-        content TEXT,
-        color TEXT DEFAULT '#ffffff',
-        created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-        updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-    );
-
-    CREATE INDEX IF NOT EXISTS idx_notes_created_at ON notes(created_at);
-    CREATE INDEX IF NOT EXISTS idx_notes_updated_at ON notes(updated_at);
-    
-    -- Full-text search table
-    CREATE VIRTUAL TABLE IF NOT EXISTS notes_fts USING fts5(
-        title, 
-        content, 
-        content_rowid=id
-    );
-
-    -- Triggers to keep FTS table in sync
-    CREATE TRIGGER IF NOT EXISTS notes_ai AFTER INSERT ON notes
-    BEGIN
-        INSERT INTO notes_fts(rowid, title, content) 
-        VALUES (new.id, new.title, new.content);
-    END;
-
-    CREATE TRIGGER IF NOT EXISTS notes_ad AFTER DELETE ON notes
-    BEGIN
-        DELETE FROM notes_fts WHERE rowid = old.id;
-    END;
-
-    CREATE TRIGGER IF NOT EXISTS notes_au AFTER UPDATE ON notes
-    BEGIN
-        UPDATE notes_fts 
-        SET title = new.title, content = new.content 
-        WHERE rowid = new.id;
-    END;
-    `
-
-	_, err := db.conn.Exec(query)
-	return err
+// Stats returns statistics of the connection pool
+func (db *DB) Stats() sql.DBStats {
+	return db.conn.Stats()
+}
+
+// Migrate brings the schema up to date, applying each pending migration in
+// its own transaction
+func (db *DB) Migrate(ctx context.Context) error {
+	version, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
 }
 
-// BeginTx starts a new transaction
-func (db *DB) BeginTx() (*sql.Tx, error) {
-	return db.conn.Begin()
+// SchemaVersion returns the number of migrations applied to the database
+func (db *DB) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	if err := db.conn.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
 }
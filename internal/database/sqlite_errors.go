@@ -0,0 +1,45 @@
+// internal/database/sqlite_errors.go
+
+//go:build cgo
+
+package database
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// isUniqueViolation reports whether err is SQLite refusing a row whose key
+// is already taken
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
+// ftsQueryMessages start the messages FTS5 raises for a malformed MATCH
+// query, as opposed to a failing database
+var ftsQueryMessages = []string{
+	"fts5: syntax error",
+	"unterminated string",
+	"no such column: ",
+	"unknown special query",
+	"expected integer",
+}
+
+// isQuerySyntaxError reports whether err is FTS5 refusing a malformed query
+func isQuerySyntaxError(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.Code != sqlite3.ErrError {
+		return false
+	}
+	msg := sqliteErr.Error()
+	for _, prefix := range ftsQueryMessages {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+// internal/database/sqlite_errors_nocgo.go
+
+//go:build !cgo
+
+package database
+
+// Without cgo there is no SQLite driver to report errors
+
+func isUniqueViolation(err error) bool {
+	return false
+}
+
+func isQuerySyntaxError(err error) bool {
+	return false
+}
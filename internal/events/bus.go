@@ -0,0 +1,131 @@
+// internal/events/bus.go
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// DefaultHistorySize is the number of past events kept for resuming streams
+const DefaultHistorySize = 1024
+
+// subscriberBuffer is the channel capacity of each subscriber
+const subscriberBuffer = 64
+
+// Bus fans out note events to all subscribers and keeps a bounded history
+// so that reconnecting clients can resume from the last event they saw.
+type Bus struct {
+	mu          sync.RWMutex
+	lastID      uint64
+	history     []models.NoteEvent
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives events published on the bus
+type Subscription struct {
+	C   chan models.NoteEvent
+	bus *Bus
+}
+
+// NewBus creates a new event bus keeping up to historySize past events
+func NewBus(historySize int) *Bus {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Bus{
+		// Seed IDs from the clock so they keep increasing across restarts and
+		// stale Last-Event-IDs from a previous run are detected.
+		lastID:      uint64(time.Now().UnixMicro()),
+		historySize: historySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns the next event ID and delivers the event to all subscribers.
+// Subscribers that cannot keep up are dropped; they will resume via Last-Event-ID.
+func (b *Bus) Publish(eventType models.NoteEventType, noteID int64, note *models.Note) models.NoteEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := models.NoteEvent{
+		ID:     b.lastID,
+		Type:   eventType,
+		NoteID: noteID,
+		Time:   time.Now(),
+	}
+	if note != nil {
+		n := *note
+		event.Note = &n
+	}
+
+	b.history = append(b.history, event)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.C <- event:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.C)
+		}
+	}
+
+	return event
+}
+
+// Subscribe registers a new subscriber. Events published after lastID that are
+// still in the history are returned as backlog; ok is false if some of them
+// have already been evicted and the client has to reload from scratch.
+func (b *Bus) Subscribe(lastID uint64) (sub *Subscription, backlog []models.NoteEvent, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ok = true
+	switch {
+	case lastID > b.lastID:
+		// The client saw events from a previous server run
+		ok = false
+	case lastID > 0 && lastID < b.lastID:
+		if len(b.history) == 0 || b.history[0].ID > lastID+1 {
+			ok = false
+			break
+		}
+		for _, e := range b.history {
+			if e.ID > lastID {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	sub = &Subscription{
+		C:   make(chan models.NoteEvent, subscriberBuffer),
+		bus: b,
+	}
+	b.subscribers[sub] = struct{}{}
+
+	return sub, backlog, ok
+}
+
+// LastID returns the ID of the most recently published event
+func (b *Bus) LastID() uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.lastID
+}
+
+// Close unregisters the subscription from its bus
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	if _, ok := s.bus.subscribers[s]; ok {
+		delete(s.bus.subscribers, s)
+		close(s.C)
+	}
+}
@@ -0,0 +1,120 @@
+// internal/events/bus_test.go
+package events
+
+import (
+	"testing"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+func TestBusDelivers(t *testing.T) {
+	bus := NewBus(DefaultHistorySize)
+	sub, backlog, ok := bus.Subscribe(0)
+	defer sub.Close()
+	if !ok || len(backlog) != 0 {
+		t.Fatalf("fresh subscription: backlog %v, ok %v", backlog, ok)
+	}
+
+	note := &models.Note{ID: 1, Title: "Shopping"}
+	published := bus.Publish(models.EventNoteCreated, note.ID, note)
+	note.Title = "changed after publishing"
+
+	event := <-sub.C
+	if event.ID != published.ID || event.ID != bus.LastID() || event.Type != models.EventNoteCreated || event.NoteID != 1 {
+		t.Errorf("received %+v, published %+v", event, published)
+	}
+	if event.Note == nil || event.Note.Title != "Shopping" {
+		t.Errorf("event shares the publisher's note: %+v", event.Note)
+	}
+
+	second := bus.Publish(models.EventNoteDeleted, 1, nil)
+	if second.ID <= published.ID {
+		t.Errorf("event IDs went from %d to %d", published.ID, second.ID)
+	}
+	if event := <-sub.C; event.ID != second.ID || event.Note != nil {
+		t.Errorf("received %+v", event)
+	}
+}
+
+func TestBusResume(t *testing.T) {
+	bus := NewBus(3)
+	var ids []uint64
+	for i := range 5 {
+		ids = append(ids, bus.Publish(models.EventNoteUpdated, int64(i), nil).ID)
+	}
+
+	tests := []struct {
+		name    string
+		lastID  uint64
+		backlog []uint64
+		ok      bool
+	}{
+		{"new client", 0, nil, true},
+		{"up to date", ids[4], nil, true},
+		{"missed events still kept", ids[2], ids[3:], true},
+		{"missed all kept events", ids[1], ids[2:], true},
+		{"missed evicted events", ids[0], nil, false},
+		{"previous server run", ids[4] + 1000, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, backlog, ok := bus.Subscribe(tt.lastID)
+			defer sub.Close()
+			if ok != tt.ok {
+				t.Errorf("ok %v, want %v", ok, tt.ok)
+			}
+			var got []uint64
+			for _, e := range backlog {
+				got = append(got, e.ID)
+			}
+			if len(got) != len(tt.backlog) {
+				t.Fatalf("backlog %v, want %v", got, tt.backlog)
+			}
+			for i := range got {
+				if got[i] != tt.backlog[i] {
+					t.Fatalf("backlog %v, want %v", got, tt.backlog)
+				}
+			}
+		})
+	}
+}
+
+func TestBusDropsSlowSubscribers(t *testing.T) {
+	bus := NewBus(DefaultHistorySize)
+	slow, _, _ := bus.Subscribe(0)
+	fast, _, _ := bus.Subscribe(0)
+	defer fast.Close()
+
+	for i := range subscriberBuffer + 1 {
+		bus.Publish(models.EventNoteUpdated, int64(i), nil)
+		<-fast.C
+	}
+
+	// The slow subscriber gets what fit in its buffer, then a closed channel
+	for range subscriberBuffer {
+		if _, ok := <-slow.C; !ok {
+			t.Fatal("buffered events were lost")
+		}
+	}
+	if _, ok := <-slow.C; ok {
+		t.Error("slow subscriber was not dropped")
+	}
+	slow.Close() // closing a dropped subscription again is harmless
+
+	bus.Publish(models.EventNoteUpdated, 0, nil)
+	if _, ok := <-fast.C; !ok {
+		t.Error("fast subscriber was dropped too")
+	}
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	bus := NewBus(DefaultHistorySize)
+	sub, _, _ := bus.Subscribe(0)
+	sub.Close()
+	sub.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("channel still open after Close")
+	}
+	// Publishing to a bus without subscribers must not block or panic
+	bus.Publish(models.EventNoteCreated, 1, nil)
+}
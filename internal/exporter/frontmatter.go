@@ -0,0 +1,129 @@
+// internal/exporter/frontmatter.go
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// frontMatterDelimiter opens and closes the YAML block of a Markdown file
+const frontMatterDelimiter = "---"
+
+// FrontMatter is the YAML header of an exported note. Together with the body
+// it holds everything needed to restore the note.
+type FrontMatter struct {
+	ID          int64           `yaml:"id,omitempty"`
+	Title       string          `yaml:"title"`
+	Color       string          `yaml:"color,omitempty"`
+	Labels      []string        `yaml:"labels,omitempty"`
+	Pinned      bool            `yaml:"pinned"`
+	Archived    bool            `yaml:"archived,omitempty"`
+	Trashed     bool            `yaml:"trashed,omitempty"`
+	Created     time.Time       `yaml:"created"`
+	Updated     time.Time       `yaml:"updated"`
+	Attachments []AttachmentRef `yaml:"attachments,omitempty"`
+}
+
+// AttachmentRef points from a note to an attachment file in the export
+type AttachmentRef struct {
+	ID       string `yaml:"id"`
+	Filename string `yaml:"filename"`
+	MimeType string `yaml:"mime_type"`
+	Path     string `yaml:"path"`
+}
+
+// AttachmentPath is where an attachment is stored inside an export
+func AttachmentPath(a models.Attachment) string {
+	name := path.Base(strings.ReplaceAll(a.Filename, "\\", "/"))
+	if name == "." || name == "/" || name == ".." {
+		name = "file"
+	}
+	return path.Join("attachments", a.ID, name)
+}
+
+// MarshalNote renders note as Markdown with a YAML front matter header. The
+// body is written verbatim so that ParseNote returns the same content.
+func MarshalNote(note models.Note) ([]byte, error) {
+	fm := FrontMatter{
+		ID:       note.ID,
+		Title:    note.Title,
+		Color:    note.Color,
+		Labels:   note.Labels,
+		Pinned:   note.Pinned,
+		Archived: note.Archived,
+		Trashed:  note.Trashed,
+		Created:  note.CreatedAt,
+		Updated:  note.UpdatedAt,
+	}
+	for _, a := range note.Attachments {
+		fm.Attachments = append(fm.Attachments, AttachmentRef{
+			ID:       a.ID,
+			Filename: a.Filename,
+			MimeType: a.MimeType,
+			Path:     AttachmentPath(a),
+		})
+	}
+
+	header, err := yaml.Marshal(fm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode front matter: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelimiter + "\n")
+	buf.Write(header)
+	buf.WriteString(frontMatterDelimiter + "\n")
+	buf.WriteString(note.Content)
+	return buf.Bytes(), nil
+}
+
+// ParseNote reads a Markdown file written by MarshalNote. Files without
+// front matter are accepted too; they become a note titled after name.
+func ParseNote(name string, data []byte) (models.Note, FrontMatter, error) {
+	var fm FrontMatter
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	body := text
+	if rest, ok := strings.CutPrefix(text, frontMatterDelimiter+"\n"); ok {
+		header, content, found := strings.Cut(rest, "\n"+frontMatterDelimiter+"\n")
+		if !found {
+			// Front matter directly followed by the end of the file
+			header, found = strings.CutSuffix(rest, "\n"+frontMatterDelimiter)
+			content = ""
+		}
+		if !found {
+			return models.Note{}, fm, fmt.Errorf("unterminated front matter")
+		}
+		if err := yaml.Unmarshal([]byte(header), &fm); err != nil {
+			return models.Note{}, fm, fmt.Errorf("invalid front matter: %w", err)
+		}
+		body = content
+	} else {
+		fm.Title = strings.TrimSuffix(path.Base(name), path.Ext(name))
+	}
+
+	note := models.Note{
+		ID:        fm.ID,
+		Title:     fm.Title,
+		Content:   body,
+		Color:     fm.Color,
+		Labels:    fm.Labels,
+		Pinned:    fm.Pinned,
+		Archived:  fm.Archived,
+		Trashed:   fm.Trashed,
+		CreatedAt: fm.Created,
+		UpdatedAt: fm.Updated,
+	}
+	if note.Color != "" && !models.ValidateColor(note.Color) {
+		return note, fm, fmt.Errorf("invalid color %q", note.Color)
+	}
+
+	return note, fm, nil
+}
@@ -0,0 +1,184 @@
+// internal/exporter/markdown.go
+package exporter
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// maxSlugLength bounds the title part of exported file names
+const maxSlugLength = 50
+
+// NoteSource provides the notes to export; NoteRepository satisfies it
+type NoteSource interface {
+	GetAll(ctx context.Context) ([]models.Note, error)
+	GetAttachment(ctx context.Context, id string) (*models.Attachment, error)
+}
+
+// Writer receives the files of an export
+type Writer interface {
+	WriteFile(name string, data []byte) error
+}
+
+// ZipWriter writes an export into a zip archive
+type ZipWriter struct {
+	zw *zip.Writer
+}
+
+// NewZipWriter creates a writer that streams a zip archive to w. Close must
+// be called to finish the archive.
+func NewZipWriter(w io.Writer) *ZipWriter {
+	return &ZipWriter{zw: zip.NewWriter(w)}
+}
+
+// WriteFile adds a file to the archive
+func (z *ZipWriter) WriteFile(name string, data []byte) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	f, err := z.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// Close finishes the archive
+func (z *ZipWriter) Close() error {
+	return z.zw.Close()
+}
+
+// DirWriter writes an export into a directory
+type DirWriter string
+
+// WriteFile creates the file and any missing parent directories. Nothing
+// is written outside the directory, not even through symbolic links.
+func (d DirWriter) WriteFile(name string, data []byte) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(string(d), 0o755); err != nil {
+		return err
+	}
+	root, err := os.OpenRoot(string(d))
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	target := filepath.FromSlash(name)
+	if err := root.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return root.WriteFile(target, data, 0o644)
+}
+
+// checkName refuses file names that are absolute or leave the root of the
+// export. Names are made from note titles and attachment metadata, which
+// may come from imported files.
+func checkName(name string) error {
+	if !fs.ValidPath(name) || name == "." || strings.Contains(name, "\\") {
+		return fmt.Errorf("refusing to write %q outside the export", name)
+	}
+	return nil
+}
+
+// ExportMarkdown writes one Markdown file per note, plus its attachments, and
+// returns the number of notes written
+func ExportMarkdown(ctx context.Context, src NoteSource, w Writer) (int, error) {
+	notes, err := src.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, note := range notes {
+		data, err := MarshalNote(note)
+		if err != nil {
+			return 0, fmt.Errorf("failed to export note %d: %w", note.ID, err)
+		}
+		if err := w.WriteFile(NoteFilename(note), data); err != nil {
+			return 0, fmt.Errorf("failed to write note %d: %w", note.ID, err)
+		}
+
+		for _, ref := range note.Attachments {
+			attachment, err := src.GetAttachment(ctx, ref.ID)
+			if err != nil {
+				return 0, fmt.Errorf("failed to export attachment %s: %w", ref.ID, err)
+			}
+			if err := w.WriteFile(AttachmentPath(*attachment), attachment.Data); err != nil {
+				return 0, fmt.Errorf("failed to write attachment %s: %w", ref.ID, err)
+			}
+		}
+	}
+
+	return len(notes), nil
+}
+
+// ExportMarkdownTo exports into target, a zip archive if it ends in .zip and
+// a directory otherwise
+func ExportMarkdownTo(ctx context.Context, src NoteSource, target string) (int, error) {
+	if !strings.EqualFold(filepath.Ext(target), ".zip") {
+		return ExportMarkdown(ctx, src, DirWriter(target))
+	}
+
+	f, err := os.Create(target)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export: %w", err)
+	}
+	defer f.Close()
+
+	zw := NewZipWriter(f)
+	n, err := ExportMarkdown(ctx, src, zw)
+	if err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, fmt.Errorf("failed to finish export: %w", err)
+	}
+	return n, f.Close()
+}
+
+// NoteFilename returns the file name of an exported note, e.g.
+// "12-shopping-list.md"
+func NoteFilename(note models.Note) string {
+	if slug := slugify(note.Title); slug != "" {
+		return fmt.Sprintf("%d-%s.md", note.ID, slug)
+	}
+	return fmt.Sprintf("%d.md", note.ID)
+}
+
+// slugify turns a title into a lowercase, dash separated file name part
+func slugify(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		} else {
+			dash = true
+		}
+		if b.Len() >= maxSlugLength {
+			break
+		}
+	}
+	return b.String()
+}
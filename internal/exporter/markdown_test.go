@@ -0,0 +1,74 @@
+// internal/exporter/markdown_test.go
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// escapes are names that point outside the root of an export
+var escapes = []string{
+	"../escaped.txt",
+	"attachments/../../escaped.txt",
+	"/etc/escaped.txt",
+	`..\escaped.txt`,
+	"",
+	".",
+}
+
+func TestDirWriterStaysInside(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "out", "exp")
+	w := DirWriter(dir)
+
+	if err := w.WriteFile("attachments/id/file.txt", []byte("ok")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "attachments", "id", "file.txt")); err != nil || string(data) != "ok" {
+		t.Fatalf("read back %q, %v", data, err)
+	}
+
+	for _, name := range escapes {
+		if err := w.WriteFile(name, []byte("pwned")); err == nil {
+			t.Errorf("wrote %q", name)
+		}
+	}
+
+	// A link planted inside the export does not lead out of it either
+	if err := os.Symlink(root, filepath.Join(dir, "link")); err != nil {
+		t.Skip(err)
+	}
+	if err := w.WriteFile("link/escaped.txt", []byte("pwned")); err == nil {
+		t.Error("wrote through a symbolic link")
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped.txt")); err == nil {
+		t.Error("a file was written outside the export")
+	}
+}
+
+func TestZipWriterStaysInside(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewZipWriter(&buf)
+	for _, name := range escapes {
+		if err := w.WriteFile(name, []byte("pwned")); err == nil {
+			t.Errorf("added %q", name)
+		}
+	}
+	if err := w.WriteFile("notes/ok.md", []byte("ok")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "notes/ok.md" {
+		t.Errorf("archive holds %v", zr.File)
+	}
+}
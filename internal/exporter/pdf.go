@@ -0,0 +1,488 @@
+// internal/exporter/pdf.go
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/russross/blackfriday/v2"
+
+	"github.com/Smil3MoreGH/gokeep/internal/markdown"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// Page layout in millimetres and points
+const (
+	pdfMargin     = 20.0
+	pdfLineHeight = 5.5
+	pdfFontSize   = 11.0
+	pdfIndent     = 6.0
+)
+
+// AttachmentSource loads attachments embedded in notes; NoteRepository
+// satisfies it
+type AttachmentSource interface {
+	GetAttachment(ctx context.Context, id string) (*models.Attachment, error)
+}
+
+// pdfWriter renders Markdown into a PDF document. It uses the core PDF
+// fonts, so text is limited to the Windows-1252 character set.
+type pdfWriter struct {
+	ctx         context.Context
+	pdf         *gofpdf.Fpdf
+	tr          func(string) string
+	attachments AttachmentSource
+
+	bold, italic, strike, mono int
+	size                       float64
+	link                       string
+	images                     map[string]bool
+}
+
+// ExportPDF renders notes into one PDF, one note per page, with the note
+// color as an accent bar
+func ExportPDF(ctx context.Context, w io.Writer, notes []models.Note, attachments AttachmentSource) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetCreator("gokeep", true)
+	if len(notes) == 1 {
+		pdf.SetTitle(notes[0].Title, true)
+	}
+
+	pw := &pdfWriter{
+		ctx:         ctx,
+		pdf:         pdf,
+		tr:          pdf.UnicodeTranslatorFromDescriptor(""),
+		attachments: attachments,
+		size:        pdfFontSize,
+		images:      make(map[string]bool),
+	}
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 5)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 5, strconv.Itoa(pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	for _, note := range notes {
+		pw.note(note)
+	}
+	if len(notes) == 0 {
+		pdf.AddPage()
+	}
+
+	return pdf.Output(w)
+}
+
+// note renders one note starting on a new page
+func (pw *pdfWriter) note(note models.Note) {
+	pdf := pw.pdf
+	pdf.AddPage()
+	pageWidth, _ := pdf.GetPageSize()
+
+	// Accent bar in the note color; white notes get a light gray one
+	r, g, b := parseHexColor(note.Color)
+	if r > 245 && g > 245 && b > 245 {
+		r, g, b = parseHexColor(string(models.ColorGray))
+	}
+	pdf.SetFillColor(r, g, b)
+	pdf.Rect(0, 0, pageWidth, 8, "F")
+	pdf.Rect(pdfMargin-4, pdfMargin, 1.5, 10, "F")
+
+	if note.Title != "" {
+		pdf.Bookmark(pw.tr(note.Title), 0, -1)
+		pdf.SetFont("Helvetica", "B", 18)
+		pdf.SetTextColor(32, 33, 36)
+		pdf.MultiCell(0, 8, pw.tr(note.Title), "", "L", false)
+	}
+
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetTextColor(95, 99, 104)
+	meta := "Updated " + note.UpdatedAt.Format("Jan 2, 2006 15:04")
+	if len(note.Labels) > 0 {
+		meta += "  ·  " + strings.Join(note.Labels, ", ")
+	}
+	pdf.MultiCell(0, 5, pw.tr(meta), "", "L", false)
+	pdf.Ln(4)
+
+	pdf.SetTextColor(32, 33, 36)
+	pw.setFont()
+	pw.blocks(markdown.Parse(note.Content))
+}
+
+// blocks renders the block level children of n
+func (pw *pdfWriter) blocks(n *blackfriday.Node) {
+	for child := n.FirstChild; child != nil; child = child.Next {
+		pw.block(child)
+	}
+}
+
+func (pw *pdfWriter) block(n *blackfriday.Node) {
+	pdf := pw.pdf
+
+	switch n.Type {
+	case blackfriday.Paragraph:
+		pw.inlines(n)
+		pdf.Ln(pdfLineHeight)
+		if n.Parent == nil || n.Parent.Type != blackfriday.Item || !n.Parent.ListData.Tight {
+			pdf.Ln(pdfLineHeight / 2)
+		}
+
+	case blackfriday.Heading:
+		pdf.Ln(pdfLineHeight / 2)
+		pw.size = []float64{16, 14, 13, 12, 11, 11}[min(max(n.Level, 1), 6)-1]
+		pw.bold++
+		pw.setFont()
+		pw.inlines(n)
+		pdf.Ln(pw.size * 0.5)
+		pw.bold--
+		pw.size = pdfFontSize
+		pw.setFont()
+		pdf.Ln(pdfLineHeight / 2)
+
+	case blackfriday.List:
+		pw.list(n)
+		if n.Parent == nil || n.Parent.Type != blackfriday.Item {
+			pdf.Ln(pdfLineHeight / 2)
+		}
+
+	case blackfriday.BlockQuote:
+		left, _, _, _ := pdf.GetMargins()
+		top := pdf.GetY()
+		pdf.SetLeftMargin(left + pdfIndent)
+		pdf.SetX(left + pdfIndent)
+		pdf.SetTextColor(95, 99, 104)
+		pw.italic++
+		pw.setFont()
+		pw.blocks(n)
+		pw.italic--
+		pw.setFont()
+		pdf.SetTextColor(32, 33, 36)
+		pdf.SetLeftMargin(left)
+		if bottom := pdf.GetY(); bottom > top {
+			pdf.SetDrawColor(218, 220, 224)
+			pdf.SetLineWidth(0.8)
+			pdf.Line(left+2, top, left+2, bottom-pdfLineHeight/2)
+			pdf.SetLineWidth(0.2)
+		}
+
+	case blackfriday.CodeBlock:
+		pw.mono++
+		pw.setFont()
+		pdf.SetFillColor(241, 243, 244)
+		pdf.MultiCell(0, pdfLineHeight-0.5, pw.tr(strings.TrimRight(string(n.Literal), "\n")), "", "L", true)
+		pw.mono--
+		pw.setFont()
+		pdf.Ln(pdfLineHeight / 2)
+
+	case blackfriday.HorizontalRule:
+		left, _, right, _ := pdf.GetMargins()
+		pageWidth, _ := pdf.GetPageSize()
+		y := pdf.GetY() + pdfLineHeight/2
+		pdf.SetDrawColor(218, 220, 224)
+		pdf.Line(left, y, pageWidth-right, y)
+		pdf.Ln(pdfLineHeight)
+
+	case blackfriday.Table:
+		pw.table(n)
+		pdf.Ln(pdfLineHeight / 2)
+
+	case blackfriday.HTMLBlock:
+		// Raw HTML has no PDF equivalent
+
+	default:
+		pw.inlines(n)
+	}
+}
+
+// list renders the items of a list with bullets or numbers
+func (pw *pdfWriter) list(n *blackfriday.Node) {
+	pdf := pw.pdf
+	left, _, _, _ := pdf.GetMargins()
+	pdf.SetLeftMargin(left + pdfIndent)
+
+	number := 1
+	for item := n.FirstChild; item != nil; item = item.Next {
+		marker := pw.tr("•")
+		if n.ListData.ListFlags&blackfriday.ListTypeOrdered != 0 {
+			marker = fmt.Sprintf("%d.", number)
+			number++
+		}
+		pdf.SetX(left)
+		pdf.CellFormat(pdfIndent, pdfLineHeight, marker, "", 0, "L", false, 0, "")
+		pw.blocks(item)
+	}
+
+	pdf.SetLeftMargin(left)
+	pdf.SetX(left)
+}
+
+// table renders a table with equally wide columns and wrapped cells
+func (pw *pdfWriter) table(n *blackfriday.Node) {
+	pdf := pw.pdf
+
+	var rows [][]string
+	var header []bool
+	n.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering {
+			return blackfriday.GoToNext
+		}
+		switch node.Type {
+		case blackfriday.TableRow:
+			rows = append(rows, nil)
+			header = append(header, node.Parent != nil && node.Parent.Type == blackfriday.TableHead)
+		case blackfriday.TableCell:
+			rows[len(rows)-1] = append(rows[len(rows)-1], pw.tr(plainText(node)))
+			return blackfriday.SkipChildren
+		}
+		return blackfriday.GoToNext
+	})
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return
+	}
+
+	left, _, right, _ := pdf.GetMargins()
+	pageWidth, pageHeight := pdf.GetPageSize()
+	width := (pageWidth - left - right) / float64(columns)
+	lineHeight := pdfLineHeight
+
+	pdf.SetDrawColor(218, 220, 224)
+	for i, row := range rows {
+		style := ""
+		if header[i] {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, pdfFontSize-1)
+
+		lines := make([][][]byte, columns)
+		height := lineHeight
+		for c := 0; c < columns; c++ {
+			text := ""
+			if c < len(row) {
+				text = row[c]
+			}
+			lines[c] = pdf.SplitLines([]byte(text), width-2)
+			height = max(height, float64(len(lines[c]))*lineHeight)
+		}
+
+		if pdf.GetY()+height > pageHeight-pdfMargin {
+			pdf.AddPage()
+		}
+		y := pdf.GetY()
+		for c := 0; c < columns; c++ {
+			x := left + float64(c)*width
+			if header[i] {
+				pdf.SetFillColor(241, 243, 244)
+				pdf.Rect(x, y, width, height, "FD")
+			} else {
+				pdf.Rect(x, y, width, height, "D")
+			}
+			for l, line := range lines[c] {
+				pdf.SetXY(x+1, y+float64(l)*lineHeight)
+				pdf.CellFormat(width-2, lineHeight, string(line), "", 0, "L", false, 0, "")
+			}
+		}
+		pdf.SetXY(left, y+height)
+	}
+	pw.setFont()
+}
+
+// inlines renders the inline children of n as flowing text
+func (pw *pdfWriter) inlines(n *blackfriday.Node) {
+	for child := n.FirstChild; child != nil; child = child.Next {
+		pw.inline(child)
+	}
+}
+
+func (pw *pdfWriter) inline(n *blackfriday.Node) {
+	pdf := pw.pdf
+
+	switch n.Type {
+	case blackfriday.Text:
+		pw.write(string(n.Literal))
+	case blackfriday.Softbreak:
+		pw.write(" ")
+	case blackfriday.Hardbreak:
+		pdf.Ln(pdfLineHeight)
+	case blackfriday.Code:
+		pw.mono++
+		pw.setFont()
+		pw.write(string(n.Literal))
+		pw.mono--
+		pw.setFont()
+	case blackfriday.Emph:
+		pw.styled(&pw.italic, n)
+	case blackfriday.Strong:
+		pw.styled(&pw.bold, n)
+	case blackfriday.Del:
+		pw.styled(&pw.strike, n)
+	case blackfriday.Link:
+		link := pw.link
+		pw.link = string(n.LinkData.Destination)
+		pdf.SetTextColor(26, 115, 232)
+		pw.inlines(n)
+		pdf.SetTextColor(32, 33, 36)
+		pw.link = link
+	case blackfriday.Image:
+		pw.image(n)
+	case blackfriday.HTMLSpan:
+		// Raw HTML has no PDF equivalent
+	default:
+		if n.FirstChild != nil {
+			pw.inlines(n)
+		} else {
+			pw.write(string(n.Literal))
+		}
+	}
+}
+
+// styled renders the children of n with a style counter raised
+func (pw *pdfWriter) styled(counter *int, n *blackfriday.Node) {
+	*counter++
+	pw.setFont()
+	pw.inlines(n)
+	*counter--
+	pw.setFont()
+}
+
+// write adds flowing text, as a link when inside one
+func (pw *pdfWriter) write(text string) {
+	if text == "" {
+		return
+	}
+	lineHeight := max(pdfLineHeight, pw.size*0.5)
+	if pw.link != "" {
+		pw.pdf.WriteLinkString(lineHeight, pw.tr(text), pw.link)
+		return
+	}
+	pw.pdf.Write(lineHeight, pw.tr(text))
+}
+
+// image embeds an attachment image scaled to the page width. Other images
+// are written as links to their source.
+func (pw *pdfWriter) image(n *blackfriday.Node) {
+	pdf := pw.pdf
+	dest := string(n.LinkData.Destination)
+	alt := plainText(n)
+
+	id, ok := strings.CutPrefix(dest, models.AttachmentURLPrefix)
+	if !ok || pw.attachments == nil || !pw.registerImage(id) {
+		if alt == "" {
+			alt = dest
+		}
+		link := pw.link
+		pw.link = dest
+		pw.write(alt)
+		pw.link = link
+		return
+	}
+
+	info := pdf.GetImageInfo(id)
+	left, _, right, _ := pdf.GetMargins()
+	pageWidth, pageHeight := pdf.GetPageSize()
+	maxWidth := pageWidth - left - right
+	maxHeight := pageHeight - 3*pdfMargin
+
+	width, height := info.Extent()
+	if width > maxWidth {
+		width, height = maxWidth, height*maxWidth/width
+	}
+	if height > maxHeight {
+		width, height = width*maxHeight/height, maxHeight
+	}
+
+	if pdf.GetX() > left {
+		pdf.Ln(pdfLineHeight)
+	}
+	pdf.ImageOptions(id, left, -1, width, height, true, gofpdf.ImageOptions{}, 0, "")
+}
+
+// registerImage loads an attachment into the document once; it reports
+// false for missing attachments and formats PDF cannot embed
+func (pw *pdfWriter) registerImage(id string) bool {
+	if registered, seen := pw.images[id]; seen {
+		return registered
+	}
+	pw.images[id] = false
+
+	attachment, err := pw.attachments.GetAttachment(pw.ctx, id)
+	if err != nil {
+		return false
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(attachment.Data))
+	if err != nil {
+		return false
+	}
+	imageType := map[string]string{"jpeg": "JPG", "png": "PNG", "gif": "GIF"}[format]
+	if imageType == "" {
+		return false
+	}
+
+	pw.pdf.RegisterImageOptionsReader(id, gofpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(attachment.Data))
+	if !pw.pdf.Ok() {
+		// A broken image must not spoil the rest of the document
+		pw.pdf.ClearError()
+		return false
+	}
+	pw.images[id] = true
+	return true
+}
+
+// setFont applies the current style counters
+func (pw *pdfWriter) setFont() {
+	family, style := "Helvetica", ""
+	if pw.mono > 0 {
+		family = "Courier"
+	}
+	if pw.bold > 0 {
+		style += "B"
+	}
+	if pw.italic > 0 {
+		style += "I"
+	}
+	if pw.strike > 0 {
+		style += "S"
+	}
+	size := pw.size
+	if pw.mono > 0 {
+		size--
+	}
+	pw.pdf.SetFont(family, style, size)
+}
+
+// plainText returns the text content of n and its descendants
+func plainText(n *blackfriday.Node) string {
+	var b strings.Builder
+	n.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && (node.Type == blackfriday.Text || node.Type == blackfriday.Code) {
+			b.Write(node.Literal)
+		}
+		return blackfriday.GoToNext
+	})
+	return b.String()
+}
+
+// parseHexColor parses a #rrggbb color, falling back to white
+func parseHexColor(color string) (int, int, int) {
+	value, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil || len(color) != 7 {
+		return 255, 255, 255
+	}
+	return int(value >> 16 & 0xff), int(value >> 8 & 0xff), int(value & 0xff)
+}
@@ -0,0 +1,423 @@
+// internal/exporter/site.go
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/russross/blackfriday/v2"
+
+	"github.com/Smil3MoreGH/gokeep/internal/markdown"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+//go:embed site/*
+var siteFS embed.FS
+
+// siteTemplates holds one template per page kind, each sharing the layout
+var siteTemplates = func() map[string]*template.Template {
+	layout := template.Must(template.ParseFS(siteFS, "site/layout.html"))
+	templates := make(map[string]*template.Template)
+	for _, name := range []string{"list", "note", "tags", "search"} {
+		t := template.Must(layout.Clone())
+		templates[name] = template.Must(t.ParseFS(siteFS, "site/"+name+".html"))
+	}
+	return templates
+}()
+
+// maxExcerptLength bounds the preview text on index and tag pages
+const maxExcerptLength = 160
+
+// noteLinkPatterns match links from one note to another: the note's API URL
+// and the file names of a Markdown export
+var noteLinkPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(?:/api)?/notes/(\d+)/?$`),
+	regexp.MustCompile(`^(?:\.{1,2}/)*(\d+)(?:-[^/]*)?\.md$`),
+}
+
+// SiteOptions controls which notes are published and how the site is titled
+type SiteOptions struct {
+	Label string
+	Title string
+}
+
+// sitePage is a published note
+type sitePage struct {
+	Note    models.Note
+	Path    string
+	HTML    template.HTML
+	Excerpt string
+	Text    string
+	Tags    []*siteTag
+	Files   []siteFile
+}
+
+// siteTag is a label together with the published notes carrying it
+type siteTag struct {
+	Name  string
+	Path  string
+	Pages []*sitePage
+}
+
+type siteFile struct {
+	Name string
+	Path string
+}
+
+// pageData is passed to the page templates. Root leads from the page back
+// to the top of the site, so the site works from any directory.
+type pageData struct {
+	SiteTitle string
+	Title     string
+	Root      string
+	Page      *sitePage
+	Pages     []*sitePage
+	Tag       *siteTag
+	Tags      []*siteTag
+}
+
+// searchEntry is one note in search-index.js
+type searchEntry struct {
+	Title  string   `json:"title"`
+	URL    string   `json:"url"`
+	Labels []string `json:"labels"`
+	Text   string   `json:"text"`
+}
+
+type siteBuilder struct {
+	ctx  context.Context
+	src  NoteSource
+	w    Writer
+	opts SiteOptions
+
+	pages       []*sitePage
+	byID        map[int64]*sitePage
+	tags        []*siteTag
+	attachments map[string]models.Attachment
+	copied      map[string]bool
+}
+
+// Publish renders the notes labelled opts.Label as a static website: one
+// page per note, an index, a page per tag and a client-side search. Links
+// between published notes are resolved to their pages, links to other notes
+// are dropped, and referenced attachments are copied alongside. It returns
+// the number of notes published.
+func Publish(ctx context.Context, src NoteSource, w Writer, opts SiteOptions) (int, error) {
+	if opts.Label == "" {
+		return 0, fmt.Errorf("no label given")
+	}
+	if opts.Title == "" {
+		opts.Title = "gokeep"
+	}
+
+	notes, err := src.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	b := &siteBuilder{
+		ctx:         ctx,
+		src:         src,
+		w:           w,
+		opts:        opts,
+		byID:        make(map[int64]*sitePage),
+		attachments: make(map[string]models.Attachment),
+		copied:      make(map[string]bool),
+	}
+	for _, note := range notes {
+		if note.Trashed || !hasLabel(note.Labels, opts.Label) {
+			continue
+		}
+		// Only files of published notes may be linked, so a public note
+		// cannot pull a private note's file into the site
+		for _, attachment := range note.Attachments {
+			b.attachments[attachment.ID] = attachment
+		}
+		page := &sitePage{
+			Note: note,
+			Path: "notes/" + strings.TrimSuffix(NoteFilename(note), ".md") + ".html",
+		}
+		b.pages = append(b.pages, page)
+		b.byID[note.ID] = page
+	}
+
+	// Pinned notes first, then the most recently updated
+	sort.SliceStable(b.pages, func(i, j int) bool {
+		a, c := b.pages[i].Note, b.pages[j].Note
+		if a.Pinned != c.Pinned {
+			return a.Pinned
+		}
+		return a.UpdatedAt.After(c.UpdatedAt)
+	})
+
+	b.collectTags()
+	for _, page := range b.pages {
+		if err := b.renderNote(page); err != nil {
+			return 0, fmt.Errorf("failed to publish note %d: %w", page.Note.ID, err)
+		}
+	}
+	if err := b.writeIndexes(); err != nil {
+		return 0, err
+	}
+
+	return len(b.pages), nil
+}
+
+// collectTags groups the published notes by label. The publishing label is
+// left out, since every note carries it.
+func (b *siteBuilder) collectTags() {
+	byName := make(map[string]*siteTag)
+	used := make(map[string]bool)
+	for _, page := range b.pages {
+		for _, label := range page.Note.Labels {
+			if strings.EqualFold(label, b.opts.Label) {
+				continue
+			}
+			key := strings.ToLower(label)
+			tag, ok := byName[key]
+			if !ok {
+				base := slugify(label)
+				if base == "" {
+					base = "tag"
+				}
+				slug := base
+				for n := 2; used[slug]; n++ {
+					slug = fmt.Sprintf("%s-%d", base, n)
+				}
+				used[slug] = true
+
+				tag = &siteTag{Name: label, Path: "tags/" + slug + ".html"}
+				byName[key] = tag
+				b.tags = append(b.tags, tag)
+			}
+			tag.Pages = append(tag.Pages, page)
+			page.Tags = append(page.Tags, tag)
+		}
+	}
+
+	sort.Slice(b.tags, func(i, j int) bool {
+		return strings.ToLower(b.tags[i].Name) < strings.ToLower(b.tags[j].Name)
+	})
+}
+
+// renderNote converts a note's Markdown to HTML and writes its page
+func (b *siteBuilder) renderNote(page *sitePage) error {
+	const root = "../"
+	ast := markdown.Parse(page.Note.Content)
+
+	// Collect first: the tree must not change while it is walked
+	var links []*blackfriday.Node
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && (node.Type == blackfriday.Link || node.Type == blackfriday.Image) {
+			links = append(links, node)
+		}
+		return blackfriday.GoToNext
+	})
+	for _, node := range links {
+		dest, ok, err := b.resolve(string(node.LinkData.Destination), root)
+		if err != nil {
+			return err
+		}
+		if ok {
+			node.LinkData.Destination = []byte(dest)
+			continue
+		}
+		// Keep the link text, or an image's alt text, without the link
+		for node.FirstChild != nil {
+			node.InsertBefore(node.FirstChild)
+		}
+		node.Unlink()
+	}
+
+	var buf bytes.Buffer
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{Flags: blackfriday.CommonHTMLFlags})
+	renderer.RenderHeader(&buf, ast)
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return renderer.RenderNode(&buf, node, entering)
+	})
+	renderer.RenderFooter(&buf, ast)
+	page.HTML = template.HTML(markdown.Sanitize(buf.Bytes()))
+
+	page.Text = searchText(ast)
+	page.Excerpt = page.Text
+	if utf8.RuneCountInString(page.Excerpt) > maxExcerptLength {
+		page.Excerpt = string([]rune(page.Excerpt)[:maxExcerptLength]) + "…"
+	}
+
+	for _, ref := range page.Note.Attachments {
+		path, err := b.copyAttachment(ref)
+		if err != nil {
+			return err
+		}
+		page.Files = append(page.Files, siteFile{Name: ref.Filename, Path: path})
+	}
+
+	return b.writePage("note", page.Path, pageData{
+		Title: page.Note.Title,
+		Root:  root,
+		Page:  page,
+	})
+}
+
+// resolve rewrites a link destination for the site. It reports false for
+// links that must be dropped: notes that are not published and attachments
+// that are not attached to a published note.
+func (b *siteBuilder) resolve(dest, root string) (string, bool, error) {
+	if id, ok := strings.CutPrefix(dest, models.AttachmentURLPrefix); ok {
+		attachment, ok := b.attachments[id]
+		if !ok {
+			return "", false, nil
+		}
+		path, err := b.copyAttachment(attachment)
+		return root + path, err == nil, err
+	}
+
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return dest, true, nil
+	}
+	for _, pattern := range noteLinkPatterns {
+		m := pattern.FindStringSubmatch(u.Path)
+		if m == nil {
+			continue
+		}
+		id, _ := strconv.ParseInt(m[1], 10, 64)
+		page, ok := b.byID[id]
+		if !ok {
+			return "", false, nil
+		}
+		target := root + page.Path
+		if u.Fragment != "" {
+			target += "#" + u.Fragment
+		}
+		return target, true, nil
+	}
+	return dest, true, nil
+}
+
+// copyAttachment writes an attachment into the site once and returns its
+// path relative to the site root
+func (b *siteBuilder) copyAttachment(ref models.Attachment) (string, error) {
+	segments := strings.Split(AttachmentPath(ref), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	path := strings.Join(segments, "/")
+
+	if b.copied[ref.ID] {
+		return path, nil
+	}
+	attachment, err := b.src.GetAttachment(b.ctx, ref.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load attachment %s: %w", ref.ID, err)
+	}
+	if err := b.w.WriteFile(AttachmentPath(*attachment), attachment.Data); err != nil {
+		return "", fmt.Errorf("failed to write attachment %s: %w", ref.ID, err)
+	}
+	b.copied[ref.ID] = true
+	return path, nil
+}
+
+// writeIndexes writes the index, tag and search pages and the static assets
+func (b *siteBuilder) writeIndexes() error {
+	if err := b.writePage("list", "index.html", pageData{Pages: b.pages}); err != nil {
+		return err
+	}
+	if err := b.writePage("tags", "tags/index.html", pageData{Title: "Tags", Root: "../", Tags: b.tags}); err != nil {
+		return err
+	}
+	for _, tag := range b.tags {
+		data := pageData{Title: tag.Name, Root: "../", Tag: tag, Pages: tag.Pages}
+		if err := b.writePage("list", tag.Path, data); err != nil {
+			return err
+		}
+	}
+	if err := b.writePage("search", "search.html", pageData{Title: "Search"}); err != nil {
+		return err
+	}
+
+	// A script rather than JSON, so the search also works from file:// URLs
+	index := make([]searchEntry, 0, len(b.pages))
+	for _, page := range b.pages {
+		labels := page.Note.Labels
+		if labels == nil {
+			labels = []string{}
+		}
+		index = append(index, searchEntry{Title: page.Note.Title, URL: page.Path, Labels: labels, Text: page.Text})
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	script := append([]byte("window.searchIndex = "), data...)
+	script = append(script, ";\n"...)
+	if err := b.w.WriteFile("search-index.js", script); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+
+	for _, asset := range []string{"style.css", "search.js"} {
+		data, err := siteFS.ReadFile("site/" + asset)
+		if err != nil {
+			return err
+		}
+		if err := b.w.WriteFile(asset, data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", asset, err)
+		}
+	}
+	return nil
+}
+
+// writePage renders a page template to name
+func (b *siteBuilder) writePage(kind, name string, data pageData) error {
+	data.SiteTitle = b.opts.Title
+	var buf bytes.Buffer
+	if err := siteTemplates[kind].ExecuteTemplate(&buf, "layout", data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	if err := b.w.WriteFile(name, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// searchText returns the text of a note with blocks separated by spaces
+func searchText(ast *blackfriday.Node) string {
+	var b strings.Builder
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		switch node.Type {
+		case blackfriday.Text, blackfriday.Code, blackfriday.CodeBlock:
+			if entering {
+				b.Write(node.Literal)
+			}
+		case blackfriday.Paragraph, blackfriday.Heading, blackfriday.Item, blackfriday.TableCell:
+			if !entering {
+				b.WriteByte(' ')
+			}
+		case blackfriday.Softbreak, blackfriday.Hardbreak:
+			b.WriteByte(' ')
+		}
+		return blackfriday.GoToNext
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// hasLabel reports whether labels contains label, ignoring case like the
+// labels table does
+func hasLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if strings.EqualFold(l, label) {
+			return true
+		}
+	}
+	return false
+}
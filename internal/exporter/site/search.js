@@ -0,0 +1,45 @@
+// search.js – searches the notes listed in search-index.js
+(function () {
+    var input = document.getElementById("search");
+    var results = document.getElementById("results");
+    var index = window.searchIndex || [];
+
+    function render() {
+        var terms = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+        results.textContent = "";
+        if (terms.length === 0) {
+            return;
+        }
+
+        index.forEach(function (entry) {
+            var haystack = (entry.title + " " + entry.labels.join(" ") + " " + entry.text).toLowerCase();
+            var matches = terms.every(function (term) {
+                return haystack.indexOf(term) !== -1;
+            });
+            if (!matches) {
+                return;
+            }
+
+            var item = document.createElement("li");
+            var link = document.createElement("a");
+            link.href = entry.url;
+            link.textContent = entry.title || "Untitled";
+            item.appendChild(link);
+            results.appendChild(item);
+        });
+
+        if (!results.firstChild) {
+            var empty = document.createElement("li");
+            empty.className = "empty";
+            empty.textContent = "No matching notes.";
+            results.appendChild(empty);
+        }
+    }
+
+    input.addEventListener("input", render);
+    var query = new URLSearchParams(window.location.search).get("q");
+    if (query) {
+        input.value = query;
+    }
+    render();
+})();
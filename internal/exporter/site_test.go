@@ -0,0 +1,90 @@
+// internal/exporter/site_test.go
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// memSource serves notes and their attachments from memory
+type memSource []models.Note
+
+func (s memSource) GetAll(ctx context.Context) ([]models.Note, error) {
+	return s, nil
+}
+
+func (s memSource) GetAttachment(ctx context.Context, id string) (*models.Attachment, error) {
+	for _, note := range s {
+		for _, attachment := range note.Attachments {
+			if attachment.ID == id {
+				return &attachment, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("attachment %s not found", id)
+}
+
+// memWriter collects the files of an export
+type memWriter map[string][]byte
+
+func (w memWriter) WriteFile(name string, data []byte) error {
+	w[name] = data
+	return nil
+}
+
+func TestPublishLeavesPrivateNotesOut(t *testing.T) {
+	public := models.NewAttachment("public.txt", "text/plain", []byte("public"))
+	private := models.NewAttachment("private.txt", "text/plain", []byte("secret"))
+	trashed := models.NewAttachment("trashed.txt", "text/plain", []byte("binned"))
+
+	src := memSource{
+		{
+			ID:     1,
+			Title:  "Public",
+			Labels: []string{"blog"},
+			Content: "[own](" + public.URL() + ") [theirs](" + private.URL() + ") " +
+				"[bin](" + trashed.URL() + ") [note](/notes/2) [gone](/notes/3)",
+			Attachments: []models.Attachment{public},
+		},
+		{ID: 2, Title: "Private", Labels: []string{"diary"}, Attachments: []models.Attachment{private}},
+		{ID: 3, Title: "Trashed", Labels: []string{"blog"}, Trashed: true, Attachments: []models.Attachment{trashed}},
+	}
+	w := memWriter{}
+	n, err := Publish(context.Background(), src, w, SiteOptions{Label: "blog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("published %d notes, want 1", n)
+	}
+
+	if _, ok := w[AttachmentPath(public)]; !ok {
+		t.Errorf("attachment of the published note is missing")
+	}
+	var page string
+	for name, data := range w {
+		switch {
+		case strings.Contains(string(data), "secret"), strings.Contains(string(data), "binned"):
+			t.Errorf("%s leaks a file of an unpublished note", name)
+		case strings.HasPrefix(name, "notes/"):
+			if page != "" {
+				t.Errorf("more than one note page: %s", name)
+			}
+			page = string(data)
+		}
+	}
+	for _, id := range []string{private.ID, trashed.ID, "notes/2", "notes/3"} {
+		if strings.Contains(page, id) {
+			t.Errorf("page still links to %s", id)
+		}
+	}
+	for _, text := range []string{"theirs", "bin", "note", "gone"} {
+		if !strings.Contains(page, text) {
+			t.Errorf("page lost the link text %q", text)
+		}
+	}
+}
@@ -3,29 +3,40 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/Smil3MoreGH/gokeep/internal/database"
-	"github.com/Smil3MoreGH/gokeep/internal/models"
+	"github.com/Smil3MoreGH/gokeep/internal/events"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
 	"github.com/go-chi/chi/v5"
 )
 
 // APIHandler handles all API requests
 type APIHandler struct {
 	repo *database.NoteRepository
+	bus  *events.Bus
+
+	enexMaxSize int64
 }
 
 // NewAPIHandler creates a new API handler
-func NewAPIHandler(repo *database.NoteRepository) *APIHandler {
-	return &APIHandler{repo: repo}
+func NewAPIHandler(repo *database.NoteRepository, bus *events.Bus) *APIHandler {
+	return &APIHandler{repo: repo, bus: bus}
+}
+
+// SetENEXMaxSize limits ENEX uploads to n bytes; 0, the default, leaves
+// them unlimited
+func (h *APIHandler) SetENEXMaxSize(n int64) {
+	h.enexMaxSize = n
 }
 
 // GetAllNotes handles GET /api/notes
 func (h *APIHandler) GetAllNotes(w http.ResponseWriter, r *http.Request) {
-	notes, err := h.repo.GetAll()
+	notes, err := h.repo.GetAll(r.Context())
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -35,13 +46,12 @@ func (h *APIHandler) GetAllNotes(w http.ResponseWriter, r *http.Request) {
 // CreateNote handles POST /api/notes
 func (h *APIHandler) CreateNote(w http.ResponseWriter, r *http.Request) {
 	var note models.Note
-	if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeNote(w, r, &note) {
 		return
 	}
 
-	if err := h.repo.Create(&note); err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+	if err := h.repo.Create(r.Context(), &note); err != nil {
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -53,17 +63,13 @@ func (h *APIHandler) GetNote(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid note ID")
+		badRequest(w, r, "Invalid note ID")
 		return
 	}
 
-	note, err := h.repo.GetByID(id)
+	note, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
-		if err.Error() == "note not found" {
-			h.respondWithError(w, http.StatusNotFound, "Note not found")
-			return
-		}
-		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -75,23 +81,18 @@ func (h *APIHandler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid note ID")
+		badRequest(w, r, "Invalid note ID")
 		return
 	}
 
 	var note models.Note
-	if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeNote(w, r, &note) {
 		return
 	}
 
 	note.ID = id
-	if err := h.repo.Update(&note); err != nil {
-		if err.Error() == "note not found" {
-			h.respondWithError(w, http.StatusNotFound, "Note not found")
-			return
-		}
-		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+	if err := h.repo.Update(r.Context(), &note); err != nil {
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -103,16 +104,12 @@ func (h *APIHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid note ID")
+		badRequest(w, r, "Invalid note ID")
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
-		if err.Error() == "note not found" {
-			h.respondWithError(w, http.StatusNotFound, "Note not found")
-			return
-		}
-		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+	if err := h.repo.Delete(r.Context(), id); err != nil {
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -123,21 +120,75 @@ func (h *APIHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
 func (h *APIHandler) SearchNotes(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 
-	notes, err := h.repo.Search(query)
+	notes, err := h.repo.Search(r.Context(), query)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, r, err)
 		return
 	}
 
 	h.respondWithJSON(w, http.StatusOK, notes)
 }
 
+// Sync handles GET /api/sync?since=<seq>&limit=<n>
+func (h *APIHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	since, err := parseIntParam(r, "since")
+	if err != nil || since < 0 {
+		badRequest(w, r, "Invalid since parameter")
+		return
+	}
+	limit, err := parseIntParam(r, "limit")
+	if err != nil || limit < 0 {
+		badRequest(w, r, "Invalid limit parameter")
+		return
+	}
+
+	result, err := h.repo.Changes(r.Context(), since, int(limit))
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, result)
+}
+
 // Helper methods
 
+// maxNoteBody bounds the JSON body of a note: the longest content with
+// every byte escaped as \u00XX, six bytes each, and room for the other
+// fields. The content itself is checked against MaxContentLength once
+// decoded.
+const maxNoteBody = 6*models.MaxContentLength + 64<<10
+
+// decodeNote reads the note sent as the request body. Bodies that are too
+// large or not a note are answered here; it reports whether note was read.
+func decodeNote(w http.ResponseWriter, r *http.Request, note *models.Note) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxNoteBody)
+	err := json.NewDecoder(r.Body).Decode(note)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		respondTooLarge(w, r, tooLarge.Limit)
+		return false
+	case err != nil:
+		badRequest(w, r, "Invalid request body")
+		return false
+	}
+	return true
+}
+
+// parseIntParam reads an optional integer query parameter, defaulting to 0
+func parseIntParam(r *http.Request, name string) (int64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
 func (h *APIHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Error marshalling JSON")
+		http.Error(w, "Error marshalling JSON", http.StatusInternalServerError)
 		return
 	}
 
@@ -145,7 +196,3 @@ func (h *APIHandler) respondWithJSON(w http.ResponseWriter, code int, payload in
 	w.WriteHeader(code)
 	w.Write(response)
 }
-
-func (h *APIHandler) respondWithError(w http.ResponseWriter, code int, message string) {
-	h.respondWithJSON(w, code, map[string]string{"error": message})
-}
@@ -0,0 +1,95 @@
+// internal/handlers/api_test.go
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/internal/database/dbtest"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// newAPI creates an API handler over an empty database
+func newAPI(t *testing.T) (*APIHandler, *database.NoteRepository) {
+	t.Helper()
+	repo := database.NewNoteRepository(dbtest.Open(t), nil)
+	return NewAPIHandler(repo, nil), repo
+}
+
+func TestSearchNotesClientGone(t *testing.T) {
+	api, repo := newAPI(t)
+	if err := repo.Create(context.Background(), &models.Note{Title: "Shopping"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	api.SearchNotes(w, httptest.NewRequest("GET", "/api/notes/search?q=shopping", nil).WithContext(ctx))
+
+	if w.Code != statusClientClosedRequest {
+		t.Errorf("status %d, want %d", w.Code, statusClientClosedRequest)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("wrote a body nobody reads: %s", w.Body)
+	}
+}
+
+func TestUpdateNote(t *testing.T) {
+	api, repo := newAPI(t)
+	ctx := context.Background()
+	note := &models.Note{Title: "Shopping", Color: "#fff475"}
+	if err := repo.Create(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	r := chi.NewRouter()
+	r.Put("/api/notes/{id}", api.UpdateNote)
+	put := func(body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("PUT", fmt.Sprintf("/api/notes/%d", note.ID), bytes.NewReader(body)))
+		return w
+	}
+
+	t.Run("without color", func(t *testing.T) {
+		if w := put([]byte(`{"title":"Shopping"}`)); w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+		stored, err := repo.GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Color != string(models.ColorWhite) {
+			t.Errorf("stored color %q", stored.Color)
+		}
+	})
+
+	t.Run("longest content escaped", func(t *testing.T) {
+		// json.Marshal escapes every one of these as \u003c
+		body, err := json.Marshal(models.Note{Title: "Shopping", Content: strings.Repeat("<", models.MaxContentLength)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w := put(body); w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("content too long", func(t *testing.T) {
+		body, err := json.Marshal(models.Note{Title: "Shopping", Content: strings.Repeat("a", models.MaxContentLength+1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w := put(body); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("status %d, want %d", w.Code, http.StatusUnprocessableEntity)
+		}
+	})
+}
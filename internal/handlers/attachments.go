@@ -0,0 +1,34 @@
+// internal/handlers/attachments.go
+package handlers
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetAttachment handles GET /api/attachments/{id} and serves the file itself
+func (h *APIHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment, err := h.repo.GetAttachment(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	disposition := "attachment"
+	if attachment.IsImage() {
+		disposition = "inline"
+	}
+
+	w.Header().Set("Content-Type", attachment.MimeType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Never let an uploaded file (e.g. an SVG) run scripts on our origin
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	// IDs are never reused, so the file can be cached for good
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+
+	http.ServeContent(w, r, attachment.Filename, attachment.CreatedAt, bytes.NewReader(attachment.Data))
+}
@@ -0,0 +1,84 @@
+// internal/handlers/collab.go
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/collab"
+)
+
+// collabWriteTimeout bounds how long a single message may take to send
+const collabWriteTimeout = 10 * time.Second
+
+// CollabHandler upgrades requests to WebSockets for simultaneous editing
+type CollabHandler struct {
+	hub *collab.Hub
+}
+
+// NewCollabHandler creates a new collaboration handler
+func NewCollabHandler(hub *collab.Hub) *CollabHandler {
+	return &CollabHandler{hub: hub}
+}
+
+// Edit handles GET /api/notes/{id}/collab as a WebSocket that exchanges
+// collab.Message values for the note's content
+func (h *CollabHandler) Edit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		badRequest(w, r, "Invalid note ID")
+		return
+	}
+
+	participant, err := h.hub.Join(r.Context(), id, r.URL.Query().Get("name"))
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+	defer participant.Leave()
+
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		slog.WarnContext(r.Context(), "collab: websocket upgrade failed", "error", err)
+		return
+	}
+	defer conn.CloseNow()
+	// An operation may insert up to a whole note
+	conn.SetReadLimit(maxNoteBody)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// Writer: forward queued messages until the hub drops us
+	go func() {
+		defer cancel()
+		for msg := range participant.Send {
+			writeCtx, done := context.WithTimeout(ctx, collabWriteTimeout)
+			err := wsjson.Write(writeCtx, conn, msg)
+			done()
+			if err != nil {
+				return
+			}
+		}
+		if ctx.Err() == nil {
+			// The hub dropped us for falling behind or ended the session
+			conn.Close(websocket.StatusTryAgainLater, "session ended, please reconnect")
+		}
+	}()
+
+	// Reader: hand every message to the hub
+	for {
+		var msg collab.Message
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			return
+		}
+		participant.Handle(msg)
+	}
+}
@@ -0,0 +1,109 @@
+// internal/handlers/events.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// heartbeatInterval keeps idle event streams alive through proxies
+const heartbeatInterval = 25 * time.Second
+
+// Events handles GET /api/events as a Server-Sent Events stream. Clients
+// resume after a disconnect by sending the Last-Event-ID header.
+func (h *APIHandler) Events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithProblem(w, r, Problem{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: "Streaming not supported"})
+		return
+	}
+
+	lastID, err := parseLastEventID(r)
+	if err != nil {
+		badRequest(w, r, "Invalid Last-Event-ID")
+		return
+	}
+
+	sub, backlog, ok := h.bus.Subscribe(lastID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Tell the browser how long to wait before reconnecting
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	if !ok {
+		writeResetEvent(w, h.bus.LastID())
+	}
+	for _, event := range backlog {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, open := <-sub.C:
+			if !open {
+				// Dropped for being too slow; the client reconnects and resumes
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// parseLastEventID reads the resume position from the Last-Event-ID header
+// or the lastEventId query parameter
+func parseLastEventID(r *http.Request) (uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("lastEventId")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// writeEvent writes a single event in SSE wire format
+func writeEvent(w http.ResponseWriter, event models.NoteEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// writeResetEvent tells the client that it missed events and must reload
+func writeResetEvent(w http.ResponseWriter, lastID uint64) {
+	writeEvent(w, models.NoteEvent{
+		ID:   lastID,
+		Type: models.EventReset,
+		Time: time.Now(),
+	})
+}
@@ -0,0 +1,118 @@
+// internal/handlers/events_test.go
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/internal/database/dbtest"
+	"github.com/Smil3MoreGH/gokeep/internal/events"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// sseEvent is an event as read off the stream
+type sseEvent struct {
+	id, event, data string
+}
+
+// openStream connects to the event stream, resuming after lastID if it is
+// not empty, and returns a function reading the next event
+func openStream(t *testing.T, url, lastID string) func() sseEvent {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	return func() sseEvent {
+		t.Helper()
+		var e sseEvent
+		for lines.Scan() {
+			line := lines.Text()
+			if line == "" {
+				if e.event != "" {
+					return e
+				}
+				continue
+			}
+			name, value, _ := strings.Cut(line, ": ")
+			switch name {
+			case "id":
+				e.id = value
+			case "event":
+				e.event = value
+			case "data":
+				e.data = value
+			}
+		}
+		t.Fatalf("stream ended: %v", lines.Err())
+		return e
+	}
+}
+
+func TestEvents(t *testing.T) {
+	bus := events.NewBus(events.DefaultHistorySize)
+	repo := database.NewNoteRepository(dbtest.Open(t), bus)
+	srv := httptest.NewServer(http.HandlerFunc(NewAPIHandler(repo, bus).Events))
+	// Cleanups run last first, so the streams are closed before the server
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	first := &models.Note{Title: "First"}
+	if err := repo.Create(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	seen := strconv.FormatUint(bus.LastID(), 10)
+	second := &models.Note{Title: "Second"}
+	if err := repo.Create(ctx, second); err != nil {
+		t.Fatal(err)
+	}
+
+	// A client that saw the first note is sent the second, then live events
+	next := openStream(t, srv.URL, seen)
+	if e := next(); e.event != string(models.EventNoteCreated) || !strings.Contains(e.data, `"Second"`) {
+		t.Errorf("backlog %+v", e)
+	}
+	if err := repo.Delete(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if e := next(); e.event != string(models.EventNoteDeleted) || e.id != strconv.FormatUint(bus.LastID(), 10) {
+		t.Errorf("live event %+v", e)
+	}
+
+	// One that saw events this server never sent has to reload
+	next = openStream(t, srv.URL, strconv.FormatUint(bus.LastID()+100, 10))
+	if e := next(); e.event != string(models.EventReset) {
+		t.Errorf("stale client got %+v, want a reset", e)
+	}
+
+	resp, err := http.Get(srv.URL + "?lastEventId=nope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid lastEventId: status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,104 @@
+// internal/handlers/export.go
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Smil3MoreGH/gokeep/internal/exporter"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// Export handles GET /api/export and downloads all notes as a zip of
+// Markdown files with front matter
+func (h *APIHandler) Export(w http.ResponseWriter, r *http.Request) {
+	filename := fmt.Sprintf("gokeep-%s.zip", time.Now().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// The archive is streamed, so errors can only be logged once it started
+	zw := exporter.NewZipWriter(w)
+	if _, err := exporter.ExportMarkdown(r.Context(), h.repo, zw); err != nil {
+		slog.ErrorContext(r.Context(), "export failed", "error", err)
+		return
+	}
+	if err := zw.Close(); err != nil {
+		slog.ErrorContext(r.Context(), "export failed", "error", err)
+	}
+}
+
+// ExportNotePDF handles GET /api/notes/{id}/export.pdf
+func (h *APIHandler) ExportNotePDF(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		badRequest(w, r, "Invalid note ID")
+		return
+	}
+
+	note, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	name := exporter.NoteFilename(*note)
+	h.respondWithPDF(w, r, strings.TrimSuffix(name, ".md")+".pdf", []models.Note{*note})
+}
+
+// ExportPDF handles GET /api/export.pdf?ids=1,2,3 and renders the selected
+// notes, or every note that is not archived or trashed, into one PDF
+func (h *APIHandler) ExportPDF(w http.ResponseWriter, r *http.Request) {
+	var notes []models.Note
+	if ids := r.URL.Query().Get("ids"); ids != "" {
+		for _, part := range strings.Split(ids, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				badRequest(w, r, "Invalid ids parameter")
+				return
+			}
+			note, err := h.repo.GetByID(r.Context(), id)
+			if err != nil {
+				respondWithError(w, r, err)
+				return
+			}
+			notes = append(notes, *note)
+		}
+	} else {
+		all, err := h.repo.GetAll(r.Context())
+		if err != nil {
+			respondWithError(w, r, err)
+			return
+		}
+		for _, note := range all {
+			if !note.Archived && !note.Trashed {
+				notes = append(notes, note)
+			}
+		}
+	}
+
+	filename := fmt.Sprintf("gokeep-%s.pdf", time.Now().Format("20060102-150405"))
+	h.respondWithPDF(w, r, filename, notes)
+}
+
+// respondWithPDF renders notes and sends them as a PDF download
+func (h *APIHandler) respondWithPDF(w http.ResponseWriter, r *http.Request, filename string, notes []models.Note) {
+	// Render fully first so a failure can still be reported as JSON
+	var buf bytes.Buffer
+	if err := exporter.ExportPDF(r.Context(), &buf, notes, h.repo); err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
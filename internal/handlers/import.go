@@ -0,0 +1,183 @@
+// internal/handlers/import.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/Smil3MoreGH/gokeep/internal/importer"
+)
+
+// maxImportSize limits the size of an uploaded zip archive, which is
+// spooled to disk before it is read
+const maxImportSize = 1 << 30
+
+// ImportMarkdown handles POST /api/import?dry_run=true with a zip created by
+// GET /api/export, sent like the Keep upload
+func (h *APIHandler) ImportMarkdown(w http.ResponseWriter, r *http.Request) {
+	h.handleImport(w, r, func(upload io.ReaderAt, size int64, opts importer.Options) (*importer.Report, error) {
+		return importer.ImportMarkdown(r.Context(), upload, size, h.repo, opts)
+	})
+}
+
+// ImportKeep handles POST /api/import/keep?dry_run=true. The Takeout zip is
+// sent either as the "file" field of a multipart form or as the raw body.
+func (h *APIHandler) ImportKeep(w http.ResponseWriter, r *http.Request) {
+	h.handleImport(w, r, func(upload io.ReaderAt, size int64, opts importer.Options) (*importer.Report, error) {
+		return importer.ImportKeep(r.Context(), upload, size, h.repo, opts)
+	})
+}
+
+// ImportENEX handles POST /api/import/enex?dry_run=true. The export is
+// streamed straight from the request, so it is never buffered whole, and
+// only limited by SetENEXMaxSize.
+func (h *APIHandler) ImportENEX(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := parseBoolParam(r, "dry_run")
+	if err != nil {
+		badRequest(w, r, "Invalid dry_run parameter")
+		return
+	}
+
+	if h.enexMaxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.enexMaxSize)
+	}
+
+	upload, err := streamUpload(r)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		respondTooLarge(w, r, tooLarge.Limit)
+		return
+	case err != nil:
+		badRequest(w, r, err.Error())
+		return
+	}
+
+	report, err := importer.ImportENEX(r.Context(), upload, h.repo, importer.Options{DryRun: dryRun})
+	h.respondWithReport(w, r, report, err)
+}
+
+// handleImport reads the uploaded archive and responds with the report of
+// run
+func (h *APIHandler) handleImport(w http.ResponseWriter, r *http.Request, run func(io.ReaderAt, int64, importer.Options) (*importer.Report, error)) {
+	dryRun, err := parseBoolParam(r, "dry_run")
+	if err != nil {
+		badRequest(w, r, "Invalid dry_run parameter")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	upload, size, cleanup, err := readUpload(r)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		respondTooLarge(w, r, tooLarge.Limit)
+		return
+	case err != nil:
+		badRequest(w, r, err.Error())
+		return
+	}
+	defer cleanup()
+
+	report, err := run(upload, size, importer.Options{DryRun: dryRun})
+	h.respondWithReport(w, r, report, err)
+}
+
+// respondWithReport sends the outcome of an import. Errors reading the
+// export describe the upload and are sent back; errors storing its notes
+// are only logged. A streamed upload may turn out too large only while it
+// is imported.
+func (h *APIHandler) respondWithReport(w http.ResponseWriter, r *http.Request, report *importer.Report, err error) {
+	var storeErr *importer.StoreError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil:
+		h.respondWithJSON(w, http.StatusOK, report)
+	case errors.As(err, &tooLarge):
+		respondTooLarge(w, r, tooLarge.Limit)
+	case !errors.As(err, &storeErr):
+		slog.WarnContext(r.Context(), "import failed", "error", err)
+		badRequest(w, r, err.Error())
+	case report == nil || report.Imported == 0:
+		respondWithError(w, r, err)
+	default:
+		// Part of the export was imported; report what made it in
+		slog.ErrorContext(r.Context(), "import failed", "error", err)
+		report.Warnings = append(report.Warnings, fmt.Sprintf("failed to import %s", storeErr.Name))
+		h.respondWithJSON(w, http.StatusInternalServerError, report)
+	}
+}
+
+// readUpload returns the uploaded file as an io.ReaderAt, spooling a raw
+// body to a temporary file since zip archives need random access
+func readUpload(r *http.Request) (io.ReaderAt, int64, func(), error) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+		return file, header.Size, func() { file.Close(); r.MultipartForm.RemoveAll() }, nil
+	}
+
+	tmp, err := os.CreateTemp("", "gokeep-import-*.zip")
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to buffer upload: %w", err)
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	size, err := io.Copy(tmp, r.Body)
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if size == 0 {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("no file uploaded")
+	}
+
+	return tmp, size, cleanup, nil
+}
+
+// streamUpload returns the uploaded file as a stream, reading either the
+// "file" part of a multipart form or the raw body
+func streamUpload(r *http.Request) (io.Reader, error) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no file uploaded")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
+
+// parseBoolParam reads an optional boolean query parameter, defaulting to false
+func parseBoolParam(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
@@ -0,0 +1,37 @@
+// internal/handlers/import_test.go
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestImportENEXLimit(t *testing.T) {
+	// Two notes with 2 MiB of whitespace between them
+	enex := `<?xml version="1.0" encoding="UTF-8"?><en-export>` +
+		`<note><title>First</title><content><![CDATA[<en-note>one</en-note>]]></content></note>` +
+		strings.Repeat(" ", 2<<20) +
+		`<note><title>Second</title><content><![CDATA[<en-note>two</en-note>]]></content></note>` +
+		`</en-export>`
+	upload := func(api *APIHandler) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		api.ImportENEX(w, httptest.NewRequest("POST", "/api/import/enex", strings.NewReader(enex)))
+		return w
+	}
+
+	api, repo := newAPI(t)
+	if w := upload(api); w.Code != http.StatusOK {
+		t.Fatalf("unlimited upload: status %d: %s", w.Code, w.Body)
+	}
+	if n, err := repo.Count(t.Context()); err != nil || n != 2 {
+		t.Errorf("imported %d notes, %v", n, err)
+	}
+
+	api, _ = newAPI(t)
+	api.SetENEXMaxSize(1 << 20)
+	if w := upload(api); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("upload over the limit: status %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -0,0 +1,173 @@
+// internal/handlers/problem.go
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// Stable error codes sent in the "code" member of every problem. Clients
+// branch on these, never on the human readable title or detail.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeTooLarge         = "request_too_large"
+	CodeUnauthorized     = "unauthorized"
+	CodeRateLimited      = "rate_limited"
+	CodeCSRF             = "csrf_failed"
+	CodeConflict         = "conflict"
+	CodeValidation       = "validation_failed"
+	CodeInternal         = "internal_error"
+)
+
+// Problem is an RFC 7807 problem details body, sent as
+// application/problem+json
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the request that failed
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// RequestID ties the problem to the server log
+	RequestID string `json:"request_id,omitempty"`
+	// Errors lists the invalid fields of a validation problem
+	Errors models.FieldErrors `json:"errors,omitempty"`
+}
+
+// statusClientClosedRequest records requests the client gave up on; nobody
+// reads the response
+const statusClientClosedRequest = 499
+
+// respondWithProblem fills in the standard members of problem and sends it.
+// The detail is shown to the user, so it must not carry internal errors.
+func respondWithProblem(w http.ResponseWriter, r *http.Request, problem Problem) {
+	problem.Type = "about:blank"
+	problem.Title = http.StatusText(problem.Status)
+	problem.Instance = r.URL.Path
+	problem.RequestID = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// respondWithError reports err, mapping the kinds of database errors to
+// their status and code. Anything else is logged with the request ID and
+// answered with a generic 500, so internal details never reach the client.
+// Errors caused by the request being cancelled are not the server's fault:
+// a timeout is answered with 504 by middleware.Timeout, and a client that
+// went away gets no answer.
+func respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	var notFound *database.NotFoundError
+	var invalid *database.ValidationError
+	switch {
+	case errors.As(err, &notFound):
+		detail := capitalize(fmt.Sprintf("%s %s not found", notFound.Resource, notFound.ID))
+		respondWithProblem(w, r, Problem{Status: http.StatusNotFound, Code: CodeNotFound, Detail: detail})
+		return
+	case errors.Is(err, database.ErrNotFound):
+		respondWithProblem(w, r, Problem{Status: http.StatusNotFound, Code: CodeNotFound})
+		return
+	case errors.As(err, &invalid):
+		respondWithProblem(w, r, Problem{Status: http.StatusUnprocessableEntity, Code: CodeValidation, Detail: capitalize(invalid.Message), Errors: invalid.Fields})
+		return
+	case errors.Is(err, database.ErrValidation):
+		respondWithProblem(w, r, Problem{Status: http.StatusUnprocessableEntity, Code: CodeValidation})
+		return
+	case errors.Is(err, database.ErrConflict):
+		slog.InfoContext(r.Context(), "request conflicts with stored data", "method", r.Method, "path", r.URL.Path, "error", err)
+		respondWithProblem(w, r, Problem{Status: http.StatusConflict, Code: CodeConflict, Detail: "The change conflicts with data that is already stored"})
+		return
+	}
+
+	switch ctxErr := r.Context().Err(); {
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		slog.WarnContext(r.Context(), "request timed out", "method", r.Method, "path", r.URL.Path, "error", err)
+		return
+	case ctxErr != nil:
+		slog.InfoContext(r.Context(), "request cancelled", "method", r.Method, "path", r.URL.Path, "error", err)
+		w.WriteHeader(statusClientClosedRequest)
+		return
+	}
+
+	slog.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	respondWithProblem(w, r, Problem{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: "The server could not complete the request"})
+}
+
+// respondTooLarge reports a request body over limit bytes
+func respondTooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	respondWithProblem(w, r, Problem{
+		Status: http.StatusRequestEntityTooLarge,
+		Code:   CodeTooLarge,
+		Detail: fmt.Sprintf("The request body must be at most %d MiB", max(limit>>20, 1)),
+	})
+}
+
+// RateLimited answers a request refused by the rate limiter; the caller
+// has set Retry-After
+func RateLimited(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	slog.DebugContext(r.Context(), "request rate limited", "method", r.Method, "path", r.URL.Path, "retry_after", retryAfter)
+	respondWithProblem(w, r, Problem{
+		Status: http.StatusTooManyRequests,
+		Code:   CodeRateLimited,
+		Detail: "Too many requests, please try again later",
+	})
+}
+
+// Unauthorized answers a request without valid credentials; the caller has
+// set WWW-Authenticate
+func Unauthorized(w http.ResponseWriter, r *http.Request) {
+	respondWithProblem(w, r, Problem{
+		Status: http.StatusUnauthorized,
+		Code:   CodeUnauthorized,
+		Detail: "A valid bearer token is required",
+	})
+}
+
+// CSRFFailed answers a write from a browser that lacks a valid CSRF token
+func CSRFFailed(w http.ResponseWriter, r *http.Request) {
+	slog.WarnContext(r.Context(), "CSRF check failed", "method", r.Method, "path", r.URL.Path, "origin", r.Header.Get("Origin"))
+	respondWithProblem(w, r, Problem{
+		Status: http.StatusForbidden,
+		Code:   CodeCSRF,
+		Detail: "The request lacks a valid CSRF token; reload the page and try again",
+	})
+}
+
+// capitalize turns an error message into a sentence for the user
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// badRequest reports a malformed request, e.g. an ID that is not a number
+func badRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	respondWithProblem(w, r, Problem{Status: http.StatusBadRequest, Code: CodeBadRequest, Detail: detail})
+}
+
+// NotFound answers requests for paths the API does not have
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	respondWithProblem(w, r, Problem{Status: http.StatusNotFound, Code: CodeNotFound, Detail: "No such API endpoint"})
+}
+
+// MethodNotAllowed answers requests with a method the path does not support
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	respondWithProblem(w, r, Problem{Status: http.StatusMethodNotAllowed, Code: CodeMethodNotAllowed})
+}
@@ -0,0 +1,26 @@
+// internal/health/disk.go
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DiskSpace returns a check that fails when the file system holding dir has
+// less than min bytes available. Platforms that cannot tell always pass.
+func DiskSpace(dir string, min uint64) Check {
+	return func(ctx context.Context) error {
+		free, err := FreeSpace(dir)
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read free disk space: %w", err)
+		}
+		if free < min {
+			return fmt.Errorf("only %d MiB free in %s, need %d MiB", free>>20, dir, min>>20)
+		}
+		return nil
+	}
+}
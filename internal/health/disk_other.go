@@ -0,0 +1,12 @@
+// internal/health/disk_other.go
+
+//go:build !linux && !darwin
+
+package health
+
+import "errors"
+
+// FreeSpace is not implemented on this platform
+func FreeSpace(path string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
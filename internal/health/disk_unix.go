@@ -0,0 +1,17 @@
+// internal/health/disk_unix.go
+
+//go:build linux || darwin
+
+package health
+
+import "syscall"
+
+// FreeSpace returns the bytes available to unprivileged users on the file
+// system holding path
+func FreeSpace(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
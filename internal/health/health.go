@@ -0,0 +1,115 @@
+// internal/health/health.go
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckTimeout bounds each readiness check
+const CheckTimeout = 2 * time.Second
+
+// Check reports why the server cannot take traffic, or nil if it can
+type Check func(ctx context.Context) error
+
+// Health serves the liveness and readiness probes
+type Health struct {
+	mu       sync.Mutex
+	names    []string
+	checks   map[string]Check
+	draining atomic.Bool
+}
+
+// Result is the body of /readyz
+type Result struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// New creates probes without checks
+func New() *Health {
+	return &Health{checks: make(map[string]Check)}
+}
+
+// Add registers a readiness check under name
+func (h *Health) Add(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.checks[name]; !ok {
+		h.names = append(h.names, name)
+	}
+	h.checks[name] = check
+}
+
+// Drain makes readiness fail from now on, so that traffic is moved away
+// before the server shuts down
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+// Live handles GET /healthz. It answers as long as the process can serve
+// requests at all.
+func (h *Health) Live(w http.ResponseWriter, r *http.Request) {
+	respond(w, http.StatusOK, Result{Status: "ok"})
+}
+
+// Ready handles GET /readyz, running every check concurrently
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		respond(w, http.StatusServiceUnavailable, Result{Status: "shutting down"})
+		return
+	}
+
+	result := h.Run(r.Context())
+	code := http.StatusOK
+	if result.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+	respond(w, code, result)
+}
+
+// Run runs every check and collects their outcome
+func (h *Health) Run(ctx context.Context) Result {
+	h.mu.Lock()
+	names := append([]string(nil), h.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = h.checks[name]
+	}
+	h.mu.Unlock()
+
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+			defer cancel()
+			errs[i] = check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	result := Result{Status: "ok", Checks: make(map[string]string, len(names))}
+	for i, name := range names {
+		if errs[i] != nil {
+			result.Status = "unavailable"
+			result.Checks[name] = errs[i].Error()
+			continue
+		}
+		result.Checks[name] = "ok"
+	}
+	return result
+}
+
+func respond(w http.ResponseWriter, code int, result Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(result)
+}
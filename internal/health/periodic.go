@@ -0,0 +1,53 @@
+// internal/health/periodic.go
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errNotChecked is reported until a periodic check has run once
+var errNotChecked = errors.New("not checked yet")
+
+// Periodic runs check in the background right away and then every
+// interval until ctx is done. The returned check reports the outcome of the
+// latest run without running anything, so checks too costly for every probe
+// can still take part in readiness. Each run may take up to interval.
+func Periodic(ctx context.Context, check Check, interval time.Duration) Check {
+	var mu sync.Mutex
+	last := errNotChecked
+
+	run := func() {
+		ctx, cancel := context.WithTimeout(ctx, interval)
+		defer cancel()
+		err := check(ctx)
+		if ctx.Err() != nil && errors.Is(err, context.Canceled) {
+			// Stopped by shutdown; the server no longer cares
+			return
+		}
+		mu.Lock()
+		last = err
+		mu.Unlock()
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			run()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func(context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return last
+	}
+}
@@ -0,0 +1,41 @@
+// internal/health/periodic_test.go
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPeriodicReportsLatestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Every run of the check returns what the test sends it, however long
+	// that takes
+	results := make(chan error)
+	defer close(results)
+	check := Periodic(ctx, func(context.Context) error { return <-results }, time.Millisecond)
+
+	if err := check(ctx); err != errNotChecked {
+		t.Fatalf("before the first run the check reported %v", err)
+	}
+
+	// waitFor waits until the probe reports want
+	waitFor := func(want error) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for check(ctx) != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("check reports %v, want %v", check(ctx), want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	broken := errors.New("broken")
+	results <- broken
+	waitFor(broken)
+	results <- nil
+	waitFor(nil)
+}
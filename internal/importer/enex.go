@@ -0,0 +1,219 @@
+// internal/importer/enex.go
+package importer
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// enexTimeLayout is the timestamp format used in ENEX files
+const enexTimeLayout = "20060102T150405Z"
+
+// preferredExtensions picks the usual extension where a MIME type has several
+var preferredExtensions = map[string]string{
+	"text/plain": ".txt",
+	"image/jpeg": ".jpg",
+}
+
+// enexNote is one <note> of an Evernote export
+type enexNote struct {
+	Title     string         `xml:"title"`
+	Content   string         `xml:"content"`
+	Created   string         `xml:"created"`
+	Updated   string         `xml:"updated"`
+	Tags      []string       `xml:"tag"`
+	SourceURL string         `xml:"note-attributes>source-url"`
+	Resources []enexResource `xml:"resource"`
+}
+
+type enexResource struct {
+	Data struct {
+		Encoding string `xml:"encoding,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"data"`
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+}
+
+// ImportENEXFile imports the Evernote export at filename
+func ImportENEXFile(ctx context.Context, filename string, store NoteCreator, opts Options) (*Report, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	defer f.Close()
+
+	return ImportENEX(ctx, f, store, opts)
+}
+
+// ImportENEX imports an Evernote export. The file is read one note at a
+// time, so only the note being converted is held in memory.
+func ImportENEX(ctx context.Context, r io.Reader, store NoteCreator, opts Options) (*Report, error) {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+
+	report := &Report{DryRun: opts.DryRun, Notes: []ReportEntry{}}
+	index := 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to read export: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		index++
+		name := fmt.Sprintf("note %d", index)
+		var en enexNote
+		if err := d.DecodeElement(&en, &start); err != nil {
+			return report, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		note, entry, err := convertENEXNote(&en, name)
+		if err == nil {
+			err = note.Validate()
+		}
+		if err != nil {
+			report.Skipped++
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s (%s): %v", name, en.Title, err))
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := store.Create(ctx, note); err != nil {
+				return report, &StoreError{Name: name, Err: err}
+			}
+			entry.ID = note.ID
+		}
+
+		entry.Action = ActionCreated
+		report.Imported++
+		report.Attachments += entry.Attachments
+		report.Notes = append(report.Notes, entry)
+	}
+
+	if index == 0 {
+		return report, fmt.Errorf("no Evernote notes found in export")
+	}
+
+	return report, nil
+}
+
+// convertENEXNote maps an Evernote note onto a gokeep note. Resources become
+// attachments that the body links to where it embeds them.
+func convertENEXNote(en *enexNote, name string) (*models.Note, ReportEntry, error) {
+	entry := ReportEntry{File: name, Title: en.Title, Labels: en.Tags}
+	note := &models.Note{
+		Title:  en.Title,
+		Color:  string(models.ColorWhite),
+		Labels: en.Tags,
+	}
+
+	if t, err := time.Parse(enexTimeLayout, strings.TrimSpace(en.Created)); err == nil {
+		note.CreatedAt = t
+	}
+	note.UpdatedAt = note.CreatedAt
+	if t, err := time.Parse(enexTimeLayout, strings.TrimSpace(en.Updated)); err == nil {
+		note.UpdatedAt = t
+	}
+
+	byHash := make(map[string]models.Attachment, len(en.Resources))
+	for i, res := range en.Resources {
+		attachment, err := decodeENEXResource(res, i+1)
+		if err != nil {
+			entry.Warnings = append(entry.Warnings, err.Error())
+			continue
+		}
+		sum := md5.Sum(attachment.Data)
+		byHash[hex.EncodeToString(sum[:])] = attachment
+		note.Attachments = append(note.Attachments, attachment)
+	}
+	entry.Attachments = len(note.Attachments)
+
+	embedded := make(map[string]bool)
+	content, err := enmlToMarkdown(en.Content, func(hash string) string {
+		attachment, ok := byHash[hash]
+		if !ok {
+			return ""
+		}
+		embedded[attachment.ID] = true
+		return attachment.Markdown()
+	})
+	if err != nil {
+		return nil, entry, err
+	}
+
+	// Resources the body does not embed are still linked at the end
+	var refs []string
+	for _, attachment := range note.Attachments {
+		if !embedded[attachment.ID] {
+			refs = append(refs, attachment.Markdown())
+		}
+	}
+	if en.SourceURL != "" {
+		refs = append(refs, fmt.Sprintf("[Source](%s)", en.SourceURL))
+	}
+	if len(refs) > 0 {
+		if content != "" {
+			content += "\n\n"
+		}
+		content += strings.Join(refs, "\n")
+	}
+
+	note.Content = content
+	return note, entry, nil
+}
+
+// decodeENEXResource decodes the base64 data of a resource
+func decodeENEXResource(res enexResource, n int) (models.Attachment, error) {
+	if enc := res.Data.Encoding; enc != "" && enc != "base64" {
+		return models.Attachment{}, fmt.Errorf("resource %d has unsupported encoding %q", n, enc)
+	}
+
+	encoded := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+			return -1
+		}
+		return r
+	}, res.Data.Value)
+	if base64.StdEncoding.DecodedLen(len(encoded)) > MaxAttachmentSize {
+		return models.Attachment{}, fmt.Errorf("resource %d is too large", n)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("resource %d is not valid base64: %w", n, err)
+	}
+
+	mimeType := strings.TrimSpace(res.Mime)
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	filename := strings.TrimSpace(res.FileName)
+	if filename == "" {
+		filename = fmt.Sprintf("resource-%d", n)
+		if ext, ok := preferredExtensions[mimeType]; ok {
+			filename += ext
+		} else if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+			filename += exts[0]
+		}
+	}
+
+	return models.NewAttachment(filename, mimeType, data), nil
+}
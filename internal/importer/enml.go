@@ -0,0 +1,431 @@
+// internal/importer/enml.go
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// enmlNode is an element or, with an empty name, a text node of an ENML body
+type enmlNode struct {
+	name     string
+	attrs    map[string]string
+	text     string
+	children []*enmlNode
+}
+
+// parseENML reads an ENML document into a tree. ENML is XHTML, but exports
+// in the wild are not always well formed, so the parser is lenient.
+func parseENML(content string) (*enmlNode, error) {
+	d := xml.NewDecoder(strings.NewReader(content))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	root := &enmlNode{name: "#document"}
+	stack := []*enmlNode{root}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid ENML: %w", err)
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &enmlNode{name: strings.ToLower(t.Name.Local), attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				n.attrs[strings.ToLower(a.Name.Local)] = a.Value
+			}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &enmlNode{text: string(t)})
+		}
+	}
+
+	return root, nil
+}
+
+// Separators between two lines of Markdown, from weakest to strongest
+const (
+	sepLine      = iota // plain newline, e.g. between list items
+	sepBreak            // hard line break within a paragraph
+	sepParagraph        // blank line
+)
+
+// enmlConverter renders an ENML tree as Markdown for blackfriday. Evernote
+// writes one <div> per line, so consecutive divs become hard line breaks and
+// empty divs become paragraph breaks.
+type enmlConverter struct {
+	out     strings.Builder
+	line    string
+	prefix  string // prefix of the next line, e.g. a list marker
+	indent  string // prefix of continuation lines inside lists and quotes
+	sep     int
+	flushes int
+	// lastIndent is the indent of the line written last
+	lastIndent string
+
+	// media renders an <en-media> reference by the MD5 hash of its resource
+	media func(hash string) string
+}
+
+// enmlToMarkdown converts an ENML body to Markdown
+func enmlToMarkdown(content string, media func(hash string) string) (string, error) {
+	root, err := parseENML(content)
+	if err != nil {
+		return "", err
+	}
+
+	c := &enmlConverter{media: media}
+	c.children(root)
+	c.flush()
+	return c.out.String(), nil
+}
+
+// raise makes the separator before the next line at least sep
+func (c *enmlConverter) raise(sep int) {
+	c.sep = max(c.sep, sep)
+}
+
+// flush writes the current line, if any
+func (c *enmlConverter) flush() {
+	line := strings.TrimSpace(c.line)
+	c.line = ""
+	if line == "" {
+		return
+	}
+
+	if c.out.Len() > 0 {
+		switch {
+		case c.sep == sepParagraph:
+			// Blank lines only continue a quote both lines are in
+			shared := 0
+			for shared < len(c.indent) && shared < len(c.lastIndent) && c.indent[shared] == c.lastIndent[shared] {
+				shared++
+			}
+			c.out.WriteString("\n" + strings.TrimRight(c.indent[:shared], " ") + "\n")
+		case c.sep == sepBreak && !strings.HasPrefix(line, "- "):
+			c.out.WriteString("  \n")
+		default:
+			c.out.WriteString("\n")
+		}
+	}
+
+	prefix := c.indent
+	if c.prefix != "" {
+		prefix = c.prefix
+		c.prefix = ""
+	}
+	c.out.WriteString(prefix + line)
+	c.lastIndent = c.indent
+	c.sep = sepBreak
+	c.flushes++
+}
+
+// children renders the children of n in order
+func (c *enmlConverter) children(n *enmlNode) {
+	for _, child := range n.children {
+		c.node(child)
+	}
+}
+
+func (c *enmlConverter) node(n *enmlNode) {
+	if n.name == "" {
+		c.text(n.text)
+		return
+	}
+
+	switch n.name {
+	case "head", "title", "style", "script":
+		// Not part of the note's text
+
+	case "div", "p", "section", "article", "center":
+		c.flush()
+		if n.name == "p" {
+			c.raise(sepParagraph)
+		}
+		sep := c.sep
+		flushes := c.flushes
+		c.children(n)
+		c.flush()
+		if n.name == "p" {
+			c.raise(sepParagraph)
+		} else if c.flushes == flushes {
+			// An empty line
+			c.sep = max(sep, sepParagraph)
+		}
+
+	case "br":
+		if strings.TrimSpace(c.line) != "" {
+			c.flush()
+		} else {
+			c.raise(sepParagraph)
+		}
+
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.flush()
+		c.raise(sepParagraph)
+		c.line = strings.Repeat("#", int(n.name[1]-'0')) + " "
+		c.children(n)
+		c.flush()
+		c.raise(sepParagraph)
+
+	case "ul", "ol":
+		c.flush()
+		if c.indent == "" || strings.HasSuffix(c.indent, "> ") {
+			c.raise(sepParagraph)
+		} else {
+			c.sep = sepLine
+		}
+		number := 0
+		for _, item := range n.children {
+			if item.name != "li" {
+				continue
+			}
+			number++
+			marker := "- "
+			if n.name == "ol" {
+				marker = fmt.Sprintf("%d. ", number)
+			}
+			c.listItem(item, marker, number > 1)
+		}
+		c.raise(sepParagraph)
+
+	case "li":
+		// A list item outside of a list
+		c.listItem(n, "- ", false)
+
+	case "blockquote":
+		c.flush()
+		c.raise(sepParagraph)
+		indent := c.indent
+		c.indent += "> "
+		c.children(n)
+		c.flush()
+		c.indent = indent
+		c.raise(sepParagraph)
+
+	case "pre":
+		c.flush()
+		c.raise(sepParagraph)
+		c.line = "```\n" + strings.Trim(rawText(n), "\n") + "\n```"
+		c.flushRaw()
+		c.raise(sepParagraph)
+
+	case "hr":
+		c.flush()
+		c.raise(sepParagraph)
+		c.line = "* * *"
+		c.flush()
+		c.raise(sepParagraph)
+
+	case "table":
+		c.flush()
+		c.raise(sepParagraph)
+		c.line = c.table(n)
+		c.flushRaw()
+		c.raise(sepParagraph)
+
+	case "b", "strong":
+		c.wrap(n, "**", "**")
+	case "i", "em":
+		c.wrap(n, "*", "*")
+	case "s", "strike", "del":
+		c.wrap(n, "~~", "~~")
+	case "code":
+		c.line += "`" + strings.ReplaceAll(rawText(n), "`", "'") + "`"
+
+	case "a":
+		href := n.attrs["href"]
+		text := strings.TrimSpace(rawText(n))
+		switch {
+		case href == "":
+			c.children(n)
+		case text == "" || text == href:
+			c.line += href
+		default:
+			c.wrap(n, "[", "]("+href+")")
+		}
+
+	case "img":
+		if src := n.attrs["src"]; src != "" {
+			c.line += fmt.Sprintf("![%s](%s)", escapeMarkdown(n.attrs["alt"]), src)
+		}
+
+	case "en-media":
+		if c.media != nil {
+			c.line += c.media(strings.ToLower(n.attrs["hash"]))
+		}
+
+	case "en-todo":
+		mark := "[ ] "
+		if n.attrs["checked"] == "true" {
+			mark = "[x] "
+		}
+		if strings.TrimSpace(c.line) == "" && c.prefix == "" {
+			mark = "- " + mark
+		}
+		c.line += mark
+
+	case "en-crypt":
+		c.line += "*[encrypted content]*"
+
+	default:
+		// span, font, en-note and anything unknown: keep the text
+		c.children(n)
+	}
+}
+
+// listItem renders one list item behind marker
+func (c *enmlConverter) listItem(n *enmlNode, marker string, next bool) {
+	c.flush()
+	if next {
+		c.sep = sepLine
+	}
+	indent := c.indent
+	c.prefix = indent + marker
+	c.indent = indent + strings.Repeat(" ", len(marker))
+	c.children(n)
+	c.flush()
+	c.prefix = ""
+	c.indent = indent
+}
+
+// wrap renders the children of n between open and close, unless they turn
+// out empty or span several lines
+func (c *enmlConverter) wrap(n *enmlNode, open, close string) {
+	mark, flushes := len(c.line), c.flushes
+	c.children(n)
+	if c.flushes != flushes {
+		return
+	}
+	inner := c.line[mark:]
+	trimmed := strings.TrimSpace(inner)
+	if trimmed == "" {
+		return
+	}
+	leading := inner[:len(inner)-len(strings.TrimLeft(inner, " "))]
+	trailing := inner[len(strings.TrimRight(inner, " ")):]
+	c.line = c.line[:mark] + leading + open + trimmed + close + trailing
+}
+
+// text appends a text node, collapsing whitespace as a browser would
+func (c *enmlConverter) text(s string) {
+	collapsed := strings.Join(strings.Fields(s), " ")
+	first, _ := utf8.DecodeRuneInString(s)
+	last, _ := utf8.DecodeLastRuneInString(s)
+	if s != "" && unicode.IsSpace(first) && c.line != "" && !strings.HasSuffix(c.line, " ") {
+		c.line += " "
+	}
+	c.line += escapeMarkdown(collapsed)
+	if collapsed != "" && unicode.IsSpace(last) {
+		c.line += " "
+	}
+}
+
+// flushRaw writes the current line without trimming, for code and tables
+func (c *enmlConverter) flushRaw() {
+	line := c.line
+	c.line = ""
+	if c.out.Len() > 0 {
+		c.out.WriteString("\n\n")
+	}
+	c.out.WriteString(line)
+	c.sep = sepParagraph
+	c.flushes++
+}
+
+// table renders a table as a Markdown table with the first row as header
+func (c *enmlConverter) table(n *enmlNode) string {
+	var rows [][]string
+	var collect func(*enmlNode)
+	collect = func(n *enmlNode) {
+		for _, child := range n.children {
+			switch child.name {
+			case "tr":
+				var row []string
+				for _, cell := range child.children {
+					if cell.name == "td" || cell.name == "th" {
+						cellText := &enmlConverter{media: c.media}
+						cellText.children(cell)
+						cellText.flush()
+						text := strings.ReplaceAll(cellText.out.String(), "  \n", " ")
+						text = strings.ReplaceAll(text, "\n", " ")
+						row = append(row, strings.ReplaceAll(text, "|", "\\|"))
+					}
+				}
+				rows = append(rows, row)
+			case "":
+			default:
+				collect(child)
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	lines := make([]string, 0, len(rows)+1)
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rawText returns the text of n and its descendants as is
+func rawText(n *enmlNode) string {
+	if n.name == "" {
+		return n.text
+	}
+	if n.name == "br" {
+		return "\n"
+	}
+	var b strings.Builder
+	for _, child := range n.children {
+		b.WriteString(rawText(child))
+		if child.name == "div" || child.name == "p" {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// markdownEscaper escapes characters that blackfriday would treat as markup
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	">", `\>`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
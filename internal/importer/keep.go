@@ -0,0 +1,305 @@
+// internal/importer/keep.go
+package importer
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// MaxAttachmentSize is the largest attachment that is imported; bigger files
+// are skipped with a warning
+const MaxAttachmentSize = 50 << 20
+
+// NoteCreator stores imported notes; NoteRepository satisfies it
+type NoteCreator interface {
+	Create(ctx context.Context, note *models.Note) error
+}
+
+// keepNote is the JSON written by Google Takeout for every Keep note
+type keepNote struct {
+	Title                   string           `json:"title"`
+	TextContent             string           `json:"textContent"`
+	ListContent             []keepListItem   `json:"listContent"`
+	Color                   string           `json:"color"`
+	IsPinned                bool             `json:"isPinned"`
+	IsArchived              bool             `json:"isArchived"`
+	IsTrashed               bool             `json:"isTrashed"`
+	Labels                  []keepLabel      `json:"labels"`
+	Annotations             []keepAnnotation `json:"annotations"`
+	Attachments             []keepAttachment `json:"attachments"`
+	CreatedTimestampUsec    int64            `json:"createdTimestampUsec"`
+	UserEditedTimestampUsec *int64           `json:"userEditedTimestampUsec"`
+}
+
+type keepListItem struct {
+	Text      string `json:"text"`
+	IsChecked bool   `json:"isChecked"`
+}
+
+type keepLabel struct {
+	Name string `json:"name"`
+}
+
+type keepAnnotation struct {
+	Source string `json:"source"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+}
+
+type keepAttachment struct {
+	FilePath string `json:"filePath"`
+	MimeType string `json:"mimetype"`
+}
+
+// keepColors maps Keep's color names onto the gokeep palette
+var keepColors = map[string]models.NoteColor{
+	"DEFAULT":  models.ColorWhite,
+	"RED":      models.ColorPink,
+	"PINK":     models.ColorPink,
+	"ORANGE":   models.ColorOrange,
+	"BROWN":    models.ColorOrange,
+	"YELLOW":   models.ColorYellow,
+	"GREEN":    models.ColorGreen,
+	"TEAL":     models.ColorGreen,
+	"BLUE":     models.ColorBlue,
+	"CERULEAN": models.ColorBlue,
+	"PURPLE":   models.ColorPurple,
+	"GRAY":     models.ColorGray,
+}
+
+// ImportKeepFile imports the Google Takeout zip at filename
+func ImportKeepFile(ctx context.Context, filename string, store NoteCreator, opts Options) (*Report, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	defer zr.Close()
+
+	return importKeep(ctx, &zr.Reader, store, opts)
+}
+
+// ImportKeep imports a Google Takeout zip read from r. Every *.json file that
+// looks like a Keep note becomes one note; attachments are stored with it and
+// linked from its content.
+func ImportKeep(ctx context.Context, r io.ReaderAt, size int64, store NoteCreator, opts Options) (*Report, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+
+	return importKeep(ctx, zr, store, opts)
+}
+
+func importKeep(ctx context.Context, zr *zip.Reader, store NoteCreator, opts Options) (*Report, error) {
+	files := make(map[string]*zip.File, len(zr.File))
+	var notes []*zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		files[f.Name] = f
+		if strings.EqualFold(path.Ext(f.Name), ".json") {
+			notes = append(notes, f)
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Name < notes[j].Name })
+
+	report := &Report{DryRun: opts.DryRun, Notes: []ReportEntry{}}
+	for _, f := range notes {
+		kn, err := readKeepNote(f)
+		if err != nil {
+			report.Skipped++
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %v", f.Name, err))
+			continue
+		}
+
+		note, entry := convertKeepNote(kn, f.Name, files)
+		if err := note.Validate(); err != nil {
+			report.Skipped++
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %v", f.Name, err))
+			continue
+		}
+		if !opts.DryRun {
+			if err := store.Create(ctx, note); err != nil {
+				return report, &StoreError{Name: f.Name, Err: err}
+			}
+			entry.ID = note.ID
+		}
+
+		report.Imported++
+		report.Attachments += entry.Attachments
+		report.Notes = append(report.Notes, entry)
+	}
+
+	if report.Imported == 0 && report.Skipped == 0 {
+		return report, fmt.Errorf("no Keep notes found in export")
+	}
+
+	return report, nil
+}
+
+// readKeepNote decodes a Takeout JSON file, rejecting files that are not notes
+func readKeepNote(f *zip.File) (*keepNote, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var kn keepNote
+	if err := json.NewDecoder(rc).Decode(&kn); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if kn.UserEditedTimestampUsec == nil {
+		return nil, fmt.Errorf("not a Keep note")
+	}
+
+	return &kn, nil
+}
+
+// convertKeepNote maps a Keep note onto a gokeep note. Attachments are looked
+// up next to the JSON file.
+func convertKeepNote(kn *keepNote, name string, files map[string]*zip.File) (*models.Note, ReportEntry) {
+	entry := ReportEntry{
+		File:     name,
+		Title:    kn.Title,
+		Pinned:   kn.IsPinned,
+		Archived: kn.IsArchived,
+		Trashed:  kn.IsTrashed,
+	}
+
+	note := &models.Note{
+		Title:    kn.Title,
+		Pinned:   kn.IsPinned,
+		Archived: kn.IsArchived,
+		Trashed:  kn.IsTrashed,
+	}
+
+	color, ok := keepColors[strings.ToUpper(kn.Color)]
+	if !ok {
+		color = models.ColorWhite
+		if kn.Color != "" {
+			entry.Warnings = append(entry.Warnings, fmt.Sprintf("unknown color %q, using white", kn.Color))
+		}
+	}
+	note.Color = string(color)
+
+	note.UpdatedAt = time.UnixMicro(*kn.UserEditedTimestampUsec)
+	note.CreatedAt = note.UpdatedAt
+	if kn.CreatedTimestampUsec > 0 {
+		note.CreatedAt = time.UnixMicro(kn.CreatedTimestampUsec)
+	}
+
+	for _, label := range kn.Labels {
+		note.Labels = append(note.Labels, label.Name)
+	}
+	entry.Labels = note.Labels
+
+	var sections []string
+	if text := strings.TrimRight(kn.TextContent, "\n"); text != "" {
+		sections = append(sections, text)
+	}
+	if len(kn.ListContent) > 0 {
+		items := make([]string, len(kn.ListContent))
+		for i, item := range kn.ListContent {
+			mark := " "
+			if item.IsChecked {
+				mark = "x"
+			}
+			items[i] = fmt.Sprintf("- [%s] %s", mark, item.Text)
+		}
+		sections = append(sections, strings.Join(items, "\n"))
+	}
+
+	var links []string
+	for _, a := range kn.Annotations {
+		if a.URL == "" {
+			continue
+		}
+		title := a.Title
+		if title == "" {
+			title = a.URL
+		}
+		links = append(links, fmt.Sprintf("[%s](%s)", title, a.URL))
+	}
+	if len(links) > 0 {
+		sections = append(sections, strings.Join(links, "\n"))
+	}
+
+	dir := path.Dir(name)
+	var refs []string
+	for _, ka := range kn.Attachments {
+		attachment, err := readKeepAttachment(dir, ka, files)
+		if err != nil {
+			entry.Warnings = append(entry.Warnings, err.Error())
+			continue
+		}
+		note.Attachments = append(note.Attachments, attachment)
+		refs = append(refs, attachment.Markdown())
+	}
+	if len(refs) > 0 {
+		sections = append(sections, strings.Join(refs, "\n"))
+	}
+	entry.Attachments = len(note.Attachments)
+
+	note.Content = strings.Join(sections, "\n\n")
+	return note, entry
+}
+
+// readKeepAttachment loads an attachment referenced by a note. Takeout does
+// not always keep the extension it records, e.g. ".jpeg" for a ".jpg" file,
+// so files with the same base name are tried as well.
+func readKeepAttachment(dir string, ka keepAttachment, files map[string]*zip.File) (models.Attachment, error) {
+	f, ok := files[path.Join(dir, ka.FilePath)]
+	if !ok {
+		stem := strings.TrimSuffix(ka.FilePath, path.Ext(ka.FilePath))
+		prefix := path.Join(dir, stem) + "."
+		for name, candidate := range files {
+			if strings.HasPrefix(name, prefix) && !strings.Contains(name[len(prefix):], "/") &&
+				!strings.EqualFold(path.Ext(name), ".json") {
+				f, ok = candidate, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return models.Attachment{}, fmt.Errorf("attachment %s not found in export", ka.FilePath)
+	}
+	if f.UncompressedSize64 > MaxAttachmentSize {
+		return models.Attachment{}, fmt.Errorf("attachment %s is too large (%d bytes)", ka.FilePath, f.UncompressedSize64)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("failed to open attachment %s: %w", ka.FilePath, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, MaxAttachmentSize+1))
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("failed to read attachment %s: %w", ka.FilePath, err)
+	}
+	if len(data) > MaxAttachmentSize {
+		return models.Attachment{}, fmt.Errorf("attachment %s is too large", ka.FilePath)
+	}
+
+	mimeType := ka.MimeType
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(path.Ext(f.Name))
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	return models.NewAttachment(path.Base(f.Name), mimeType, data), nil
+}
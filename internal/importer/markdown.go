@@ -0,0 +1,258 @@
+// internal/importer/markdown.go
+package importer
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Smil3MoreGH/gokeep/internal/exporter"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// Import actions reported per note
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionUnchanged = "unchanged"
+	ActionDuplicate = "duplicate"
+)
+
+// NoteRestorer stores notes read from a Markdown export; NoteRepository
+// satisfies it
+type NoteRestorer interface {
+	NoteCreator
+	GetAll(ctx context.Context) ([]models.Note, error)
+	Restore(ctx context.Context, note *models.Note) error
+}
+
+// ImportMarkdownPath imports a Markdown export from a zip archive or a
+// directory
+func ImportMarkdownPath(ctx context.Context, source string, store NoteRestorer, opts Options) (*Report, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	if info.IsDir() {
+		return ImportMarkdownFS(ctx, os.DirFS(source), store, opts)
+	}
+
+	zr, err := zip.OpenReader(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	defer zr.Close()
+
+	return ImportMarkdownFS(ctx, zr, store, opts)
+}
+
+// ImportMarkdown imports a Markdown export zip read from r
+func ImportMarkdown(ctx context.Context, r io.ReaderAt, size int64, store NoteRestorer, opts Options) (*Report, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+
+	return ImportMarkdownFS(ctx, zr, store, opts)
+}
+
+// ImportMarkdownFS imports every .md file in fsys. A note whose ID exists is
+// updated when it is the same note (same creation time) and left alone when
+// nothing changed. Other notes are skipped when a note with the same title
+// and content was already stored before the import, and created otherwise,
+// keeping their ID if it is free.
+func ImportMarkdownFS(ctx context.Context, fsys fs.FS, store NoteRestorer, opts Options) (*Report, error) {
+	existing, err := store.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]models.Note, len(existing))
+	byHash := make(map[string]int64, len(existing))
+	for _, note := range existing {
+		byID[note.ID] = note
+		byHash[contentHash(note)] = note.ID
+	}
+
+	var files []string
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && name == "attachments" {
+			return fs.SkipDir
+		}
+		if !d.IsDir() && strings.EqualFold(path.Ext(name), ".md") {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+	slices.Sort(files)
+
+	report := &Report{DryRun: opts.DryRun, Notes: []ReportEntry{}}
+	for _, name := range files {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return report, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		note, fm, err := exporter.ParseNote(name, data)
+		if err == nil {
+			err = note.Validate()
+		}
+		if err != nil {
+			report.Skipped++
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		entry := ReportEntry{
+			File:     name,
+			ID:       note.ID,
+			Title:    note.Title,
+			Labels:   note.Labels,
+			Pinned:   note.Pinned,
+			Archived: note.Archived,
+			Trashed:  note.Trashed,
+		}
+		for _, ref := range fm.Attachments {
+			attachment, err := readMarkdownAttachment(fsys, ref)
+			if err != nil {
+				entry.Warnings = append(entry.Warnings, err.Error())
+				continue
+			}
+			if ref.ID != "" && ref.ID != attachment.ID {
+				note.Content = strings.ReplaceAll(note.Content, models.AttachmentURL(ref.ID), attachment.URL())
+			}
+			note.Attachments = append(note.Attachments, attachment)
+		}
+		entry.Attachments = len(note.Attachments)
+
+		stored, sameNote := byID[note.ID]
+		sameNote = sameNote && stored.CreatedAt.Equal(note.CreatedAt)
+		duplicateOf, duplicate := byHash[contentHash(note)]
+
+		switch {
+		case sameNote && equalNotes(stored, note):
+			entry.Action = ActionUnchanged
+		case sameNote:
+			entry.Action = ActionUpdated
+		case duplicate:
+			entry.Action = ActionDuplicate
+			entry.DuplicateOf = duplicateOf
+		default:
+			entry.Action = ActionCreated
+			if _, taken := byID[note.ID]; taken || note.ID <= 0 {
+				// The ID is missing or taken by another note, so a new one is assigned
+				note.ID = 0
+				renewAttachmentIDs(&note)
+			}
+		}
+
+		if !opts.DryRun {
+			switch {
+			case entry.Action == ActionUpdated || (entry.Action == ActionCreated && note.ID > 0):
+				err = store.Restore(ctx, &note)
+			case entry.Action == ActionCreated:
+				err = store.Create(ctx, &note)
+			}
+			if err != nil {
+				return report, &StoreError{Name: name, Err: err}
+			}
+			entry.ID = note.ID
+		}
+
+		switch entry.Action {
+		case ActionCreated, ActionUpdated:
+			report.Imported++
+			report.Attachments += entry.Attachments
+			if note.ID > 0 {
+				byID[note.ID] = note
+			}
+		default:
+			report.Skipped++
+		}
+		report.Notes = append(report.Notes, entry)
+	}
+
+	if len(files) == 0 {
+		return report, fmt.Errorf("no Markdown files found in export")
+	}
+
+	return report, nil
+}
+
+// readMarkdownAttachment loads a file referenced from a note's front matter
+func readMarkdownAttachment(fsys fs.FS, ref exporter.AttachmentRef) (models.Attachment, error) {
+	name := path.Clean(ref.Path)
+	if !fs.ValidPath(name) {
+		return models.Attachment{}, fmt.Errorf("attachment %s has an invalid path", ref.Path)
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("attachment %s not found in export", ref.Path)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, MaxAttachmentSize+1))
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("failed to read attachment %s: %w", ref.Path, err)
+	}
+	if len(data) > MaxAttachmentSize {
+		return models.Attachment{}, fmt.Errorf("attachment %s is too large", ref.Path)
+	}
+
+	// Anything but a UUID gets a fresh ID, since the ID becomes part of
+	// the file paths of later exports
+	attachment := models.NewAttachment(ref.Filename, ref.MimeType, data)
+	if models.ValidAttachmentID(ref.ID) {
+		attachment.ID = ref.ID
+	}
+	return attachment, nil
+}
+
+// renewAttachmentIDs gives the attachments of a copied note fresh IDs and
+// points the links in its content at them
+func renewAttachmentIDs(note *models.Note) {
+	for i := range note.Attachments {
+		renewed := models.NewAttachment(note.Attachments[i].Filename, note.Attachments[i].MimeType, note.Attachments[i].Data)
+		note.Content = strings.ReplaceAll(note.Content, note.Attachments[i].URL(), renewed.URL())
+		note.Attachments[i] = renewed
+	}
+}
+
+// contentHash identifies a note by its title and content
+func contentHash(note models.Note) string {
+	sum := sha256.Sum256([]byte(note.Title + "\x00" + note.Content))
+	return fmt.Sprintf("%x", sum)
+}
+
+// equalNotes reports whether an import would leave stored unchanged
+func equalNotes(stored, imported models.Note) bool {
+	return stored.Title == imported.Title &&
+		stored.Content == imported.Content &&
+		(imported.Color == "" || stored.Color == imported.Color) &&
+		stored.Pinned == imported.Pinned &&
+		stored.Archived == imported.Archived &&
+		stored.Trashed == imported.Trashed &&
+		stored.UpdatedAt.Equal(imported.UpdatedAt) &&
+		slices.Equal(stored.Labels, sortedLabels(imported.Labels))
+}
+
+// sortedLabels orders labels the way the database returns them
+func sortedLabels(labels []string) []string {
+	result := append([]string{}, labels...)
+	slices.SortFunc(result, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	return result
+}
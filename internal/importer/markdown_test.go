@@ -0,0 +1,126 @@
+// internal/importer/markdown_test.go
+package importer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/internal/database/dbtest"
+	"github.com/Smil3MoreGH/gokeep/internal/exporter"
+	"github.com/Smil3MoreGH/gokeep/internal/importer"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := database.NewNoteRepository(dbtest.Open(t), nil)
+
+	photo := models.NewAttachment("photo.png", "image/png", []byte("not really a png"))
+	original := models.Note{
+		Title:       "Holiday",
+		Content:     "Packing list\n\n![photo](" + photo.URL() + ")",
+		Color:       string(models.ColorBlue),
+		Labels:      []string{"travel", "family"},
+		Pinned:      true,
+		CreatedAt:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		UpdatedAt:   time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC),
+		Attachments: []models.Attachment{photo},
+	}
+	if err := src.Create(ctx, &original); err != nil {
+		t.Fatal(err)
+	}
+	trashed := models.Note{Title: "Old", Content: "gone", Trashed: true}
+	if err := src.Create(ctx, &trashed); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "export")
+	if n, err := exporter.ExportMarkdownTo(ctx, src, dir); err != nil || n != 2 {
+		t.Fatalf("exported %d notes, %v", n, err)
+	}
+
+	dst := database.NewNoteRepository(dbtest.Open(t), nil)
+	report, err := importer.ImportMarkdownPath(ctx, dir, dst, importer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Imported != 2 || report.Attachments != 1 {
+		t.Fatalf("imported %d notes and %d attachments", report.Imported, report.Attachments)
+	}
+
+	got, err := dst.GetByID(ctx, original.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != original.Title || got.Content != original.Content || got.Color != original.Color ||
+		!got.Pinned || !got.CreatedAt.Equal(original.CreatedAt) || !got.UpdatedAt.Equal(original.UpdatedAt) ||
+		!slices.Equal(got.Labels, []string{"family", "travel"}) {
+		t.Errorf("imported note differs:\n got %+v\nwant %+v", got, original)
+	}
+	if len(got.Attachments) != 1 || got.Attachments[0].ID != photo.ID {
+		t.Fatalf("imported attachments %+v", got.Attachments)
+	}
+	file, err := dst.GetAttachment(ctx, photo.ID)
+	if err != nil || string(file.Data) != "not really a png" {
+		t.Errorf("attachment data %q, %v", file.Data, err)
+	}
+	if back, err := dst.GetByID(ctx, trashed.ID); err != nil || !back.Trashed {
+		t.Errorf("trashed note came back as %+v, %v", back, err)
+	}
+
+	// Importing the same export again changes nothing
+	report, err = importer.ImportMarkdownPath(ctx, dir, dst, importer.Options{})
+	if err != nil || report.Imported != 0 || report.Skipped != 2 {
+		t.Errorf("second import: %+v, %v", report, err)
+	}
+}
+
+func TestMarkdownImportRejectsPathsInAttachmentIDs(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewNoteRepository(dbtest.Open(t), nil)
+
+	export := fstest.MapFS{
+		"note.md": {Data: []byte(`---
+title: Innocent
+created: 2024-05-01T12:00:00Z
+updated: 2024-05-01T12:00:00Z
+attachments:
+  - id: ../../escaped
+    filename: pwn.txt
+    mime_type: text/plain
+    path: attachments/x/pwn.txt
+---
+See [the file](/api/attachments/../../escaped)
+`)},
+		"attachments/x/pwn.txt": {Data: []byte("pwned")},
+	}
+	if _, err := importer.ImportMarkdownFS(ctx, export, repo, importer.Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := repo.GetAll(ctx)
+	if err != nil || len(notes) != 1 || len(notes[0].Attachments) != 1 {
+		t.Fatalf("imported %+v, %v", notes, err)
+	}
+	stored := notes[0].Attachments[0]
+	if !models.ValidAttachmentID(stored.ID) {
+		t.Fatalf("attachment stored under ID %q", stored.ID)
+	}
+	if want := "See [the file](" + stored.URL() + ")\n"; notes[0].Content != want {
+		t.Errorf("content %q, want the link pointed at the new ID", notes[0].Content)
+	}
+
+	root := t.TempDir()
+	if _, err := exporter.ExportMarkdownTo(ctx, repo, filepath.Join(root, "out", "exp")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped")); err == nil {
+		t.Error("export wrote outside its directory")
+	}
+}
@@ -0,0 +1,54 @@
+// internal/importer/report.go
+package importer
+
+import "fmt"
+
+// Options controls an import
+type Options struct {
+	// DryRun parses the export and reports what would be imported without
+	// writing anything
+	DryRun bool
+}
+
+// Report summarises an import
+type Report struct {
+	DryRun      bool          `json:"dry_run"`
+	Imported    int           `json:"imported"`
+	Skipped     int           `json:"skipped"`
+	Attachments int           `json:"attachments"`
+	Notes       []ReportEntry `json:"notes"`
+	Warnings    []string      `json:"warnings,omitempty"`
+}
+
+// ReportEntry describes one note of the export
+type ReportEntry struct {
+	File        string   `json:"file"`
+	ID          int64    `json:"id,omitempty"`
+	Title       string   `json:"title"`
+	Labels      []string `json:"labels,omitempty"`
+	Pinned      bool     `json:"pinned,omitempty"`
+	Archived    bool     `json:"archived,omitempty"`
+	Trashed     bool     `json:"trashed,omitempty"`
+	Attachments int      `json:"attachments,omitempty"`
+	// Action is what happened to the note: created, updated, unchanged or
+	// duplicate. Keep imports always create.
+	Action      string   `json:"action,omitempty"`
+	DuplicateOf int64    `json:"duplicate_of,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// StoreError is returned when the store fails to save a note of the
+// export, as opposed to the export itself being unreadable
+type StoreError struct {
+	// Name is the file of the note in the export
+	Name string
+	Err  error
+}
+
+func (e *StoreError) Error() string {
+	return fmt.Sprintf("failed to import %s: %v", e.Name, e.Err)
+}
+
+func (e *StoreError) Unwrap() error {
+	return e.Err
+}
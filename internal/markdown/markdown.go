@@ -0,0 +1,21 @@
+// internal/markdown/markdown.go
+package markdown
+
+import (
+	"github.com/russross/blackfriday/v2"
+)
+
+// Extensions are the Markdown extensions used wherever note content is
+// rendered, so the browser and exports agree
+const Extensions = blackfriday.CommonExtensions | blackfriday.Autolink
+
+// Parse returns the syntax tree of note content
+func Parse(content string) *blackfriday.Node {
+	return blackfriday.New(blackfriday.WithExtensions(Extensions)).Parse([]byte(content))
+}
+
+// HTML renders note content to HTML that is safe to put into a page
+func HTML(content string) string {
+	html := blackfriday.Run([]byte(content), blackfriday.WithExtensions(Extensions))
+	return string(Sanitize(html))
+}
@@ -0,0 +1,288 @@
+// internal/markdown/sanitize.go
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedTags are the elements kept by Sanitize: what blackfriday
+// produces, plus harmless inline HTML people write in notes. Any other
+// element is dropped but its text is kept.
+var allowedTags = map[string]bool{
+	"a": true, "abbr": true, "b": true, "blockquote": true, "br": true,
+	"code": true, "dd": true, "del": true, "details": true, "div": true,
+	"dl": true, "dt": true, "em": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "hr": true, "i": true, "img": true,
+	"ins": true, "kbd": true, "li": true, "mark": true, "ol": true, "p": true,
+	"pre": true, "q": true, "s": true, "small": true, "span": true,
+	"strong": true, "sub": true, "summary": true, "sup": true, "table": true,
+	"tbody": true, "td": true, "tfoot": true, "th": true, "thead": true,
+	"tr": true, "u": true, "ul": true,
+}
+
+// droppedTags are dropped together with everything inside them, since
+// their content is code, markup of another kind or form state rather than
+// text. One that is never closed loses only its tag, so it cannot swallow
+// the rest of the note.
+var droppedTags = map[string]bool{
+	"iframe": true, "math": true, "noembed": true, "noframes": true,
+	"noscript": true, "object": true, "plaintext": true, "script": true,
+	"select": true, "style": true, "svg": true, "template": true,
+	"textarea": true, "title": true, "xmp": true,
+}
+
+// rawTextTags hold raw text up to their end tag, or to the end of the input
+// if there is none; the tokenizer does not look for markup inside them
+var rawTextTags = map[string]bool{
+	"iframe": true, "noembed": true, "noframes": true, "noscript": true,
+	"plaintext": true, "script": true, "style": true, "textarea": true,
+	"title": true, "xmp": true,
+}
+
+// voidTags have no content and no end tag
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+var (
+	// languageClass is the class blackfriday gives fenced code blocks
+	languageClass = regexp.MustCompile(`^language-[\w+#-]+$`)
+	// footnoteID are the IDs blackfriday gives footnotes and their references
+	footnoteID = regexp.MustCompile(`^fn(ref)?:[\w-]+$`)
+	alignment  = regexp.MustCompile(`^(left|right|center)$`)
+	number     = regexp.MustCompile(`^[0-9]{1,6}$`)
+)
+
+// attributeRule decides whether an attribute value may be kept
+type attributeRule func(value string) bool
+
+// allowedAttributes are the attributes kept per element; "*" applies to
+// all of them. Nothing that runs script, loads content other than images,
+// or styles the page is on the list.
+var allowedAttributes = map[string]map[string]attributeRule{
+	"*":    {"title": anyValue},
+	"a":    {"href": SafeURL},
+	"code": {"class": languageClass.MatchString},
+	"img": {
+		"src":    safeImageURL,
+		"alt":    anyValue,
+		"width":  number.MatchString,
+		"height": number.MatchString,
+	},
+	"li":  {"id": footnoteID.MatchString},
+	"ol":  {"start": number.MatchString},
+	"sup": {"id": footnoteID.MatchString},
+	"td":  {"align": alignment.MatchString},
+	"th":  {"align": alignment.MatchString},
+}
+
+func anyValue(string) bool { return true }
+
+// Sanitize keeps only the allowlisted elements and attributes of an HTML
+// fragment, so rendered notes cannot run script in the page that shows
+// them. Text is re-escaped, comments are dropped and every element left
+// open is closed, so the fragment cannot break out of its container.
+func Sanitize(fragment []byte) []byte {
+	var buf bytes.Buffer
+	var open []string
+
+	tokens := tokenize(fragment)
+	ends := droppedEnds(tokens)
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.Type {
+		case html.TextToken:
+			buf.WriteString(html.EscapeString(tok.Data))
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedTags[tok.Data] {
+				if end, ok := ends[i]; ok {
+					i = end
+				}
+				continue
+			}
+			if !allowedTags[tok.Data] {
+				continue
+			}
+			writeStartTag(&buf, tok)
+			if !voidTags[tok.Data] {
+				open = append(open, tok.Data)
+			}
+
+		case html.EndTagToken:
+			// Close the element and any left open inside it, or ignore
+			// the end tag if the element is not open
+			for k := len(open) - 1; k >= 0; k-- {
+				if open[k] != tok.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= k; j-- {
+					buf.WriteString("</" + open[j] + ">")
+				}
+				open = open[:k]
+				break
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		buf.WriteString("</" + open[i] + ">")
+	}
+	return buf.Bytes()
+}
+
+// tokenize splits fragment into tokens. A raw text element that is never
+// closed would turn everything after it into raw text, so its content is
+// tokenized as markup instead. Self-closing raw text tags still start raw
+// text and are made start tags.
+func tokenize(fragment []byte) []html.Token {
+	var tokens []html.Token
+	lastEnd := make(map[string]int)
+	pos := 0
+
+	z := html.NewTokenizer(bytes.NewReader(fragment))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		pos += len(z.Raw())
+		tok := z.Token()
+
+		if (tt == html.StartTagToken || tt == html.SelfClosingTagToken) && rawTextTags[tok.Data] {
+			tok.Type = html.StartTagToken
+			end, ok := lastEnd[tok.Data]
+			if !ok {
+				end = lastEndTag(fragment, tok.Data)
+				lastEnd[tok.Data] = end
+			}
+			if tok.Data == "plaintext" || end < pos {
+				z.NextIsNotRawText()
+			}
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+// lastEndTag returns the offset of the last end tag of the raw text element
+// name in fragment, as the tokenizer recognizes it, or -1 if there is none
+func lastEndTag(fragment []byte, name string) int {
+	for end := len(fragment); ; {
+		i := bytes.LastIndex(fragment[:end], []byte("</"))
+		if i < 0 {
+			return -1
+		}
+		tag := fragment[i+2:]
+		if len(tag) > len(name) && strings.EqualFold(string(tag[:len(name)]), name) {
+			switch tag[len(name)] {
+			case ' ', '\n', '\r', '\t', '\f', '/', '>':
+				return i
+			}
+		}
+		end = i
+	}
+}
+
+// droppedEnds maps the index of the start tag of every dropped element to
+// the index of its end tag. Elements that are never closed are left out.
+func droppedEnds(tokens []html.Token) map[int]int {
+	ends := make(map[int]int)
+	starts := make(map[string][]int)
+	for i, tok := range tokens {
+		if !droppedTags[tok.Data] {
+			continue
+		}
+		switch tok.Type {
+		case html.StartTagToken:
+			starts[tok.Data] = append(starts[tok.Data], i)
+		case html.EndTagToken:
+			if open := starts[tok.Data]; len(open) > 0 {
+				ends[open[len(open)-1]] = i
+				starts[tok.Data] = open[:len(open)-1]
+			}
+		}
+	}
+	return ends
+}
+
+// writeStartTag writes tok with the attributes allowed on it. External
+// links get rel="noopener noreferrer nofollow".
+func writeStartTag(buf *bytes.Buffer, tok html.Token) {
+	buf.WriteString("<" + tok.Data)
+	external := false
+	seen := make(map[string]bool)
+	for _, attr := range tok.Attr {
+		if attr.Namespace != "" || seen[attr.Key] {
+			continue
+		}
+		allowed, ok := allowedAttributes[tok.Data][attr.Key]
+		if !ok {
+			allowed, ok = allowedAttributes["*"][attr.Key]
+		}
+		if !ok || !allowed(attr.Val) {
+			continue
+		}
+		seen[attr.Key] = true
+		if tok.Data == "a" && attr.Key == "href" && urlScheme(attr.Val) != "" {
+			external = true
+		}
+		buf.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	if external {
+		buf.WriteString(` rel="noopener noreferrer nofollow"`)
+	}
+	buf.WriteString(">")
+}
+
+// SafeURL reports whether a link may point at url: relative URLs,
+// fragments and http, https and mailto URLs may, javascript: and data: URLs
+// and anything else may not
+func SafeURL(url string) bool {
+	switch urlScheme(url) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// safeImageURL is SafeURL for images, which may also be inline raster
+// images
+func safeImageURL(url string) bool {
+	switch urlScheme(url) {
+	case "", "http", "https":
+		return true
+	case "data":
+		_, data, _ := strings.Cut(url, ":")
+		mediaType, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(data)), ";")
+		switch mediaType {
+		case "image/png", "image/gif", "image/jpeg", "image/webp":
+			return true
+		}
+	}
+	return false
+}
+
+// urlScheme returns the lower-cased scheme of url, or "" if it is
+// relative. Browsers skip tabs and newlines inside a URL, as in
+// "java\tscript:", so whitespace and control characters are left out
+// here; anything else before the colon ends up in the scheme and fails
+// the checks above.
+func urlScheme(url string) string {
+	var b strings.Builder
+	for _, r := range url {
+		if r <= ' ' || r == 0x7f {
+			continue
+		}
+		switch r {
+		case ':':
+			return strings.ToLower(b.String())
+		case '/', '?', '#':
+			return ""
+		}
+		b.WriteRune(r)
+	}
+	return ""
+}
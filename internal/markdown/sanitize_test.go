@@ -0,0 +1,117 @@
+// internal/markdown/sanitize_test.go
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestHTMLIsSafe(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		// want are parts the output must still contain
+		want []string
+	}{
+		{"script", "<script>alert(1)</script>\n\nafter", []string{"after"}},
+		{"script in a paragraph", "before <script>alert(1)</script> after", []string{"before", "after"}},
+		{"img onerror", "<img src=x onerror=alert(1)>", []string{`<img src="x">`}},
+		{"svg onload", "<svg onload=alert(1)><circle r=1 /></svg>\n\nafter", []string{"after"}},
+		{"script inside svg", "<svg><script>alert(1)</script></svg>", nil},
+		{"iframe", `<iframe src="https://example.com"></iframe>` + "\n\nafter", []string{"after"}},
+		{"style", "<style>body { display: none }</style>\n\nafter", []string{"after"}},
+		{"object", `<object data="x.swf"><embed src="x.swf"></object>`, nil},
+		{"form controls", `<form action="https://example.com"><input name=x><button formaction="javascript:alert(1)">go</button></form>`, []string{"go"}},
+		{"comment", "<!-- <script>alert(1)</script> -->after", []string{"after"}},
+
+		{"javascript link", "[x](javascript:alert(1))", nil},
+		{"mixed case scheme", "[x](JaVaScRiPt:alert(1))", nil},
+		{"tab in scheme", "<a href=\"java\tscript:alert(1)\">x</a>", []string{">x</a>"}},
+		{"entity tab in scheme", `<a href="java&#x09;script:alert(1)">x</a>`, []string{">x</a>"}},
+		{"entity tab in Markdown link", "[x](java&#x09;script:alert(1))", nil},
+		{"entity newline in scheme", `<a href="java&#10;script:alert(1)">x</a>`, nil},
+		{"entity letters", `<a href="&#106;&#97;vascript:alert(1)">x</a>`, nil},
+		{"entity colon", `<a href="javascript&colon;alert(1)">x</a>`, nil},
+		{"leading space", `<a href=" javascript:alert(1)">x</a>`, nil},
+		{"vbscript", `<a href="vbscript:msgbox(1)">x</a>`, nil},
+		{"data html link", "[x](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)", nil},
+		{"data html image", `<img src="data:text/html,<script>alert(1)</script>">`, nil},
+		{"data svg image", "![x](data:image/svg+xml;base64,PHN2ZyBvbmxvYWQ9YWxlcnQoMSk+)", nil},
+		{"javascript image", "![x](javascript:alert(1))", nil},
+
+		{"breakout of a quoted attribute", `<a title=""><img src=x onerror=alert(1)>">x</a>`, nil},
+		{"breakout of an image alt", `![x"><img src=x onerror=alert(1)>](a.png)`, nil},
+		{"breakout of a link title", `[x](a.png "\"><img src=x onerror=alert(1)>")`, nil},
+		{"breakout of raw text", `<noscript><p title="</noscript><img src=x onerror=alert(1)>">`, nil},
+		{"breakout of the container", "</div></body><script>alert(1)</script>", nil},
+		{"style attribute", `<p style="background:url(javascript:alert(1))">x</p>`, []string{"<p>x</p>"}},
+
+		{"unclosed select", "<select>\n\nafter **select**", []string{"<strong>select</strong>"}},
+		{"unclosed textarea", "<textarea>\n\nafter **textarea**", []string{"<strong>textarea</strong>"}},
+		{"unclosed script", "<script>\n\nafter **script**", []string{"<strong>script</strong>"}},
+		{"unclosed style", "<style>\n\nafter <img src=x onerror=alert(1)>", []string{"after"}},
+		{"unclosed title", "<title>\n\nafter", []string{"after"}},
+		{"plaintext", "<plaintext>\n\nafter **plaintext**", []string{"<strong>plaintext</strong>"}},
+		{"unbalanced svg", "<svg><svg></svg>\n\nafter **svg**", []string{"<strong>svg</strong>"}},
+		{"self-closing script", "<script/>alert(1)</script>\n\nafter", []string{"after"}},
+
+		{"safe link", "[x](https://example.com)", []string{`<a href="https://example.com" rel="noopener noreferrer nofollow">x</a>`}},
+		{"relative link", "[x](/notes/1)", []string{`<a href="/notes/1">x</a>`}},
+		{"inline image", "![x](data:image/png;base64,iVBORw0KGgo=)", []string{`src="data:image/png;base64,iVBORw0KGgo="`}},
+		{"code block", "```go\nfmt.Println(\"<script>\")\n```", []string{`<code class="language-go">`, "&lt;script&gt;"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := HTML(tt.content)
+			if problem := unsafeHTML(out); problem != "" {
+				t.Errorf("%s in %q", problem, out)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("%q lacks %q", out, want)
+				}
+			}
+		})
+	}
+}
+
+// unsafeHTML parses out as a browser would and describes the first element
+// or attribute that is not on the allowlist or could run script, or returns
+// "" if there is none
+func unsafeHTML(out string) string {
+	doc, err := html.Parse(strings.NewReader("<!DOCTYPE html><body>" + out))
+	if err != nil {
+		return err.Error()
+	}
+	var problem string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if problem != "" {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data != "html" && n.Data != "head" && n.Data != "body" {
+			if !allowedTags[n.Data] {
+				problem = "element " + n.Data
+				return
+			}
+			for _, attr := range n.Attr {
+				key := strings.ToLower(attr.Key)
+				switch {
+				case strings.HasPrefix(key, "on"), key == "style", key == "srcdoc", key == "formaction":
+					problem = "attribute " + key
+				case key == "href" && !SafeURL(attr.Val):
+					problem = "link to " + attr.Val
+				case key == "src" && !safeImageURL(attr.Val):
+					problem = "image from " + attr.Val
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return problem
+}
@@ -0,0 +1,111 @@
+// internal/metrics/database.go
+package metrics
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Stats are the sources the database collector reads on every scrape
+type Stats interface {
+	Stats(ctx context.Context) (*database.Stats, error)
+}
+
+// Pool reports connection pool statistics, like *database.DB
+type Pool interface {
+	Stats() sql.DBStats
+}
+
+// DatabaseCollector exports connection pool statistics and what the
+// database holds, read fresh on every scrape
+type DatabaseCollector struct {
+	pool  Pool
+	stats Stats
+
+	openConns        *prometheus.Desc
+	inUseConns       *prometheus.Desc
+	idleConns        *prometheus.Desc
+	maxOpenConns     *prometheus.Desc
+	waitCount        *prometheus.Desc
+	waitDuration     *prometheus.Desc
+	maxIdleClosed    *prometheus.Desc
+	maxLifetimeClose *prometheus.Desc
+
+	notes           *prometheus.Desc
+	pinned          *prometheus.Desc
+	labels          *prometheus.Desc
+	attachments     *prometheus.Desc
+	attachmentBytes *prometheus.Desc
+}
+
+// NewDatabaseCollector creates a collector for the given pool and content
+func NewDatabaseCollector(pool Pool, stats Stats) *DatabaseCollector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil)
+	}
+	return &DatabaseCollector{
+		pool:  pool,
+		stats: stats,
+
+		openConns:        desc("db_open_connections", "Open database connections, in use or idle."),
+		inUseConns:       desc("db_in_use_connections", "Database connections in use."),
+		idleConns:        desc("db_idle_connections", "Idle database connections."),
+		maxOpenConns:     desc("db_max_open_connections", "Limit of open database connections."),
+		waitCount:        desc("db_wait_count_total", "Times a request waited for a database connection."),
+		waitDuration:     desc("db_wait_duration_seconds_total", "Time spent waiting for database connections."),
+		maxIdleClosed:    desc("db_max_idle_closed_total", "Connections closed because of the idle connection limit."),
+		maxLifetimeClose: desc("db_max_lifetime_closed_total", "Connections closed because they reached their maximum lifetime."),
+
+		notes:           desc("notes", "Notes by state: active, archived or trashed.", "state"),
+		pinned:          desc("notes_pinned", "Pinned notes, in any state."),
+		labels:          desc("labels", "Labels used by at least one note."),
+		attachments:     desc("attachments", "Stored attachments."),
+		attachmentBytes: desc("attachment_bytes", "Total size of stored attachments."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *DatabaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.openConns, c.inUseConns, c.idleConns, c.maxOpenConns,
+		c.waitCount, c.waitDuration, c.maxIdleClosed, c.maxLifetimeClose,
+		c.notes, c.pinned, c.labels, c.attachments, c.attachmentBytes,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *DatabaseCollector) Collect(ch chan<- prometheus.Metric) {
+	pool := c.pool.Stats()
+	gauge := func(d *prometheus.Desc, v float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, labels...)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+	gauge(c.openConns, float64(pool.OpenConnections))
+	gauge(c.inUseConns, float64(pool.InUse))
+	gauge(c.idleConns, float64(pool.Idle))
+	gauge(c.maxOpenConns, float64(pool.MaxOpenConnections))
+	counter(c.waitCount, float64(pool.WaitCount))
+	counter(c.waitDuration, pool.WaitDuration.Seconds())
+	counter(c.maxIdleClosed, float64(pool.MaxIdleClosed))
+	counter(c.maxLifetimeClose, float64(pool.MaxLifetimeClosed))
+
+	stats, err := c.stats.Stats(context.Background())
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.notes, err)
+		return
+	}
+	gauge(c.notes, float64(stats.Notes-stats.Archived-stats.Trashed), "active")
+	gauge(c.notes, float64(stats.Archived), "archived")
+	gauge(c.notes, float64(stats.Trashed), "trashed")
+	gauge(c.pinned, float64(stats.Pinned))
+	gauge(c.labels, float64(stats.Labels))
+	gauge(c.attachments, float64(stats.Attachments))
+	gauge(c.attachmentBytes, float64(stats.AttachmentBytes))
+}
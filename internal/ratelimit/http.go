@@ -0,0 +1,72 @@
+// internal/ratelimit/http.go
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reject answers a request that was refused. Retry-After is already set.
+type Reject func(w http.ResponseWriter, r *http.Request, retryAfter time.Duration)
+
+// Limit spends a token of l for every request that match selects, both
+// from the budget of the client's IP address and from that of its bearer
+// token if it sent one. A nil match selects every request. Requests over
+// budget are answered by reject.
+func Limit(l *Limiter, match func(*http.Request) bool, reject Reject) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if match != nil && !match(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if ok, wait := l.Allow(ClientKeys(r)...); !ok {
+				Refuse(w, r, wait, reject)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsWrite selects requests that change data
+func IsWrite(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// ClientKeys returns the keys a request is limited by: its IP address, as
+// set by security.RealIP, and a hash of its bearer token if it has one
+func ClientKeys(r *http.Request) []string {
+	keys := []string{"ip:" + ClientIP(r)}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+		sum := sha256.Sum256([]byte(token))
+		keys = append(keys, "token:"+hex.EncodeToString(sum[:8]))
+	}
+	return keys
+}
+
+// ClientIP returns the IP address of the client without the port
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// Refuse sets Retry-After to wait, rounded up to whole seconds, and lets
+// reject answer the request
+func Refuse(w http.ResponseWriter, r *http.Request, wait time.Duration, reject Reject) {
+	seconds := int64(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.FormatInt(max(seconds, 1), 10))
+	reject(w, r, wait)
+}
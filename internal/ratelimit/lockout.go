@@ -0,0 +1,83 @@
+// internal/ratelimit/lockout.go
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Lockout shuts out a key, e.g. an IP address, after failed
+// authentications. The first failure locks it for base, and every further
+// one doubles the time up to max. Failures are forgotten after a success,
+// or once max has passed without one.
+type Lockout struct {
+	base, max time.Duration
+
+	mu      sync.Mutex
+	strikes map[string]*strike
+}
+
+type strike struct {
+	failures int
+	last     time.Time
+	until    time.Time
+}
+
+// NewLockout creates a lockout starting at base and growing up to limit
+func NewLockout(base, limit time.Duration) *Lockout {
+	return &Lockout{base: base, max: limit, strikes: make(map[string]*strike)}
+}
+
+// Locked returns how long key is still shut out, or zero if it is not
+func (l *Lockout) Locked(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s, ok := l.strikes[key]
+	if !ok {
+		return 0
+	}
+	return max(time.Until(s.until), 0)
+}
+
+// Fail records a failed authentication of key and returns how long it is
+// locked out now
+func (l *Lockout) Fail(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.forget(now)
+
+	s, ok := l.strikes[key]
+	if !ok {
+		s = &strike{}
+		l.strikes[key] = s
+	}
+	s.failures++
+	s.last = now
+
+	wait := l.base
+	for i := 1; i < s.failures && wait < l.max; i++ {
+		wait *= 2
+	}
+	wait = min(wait, l.max)
+	s.until = now.Add(wait)
+	return wait
+}
+
+// Succeed forgets the failures of key
+func (l *Lockout) Succeed(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.strikes, key)
+}
+
+// forget drops keys whose last failure is longer than max ago
+func (l *Lockout) forget(now time.Time) {
+	for key, s := range l.strikes {
+		if now.Sub(s.last) > l.max && now.After(s.until) {
+			delete(l.strikes, key)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// internal/security/csrf.go
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Names of the double-submit CSRF token. The server sets the cookie, and
+// the browser UI copies it into the header on every write; pkg/client
+// repeats the names. A page of another site can make the browser send the
+// cookie, but it can neither read it nor set the header.
+const (
+	CSRFCookie = "gokeep_csrf"
+	// CSRFCookieSecure is the name over HTTPS. The __Host- prefix keeps
+	// other hosts of the domain from planting a token of their own.
+	CSRFCookieSecure = "__Host-gokeep_csrf"
+	CSRFHeader       = "X-CSRF-Token"
+)
+
+const (
+	tokenBytes  = 32
+	tokenMaxAge = 365 * 24 * time.Hour
+)
+
+// CSRF hands out CSRF tokens and checks them
+type CSRF struct {
+	secure bool
+	reject http.HandlerFunc
+}
+
+// NewCSRF creates a CSRF check answering refused requests with reject.
+// secure marks the cookie for HTTPS only.
+func NewCSRF(secure bool, reject http.HandlerFunc) *CSRF {
+	return &CSRF{secure: secure, reject: reject}
+}
+
+// Issue sets a token cookie on page loads that do not carry a valid one,
+// so the page equips the UI with a token. API calls, metrics scrapes and
+// the like get no cookie they would never use.
+func (c *CSRF) Issue(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if pageLoad(r) && c.token(r) == "" {
+			http.SetCookie(w, &http.Cookie{
+				Name:     c.cookieName(),
+				Value:    newToken(),
+				Path:     "/",
+				MaxAge:   int(tokenMaxAge.Seconds()),
+				Secure:   c.secure,
+				SameSite: http.SameSiteStrictMode,
+				// Not HttpOnly: the UI has to read it
+			})
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Verify refuses writes from a browser unless the CSRF header matches the
+// cookie. Requests without an Origin or Sec-Fetch-Site header do not come
+// from a browser, so no other site can have sent them; they pass, which
+// lets the CLI and other API clients work without a token.
+func (c *CSRF) Verify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !fromBrowser(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token := c.token(r)
+		given := r.Header.Get(CSRFHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.reject(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// token returns the token cookie of r, or "" if it has none or the value
+// is not one Issue could have made
+func (c *CSRF) token(r *http.Request) string {
+	cookie, err := r.Cookie(c.cookieName())
+	if err != nil {
+		return ""
+	}
+	if b, err := base64.RawURLEncoding.DecodeString(cookie.Value); err != nil || len(b) != tokenBytes {
+		return ""
+	}
+	return cookie.Value
+}
+
+func (c *CSRF) cookieName() string {
+	if c.secure {
+		return CSRFCookieSecure
+	}
+	return CSRFCookie
+}
+
+// fromBrowser reports whether r carries the headers browsers add to
+// requests made by pages
+func fromBrowser(r *http.Request) bool {
+	return r.Header.Get("Origin") != "" || r.Header.Get("Sec-Fetch-Site") != ""
+}
+
+// pageLoad reports whether r asks for an HTML page: browsers say so in
+// Sec-Fetch-Dest, or at least in Accept, when they navigate
+func pageLoad(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if dest := r.Header.Get("Sec-Fetch-Dest"); dest != "" {
+		return dest == "document"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func newToken() string {
+	b := make([]byte, tokenBytes)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,117 @@
+// internal/security/csrf_test.go
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+func TestCSRFIssue(t *testing.T) {
+	csrf := NewCSRF(false, nil)
+	valid := newToken()
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		cookie  string
+		want    bool
+	}{
+		{"page load", "GET", "/", map[string]string{"Sec-Fetch-Dest": "document", "Accept": "text/html"}, "", true},
+		{"page load without fetch metadata", "GET", "/", map[string]string{"Accept": "text/html,application/xhtml+xml"}, "", true},
+		{"page load with a token", "GET", "/", map[string]string{"Accept": "text/html"}, valid, false},
+		{"page load with a forged token", "GET", "/", map[string]string{"Accept": "text/html"}, "forged", true},
+		{"API call from the UI", "GET", "/api/notes", map[string]string{"Sec-Fetch-Dest": "empty", "Accept": "*/*"}, "", false},
+		{"API call with a bearer token", "GET", "/api/notes", map[string]string{"Authorization": "Bearer secret"}, "", false},
+		{"metrics scrape", "GET", "/metrics", map[string]string{"Accept": "text/plain"}, "", false},
+		{"script", "GET", "/web/app.js", map[string]string{"Sec-Fetch-Dest": "script", "Accept": "*/*"}, "", false},
+		{"form post", "POST", "/", map[string]string{"Accept": "text/html"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: CSRFCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			csrf.Issue(ok).ServeHTTP(w, r)
+
+			var issued *http.Cookie
+			for _, c := range w.Result().Cookies() {
+				if c.Name == CSRFCookie {
+					issued = c
+				}
+			}
+			if (issued != nil) != tt.want {
+				t.Fatalf("issued %v, want a cookie: %v", issued, tt.want)
+			}
+			if issued != nil && (issued.Value == tt.cookie || issued.HttpOnly || issued.SameSite != http.SameSiteStrictMode) {
+				t.Errorf("issued %+v", issued)
+			}
+		})
+	}
+}
+
+func TestCSRFVerify(t *testing.T) {
+	token := newToken()
+
+	tests := []struct {
+		name    string
+		method  string
+		browser bool
+		cookie  string
+		header  string
+		want    int
+	}{
+		{"read", "GET", true, "", "", http.StatusOK},
+		{"write with matching token", "POST", true, token, token, http.StatusOK},
+		{"write without header", "POST", true, token, "", http.StatusForbidden},
+		{"write without cookie", "DELETE", true, "", token, http.StatusForbidden},
+		{"write with other token", "PUT", true, token, newToken(), http.StatusForbidden},
+		{"write with malformed token", "POST", true, "short", "short", http.StatusForbidden},
+		{"write from a non-browser client", "POST", false, "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, secure := range []bool{false, true} {
+				csrf := NewCSRF(secure, func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusForbidden)
+				})
+				r := httptest.NewRequest(tt.method, "/api/notes", nil)
+				if tt.browser {
+					r.Header.Set("Sec-Fetch-Site", "same-origin")
+				}
+				if tt.cookie != "" {
+					r.AddCookie(&http.Cookie{Name: csrf.cookieName(), Value: tt.cookie})
+				}
+				if tt.header != "" {
+					r.Header.Set(CSRFHeader, tt.header)
+				}
+				w := httptest.NewRecorder()
+				csrf.Verify(ok).ServeHTTP(w, r)
+				if w.Code != tt.want {
+					t.Errorf("secure=%v: status %d, want %d", secure, w.Code, tt.want)
+				}
+			}
+		})
+	}
+
+	// The plain cookie does not stand in for the __Host- one over HTTPS
+	csrf := NewCSRF(true, func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusForbidden) })
+	r := httptest.NewRequest("POST", "/api/notes", nil)
+	r.Header.Set("Origin", "https://example.com")
+	r.AddCookie(&http.Cookie{Name: CSRFCookie, Value: token})
+	r.Header.Set(CSRFHeader, token)
+	w := httptest.NewRecorder()
+	csrf.Verify(ok).ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("plain cookie over HTTPS: status %d", w.Code)
+	}
+}
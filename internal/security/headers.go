@@ -0,0 +1,28 @@
+// internal/security/headers.go
+package security
+
+import "net/http"
+
+// permissionsPolicy turns off the browser features gokeep never uses, so
+// injected content cannot ask for them either
+const permissionsPolicy = "accelerometer=(), camera=(), geolocation=(), gyroscope=(), magnetometer=(), microphone=(), payment=(), usb=()"
+
+// Headers sets the security headers of every response: the
+// Content-Security-Policy csp (left out if empty), no framing, no referrer
+// to other sites, no powerful browser features and no content sniffing.
+// Handlers may replace them, e.g. to sandbox attachments.
+func Headers(csp string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			if csp != "" {
+				h.Set("Content-Security-Policy", csp)
+			}
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "same-origin")
+			h.Set("Permissions-Policy", permissionsPolicy)
+			h.Set("X-Content-Type-Options", "nosniff")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
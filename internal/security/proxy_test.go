@@ -0,0 +1,52 @@
+// internal/security/proxy_test.go
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded string
+		realIP    string
+		want      string
+	}{
+		{"direct client", "203.0.113.7:4000", "", "", "203.0.113.7:4000"},
+		{"direct client spoofing X-Forwarded-For", "203.0.113.7:4000", "198.51.100.1", "", "203.0.113.7:4000"},
+		{"direct client spoofing X-Real-IP", "203.0.113.7:4000", "", "198.51.100.1", "203.0.113.7:4000"},
+		{"trusted proxy", "10.0.0.1:4000", "198.51.100.1", "", "198.51.100.1"},
+		{"trusted proxy with X-Real-IP", "10.0.0.1:4000", "", "198.51.100.1", "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.1:4000", "198.51.100.1, 10.0.0.2", "", "198.51.100.1"},
+		{"client prepending an address", "10.0.0.1:4000", "192.0.2.9, 198.51.100.1", "", "198.51.100.1"},
+		{"garbage from the client", "10.0.0.1:4000", "not an address, 198.51.100.1", "", "198.51.100.1"},
+		{"proxy without headers", "10.0.0.1:4000", "", "", "10.0.0.1:4000"},
+		{"IPv6 client", "10.0.0.1:4000", "2001:db8::1", "", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.peer
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			var got string
+			RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			})).ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// internal/tui/draw.go
+package tui
+
+import (
+	"strings"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// Styles shared by the views
+var (
+	styleDefault  = tcell.StyleDefault
+	styleBar      = tcell.StyleDefault.Reverse(true)
+	styleDim      = tcell.StyleDefault.Dim(true)
+	styleSelected = tcell.StyleDefault.Reverse(true)
+	styleError    = tcell.StyleDefault.Foreground(tcell.ColorRed).Bold(true)
+	styleBorder   = tcell.StyleDefault.Foreground(tcell.ColorGray)
+	styleFocus    = tcell.StyleDefault.Foreground(tcell.ColorYellow).Bold(true)
+)
+
+// colorOrder is the order of the color picker, as in the browser
+var colorOrder = []string{"white", "yellow", "orange", "pink", "purple", "blue", "green", "gray"}
+
+// noteColor returns the terminal color of a note's background
+func noteColor(note models.Note) tcell.Color {
+	if note.Color == "" || !models.ValidateColor(note.Color) {
+		return tcell.GetColor(string(models.ColorWhite))
+	}
+	return tcell.GetColor(note.Color)
+}
+
+// colorName returns the name of a note color value
+func colorName(value string) string {
+	for name, color := range models.ColorNames {
+		if string(color) == value {
+			return name
+		}
+	}
+	return "white"
+}
+
+// cardStyle is black text on the note's color, like a card in the browser
+func cardStyle(note models.Note) tcell.Style {
+	return tcell.StyleDefault.Background(noteColor(note)).Foreground(tcell.ColorBlack)
+}
+
+// drawText writes s at x, y, cut to width cells, and returns the cells used
+func drawText(s tcell.Screen, x, y, width int, text string, style tcell.Style) int {
+	used := 0
+	for _, c := range text {
+		if c == '\t' || c == '\n' {
+			c = ' '
+		}
+		w := runewidth.RuneWidth(c)
+		if w == 0 {
+			continue
+		}
+		if used+w > width {
+			break
+		}
+		s.SetContent(x+used, y, c, nil, style)
+		used += w
+	}
+	return used
+}
+
+// drawLine writes a rendered line
+func drawLine(s tcell.Screen, x, y, width int, l line) {
+	used := 0
+	for _, seg := range l {
+		used += drawText(s, x+used, y, width-used, seg.text, seg.style)
+	}
+}
+
+// drawLines writes rendered lines into a box, styling them on top of base
+func drawLines(s tcell.Screen, x, y, width, height int, lines []line, base tcell.Style) {
+	fill(s, x, y, width, height, base)
+	for i := 0; i < height && i < len(lines); i++ {
+		drawLine(s, x, y+i, width, lines[i])
+	}
+}
+
+// fill paints a box
+func fill(s tcell.Screen, x, y, width, height int, style tcell.Style) {
+	for row := y; row < y+height; row++ {
+		for col := x; col < x+width; col++ {
+			s.SetContent(col, row, ' ', nil, style)
+		}
+	}
+}
+
+// drawBox draws a frame around a box, with an optional title
+func drawBox(s tcell.Screen, x, y, width, height int, title string, style tcell.Style) {
+	if width < 2 || height < 2 {
+		return
+	}
+	for col := x + 1; col < x+width-1; col++ {
+		s.SetContent(col, y, '─', nil, style)
+		s.SetContent(col, y+height-1, '─', nil, style)
+	}
+	for row := y + 1; row < y+height-1; row++ {
+		s.SetContent(x, row, '│', nil, style)
+		s.SetContent(x+width-1, row, '│', nil, style)
+	}
+	s.SetContent(x, y, '┌', nil, style)
+	s.SetContent(x+width-1, y, '┐', nil, style)
+	s.SetContent(x, y+height-1, '└', nil, style)
+	s.SetContent(x+width-1, y+height-1, '┘', nil, style)
+	if title != "" {
+		drawText(s, x+2, y, width-4, " "+title+" ", style)
+	}
+}
+
+// noteTitle returns the title of a note, or its first line if it has none
+func noteTitle(note models.Note) string {
+	if title := strings.TrimSpace(note.Title); title != "" {
+		return title
+	}
+	for _, text := range strings.Split(note.Content, "\n") {
+		if text = strings.TrimSpace(text); text != "" {
+			return text
+		}
+	}
+	return "(empty note)"
+}
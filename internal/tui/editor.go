@@ -0,0 +1,185 @@
+// internal/tui/editor.go
+package tui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// textArea is a small editor for a note's title or content. Long lines are
+// wrapped softly; the arrow keys move through the wrapped rows.
+type textArea struct {
+	text       []rune
+	cursor     int
+	scroll     int
+	singleLine bool
+
+	// Size of the last draw, which up, down and paging depend on
+	width, height int
+}
+
+// span is a wrapped row of text[start:end]
+type span struct {
+	start, end int
+}
+
+func newTextArea(s string, singleLine bool) *textArea {
+	text := []rune(s)
+	return &textArea{text: text, cursor: len(text), singleLine: singleLine, width: 80, height: 1}
+}
+
+func (t *textArea) String() string {
+	return string(t.text)
+}
+
+// handleKey edits or moves the cursor and reports whether the key was used
+func (t *textArea) handleKey(ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyRune:
+		t.insert(ev.Rune())
+	case tcell.KeyEnter:
+		if t.singleLine {
+			return false
+		}
+		t.insert('\n')
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if t.cursor > 0 {
+			t.text = append(t.text[:t.cursor-1], t.text[t.cursor:]...)
+			t.cursor--
+		}
+	case tcell.KeyDelete, tcell.KeyCtrlD:
+		if t.cursor < len(t.text) {
+			t.text = append(t.text[:t.cursor], t.text[t.cursor+1:]...)
+		}
+	case tcell.KeyLeft:
+		if t.cursor > 0 {
+			t.cursor--
+		}
+	case tcell.KeyRight:
+		if t.cursor < len(t.text) {
+			t.cursor++
+		}
+	case tcell.KeyUp:
+		if t.singleLine {
+			return false
+		}
+		t.moveRows(-1)
+	case tcell.KeyDown:
+		if t.singleLine {
+			return false
+		}
+		t.moveRows(1)
+	case tcell.KeyPgUp:
+		t.moveRows(-max(t.height-1, 1))
+	case tcell.KeyPgDn:
+		t.moveRows(max(t.height-1, 1))
+	case tcell.KeyHome, tcell.KeyCtrlA:
+		rows := t.rows(t.width)
+		t.cursor = rows[t.cursorRow(rows)].start
+	case tcell.KeyEnd, tcell.KeyCtrlE:
+		rows := t.rows(t.width)
+		t.cursor = rows[t.cursorRow(rows)].end
+	default:
+		return false
+	}
+	return true
+}
+
+func (t *textArea) insert(r rune) {
+	t.text = append(t.text, 0)
+	copy(t.text[t.cursor+1:], t.text[t.cursor:])
+	t.text[t.cursor] = r
+	t.cursor++
+}
+
+// moveRows moves the cursor n wrapped rows down, or up if n is negative,
+// keeping its column where the row is long enough
+func (t *textArea) moveRows(n int) {
+	rows := t.rows(t.width)
+	row := t.cursorRow(rows)
+	column := runewidth.StringWidth(string(t.text[rows[row].start:t.cursor]))
+
+	target := min(max(row+n, 0), len(rows)-1)
+	if target == row {
+		if n < 0 {
+			t.cursor = rows[row].start
+		} else {
+			t.cursor = rows[row].end
+		}
+		return
+	}
+
+	t.cursor = rows[target].start
+	used := 0
+	for t.cursor < rows[target].end {
+		w := runewidth.RuneWidth(t.text[t.cursor])
+		if used+w > column {
+			break
+		}
+		used += w
+		t.cursor++
+	}
+}
+
+// rows wraps the text at newlines and at width cells
+func (t *textArea) rows(width int) []span {
+	if width < 1 {
+		width = 1
+	}
+	var rows []span
+	start, used := 0, 0
+	for i, c := range t.text {
+		if c == '\n' {
+			rows = append(rows, span{start, i})
+			start, used = i+1, 0
+			continue
+		}
+		w := runewidth.RuneWidth(c)
+		if used+w > width && i > start {
+			rows = append(rows, span{start, i})
+			start, used = i, 0
+		}
+		used += w
+	}
+	return append(rows, span{start, len(t.text)})
+}
+
+// cursorRow returns the row the cursor is on. At a soft wrap the cursor
+// belongs to the start of the next row.
+func (t *textArea) cursorRow(rows []span) int {
+	for i := len(rows) - 1; i > 0; i-- {
+		if t.cursor >= rows[i].start {
+			return i
+		}
+	}
+	return 0
+}
+
+// draw renders the visible rows into the given box and places the terminal
+// cursor if focused
+func (t *textArea) draw(s tcell.Screen, x, y, width, height int, style tcell.Style, focused bool) {
+	t.width, t.height = width, height
+	rows := t.rows(width)
+	row := t.cursorRow(rows)
+
+	// Keep the cursor in view
+	if row < t.scroll {
+		t.scroll = row
+	}
+	if row >= t.scroll+height {
+		t.scroll = row - height + 1
+	}
+
+	for i := 0; i < height; i++ {
+		fill(s, x, y+i, width, 1, style)
+		if t.scroll+i < len(rows) {
+			r := rows[t.scroll+i]
+			drawText(s, x, y+i, width, string(t.text[r.start:r.end]), style)
+		}
+	}
+
+	if focused {
+		column := runewidth.StringWidth(string(t.text[rows[row].start:t.cursor]))
+		s.ShowCursor(x+min(column, width-1), y+row-t.scroll)
+	}
+}
@@ -0,0 +1,309 @@
+// internal/tui/preview.go
+package tui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Smil3MoreGH/gokeep/internal/markdown"
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+	"github.com/russross/blackfriday/v2"
+)
+
+// segment is a run of text in one style
+type segment struct {
+	text  string
+	style tcell.Style
+}
+
+// line is one row of rendered text
+type line []segment
+
+// renderMarkdown lays out note content as styled lines of at most width
+// cells, approximating in the terminal what the browser shows
+func renderMarkdown(content string, width int, base tcell.Style) []line {
+	if width < 8 {
+		width = 8
+	}
+	r := &previewRenderer{width: width, base: base}
+	for block := markdown.Parse(content).FirstChild; block != nil; block = block.Next {
+		r.block(block, "", "")
+	}
+	// Drop the blank line after the last block
+	for len(r.lines) > 0 && len(r.lines[len(r.lines)-1]) == 0 {
+		r.lines = r.lines[:len(r.lines)-1]
+	}
+	return r.lines
+}
+
+type previewRenderer struct {
+	width int
+	base  tcell.Style
+	lines []line
+}
+
+// block renders a block node. first prefixes its first line and rest the
+// others, which is how list bullets and quote bars are drawn.
+func (r *previewRenderer) block(node *blackfriday.Node, first, rest string) {
+	switch node.Type {
+	case blackfriday.Heading:
+		style := r.base.Bold(true)
+		if node.Level == 1 {
+			style = style.Underline(true)
+		}
+		r.wrap(r.inline(node, style), first, rest)
+		r.blank()
+
+	case blackfriday.Paragraph:
+		r.wrap(r.inline(node, r.base), first, rest)
+		// Items of tight lists sit directly below each other
+		if item := node.Parent; item == nil || item.Type != blackfriday.Item || item.Parent == nil || !item.Parent.Tight {
+			r.blank()
+		}
+
+	case blackfriday.List:
+		// blackfriday does not keep the start number of ordered lists
+		number := 1
+		for item := node.FirstChild; item != nil; item = item.Next {
+			bullet := "• "
+			if node.ListFlags&blackfriday.ListTypeOrdered != 0 {
+				bullet = strconv.Itoa(number) + ". "
+				number++
+			}
+			r.item(item, first+bullet, rest+strings.Repeat(" ", runewidth.StringWidth(bullet)))
+			first = rest
+		}
+		if node.Parent == nil || node.Parent.Type != blackfriday.Item {
+			r.blank()
+		}
+
+	case blackfriday.BlockQuote:
+		for child := node.FirstChild; child != nil; child = child.Next {
+			start := len(r.lines)
+			r.block(child, first+"│ ", rest+"│ ")
+			first = rest
+			for i := start; i < len(r.lines); i++ {
+				r.lines[i] = dim(r.lines[i])
+			}
+		}
+
+	case blackfriday.CodeBlock:
+		code := r.base.Foreground(tcell.ColorTeal)
+		for _, text := range strings.Split(strings.TrimRight(string(node.Literal), "\n"), "\n") {
+			r.lines = append(r.lines, line{{first + "  ", r.base}, {clip(text, r.width-runewidth.StringWidth(first)-2), code}})
+			first = rest
+		}
+		r.blank()
+
+	case blackfriday.HorizontalRule:
+		r.lines = append(r.lines, line{{first + strings.Repeat("─", r.width-runewidth.StringWidth(first)), r.base.Dim(true)}})
+		r.blank()
+
+	case blackfriday.Table:
+		r.table(node, first, rest)
+		r.blank()
+
+	case blackfriday.HTMLBlock:
+		for _, text := range strings.Split(strings.TrimRight(string(node.Literal), "\n"), "\n") {
+			r.lines = append(r.lines, line{{first + clip(text, r.width-runewidth.StringWidth(first)), r.base.Dim(true)}})
+			first = rest
+		}
+		r.blank()
+	}
+}
+
+// item renders the blocks of a list item, the first of them after the bullet
+func (r *previewRenderer) item(item *blackfriday.Node, first, rest string) {
+	for child := item.FirstChild; child != nil; child = child.Next {
+		r.block(child, first, rest)
+		first = rest
+	}
+}
+
+// table renders each row as its cells separated by bars
+func (r *previewRenderer) table(node *blackfriday.Node, first, rest string) {
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || n.Type != blackfriday.TableRow {
+			return blackfriday.GoToNext
+		}
+		var cells []string
+		header := false
+		for cell := n.FirstChild; cell != nil; cell = cell.Next {
+			cells = append(cells, plainText(cell))
+			header = header || cell.IsHeader
+		}
+		style := r.base
+		if header {
+			style = style.Bold(true)
+		}
+		text := clip(strings.Join(cells, " │ "), r.width-runewidth.StringWidth(first))
+		r.lines = append(r.lines, line{{first, r.base}, {text, style}})
+		first = rest
+		return blackfriday.SkipChildren
+	})
+}
+
+// inline collects the styled text of an inline container
+func (r *previewRenderer) inline(node *blackfriday.Node, style tcell.Style) []segment {
+	var segments []segment
+	for child := node.FirstChild; child != nil; child = child.Next {
+		switch child.Type {
+		case blackfriday.Text, blackfriday.HTMLSpan:
+			segments = append(segments, segment{string(child.Literal), style})
+		case blackfriday.Code:
+			segments = append(segments, segment{string(child.Literal), style.Foreground(tcell.ColorTeal)})
+		case blackfriday.Emph:
+			segments = append(segments, r.inline(child, style.Italic(true))...)
+		case blackfriday.Strong:
+			segments = append(segments, r.inline(child, style.Bold(true))...)
+		case blackfriday.Del:
+			segments = append(segments, r.inline(child, style.StrikeThrough(true))...)
+		case blackfriday.Link:
+			segments = append(segments, r.inline(child, style.Underline(true).Foreground(tcell.ColorBlue))...)
+		case blackfriday.Image:
+			alt := plainText(child)
+			if alt == "" {
+				alt = "image"
+			}
+			segments = append(segments, segment{"[" + alt + "]", style.Dim(true)})
+		case blackfriday.Softbreak:
+			segments = append(segments, segment{" ", style})
+		case blackfriday.Hardbreak:
+			segments = append(segments, segment{"\n", style})
+		default:
+			segments = append(segments, r.inline(child, style)...)
+		}
+	}
+	return segments
+}
+
+// wrap breaks segments into lines at spaces, prefixing them with first and
+// rest
+func (r *previewRenderer) wrap(segments []segment, first, rest string) {
+	prefix := first
+	current := line{{prefix, r.base}}
+	used := runewidth.StringWidth(prefix)
+	pendingSpace := false
+
+	newLine := func() {
+		r.lines = append(r.lines, current)
+		prefix = rest
+		current = line{{prefix, r.base}}
+		used = runewidth.StringWidth(prefix)
+		pendingSpace = false
+	}
+
+	for _, seg := range segments {
+		for _, word := range splitWords(seg.text) {
+			switch word {
+			case "\n":
+				newLine()
+				continue
+			case " ":
+				pendingSpace = used > runewidth.StringWidth(prefix)
+				continue
+			}
+
+			w := runewidth.StringWidth(word)
+			space := 0
+			if pendingSpace {
+				space = 1
+			}
+			if used+space+w > r.width && used > runewidth.StringWidth(prefix) {
+				newLine()
+				space = 0
+			}
+			if space == 1 {
+				current = append(current, segment{" ", seg.style})
+				used++
+			}
+			// Words wider than the line are broken anywhere
+			for used+w > r.width && r.width-used > 0 {
+				head, tail := splitAtWidth(word, r.width-used)
+				current = append(current, segment{head, seg.style})
+				newLine()
+				word, w = tail, runewidth.StringWidth(tail)
+			}
+			current = append(current, segment{word, seg.style})
+			used += w
+			pendingSpace = false
+		}
+	}
+	r.lines = append(r.lines, current)
+}
+
+func (r *previewRenderer) blank() {
+	if len(r.lines) > 0 && len(r.lines[len(r.lines)-1]) > 0 {
+		r.lines = append(r.lines, nil)
+	}
+}
+
+// splitWords splits text into words, single spaces for runs of whitespace
+// and "\n" for explicit line breaks
+func splitWords(text string) []string {
+	var words []string
+	start := -1
+	for i, c := range text {
+		if c == ' ' || c == '\t' || c == '\n' {
+			if start >= 0 {
+				words = append(words, text[start:i])
+				start = -1
+			}
+			if c == '\n' {
+				words = append(words, "\n")
+			} else if len(words) == 0 || words[len(words)-1] != " " {
+				words = append(words, " ")
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// splitAtWidth splits s after at most width cells, but after at least one rune
+func splitAtWidth(s string, width int) (string, string) {
+	used := 0
+	for i, c := range s {
+		w := runewidth.RuneWidth(c)
+		if used+w > width && i > 0 {
+			return s[:i], s[i:]
+		}
+		used += w
+	}
+	return s, ""
+}
+
+// clip cuts s to at most width cells
+func clip(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return runewidth.Truncate(s, width, "…")
+}
+
+// dim fades every segment of a line
+func dim(l line) line {
+	for i := range l {
+		l[i].style = l[i].style.Dim(true)
+	}
+	return l
+}
+
+// plainText returns the text of a node without any formatting
+func plainText(node *blackfriday.Node) string {
+	var b strings.Builder
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && (n.Type == blackfriday.Text || n.Type == blackfriday.Code) {
+			b.Write(n.Literal)
+		}
+		return blackfriday.GoToNext
+	})
+	return b.String()
+}
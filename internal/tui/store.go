@@ -0,0 +1,108 @@
+// internal/tui/store.go
+package tui
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/pkg/client"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// Store is where the terminal UI reads and writes notes
+type Store interface {
+	// Name describes the store in the header, e.g. the database file
+	Name() string
+	Notes(ctx context.Context) ([]models.Note, error)
+	// Search takes an FTS5 query
+	Search(ctx context.Context, query string) ([]models.Note, error)
+	// Save creates a note without an ID and updates one with an ID, and
+	// returns it as stored
+	Save(ctx context.Context, note models.Note) (*models.Note, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// LocalStore works directly on a database
+type LocalStore struct {
+	repo *database.NoteRepository
+	path string
+}
+
+// NewLocalStore creates a store for the database file at path
+func NewLocalStore(repo *database.NoteRepository, path string) *LocalStore {
+	return &LocalStore{repo: repo, path: path}
+}
+
+func (s *LocalStore) Name() string {
+	return s.path
+}
+
+func (s *LocalStore) Notes(ctx context.Context) ([]models.Note, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *LocalStore) Search(ctx context.Context, query string) ([]models.Note, error) {
+	return s.repo.Search(ctx, query)
+}
+
+func (s *LocalStore) Save(ctx context.Context, note models.Note) (*models.Note, error) {
+	var err error
+	if note.ID == 0 {
+		err = s.repo.Create(ctx, &note)
+	} else {
+		err = s.repo.Update(ctx, &note)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, id int64) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// RemoteStore works through the API of a running server
+type RemoteStore struct {
+	api    *client.Client
+	server string
+}
+
+// NewRemoteStore creates a store for the server at the given URL
+func NewRemoteStore(api *client.Client, server string) *RemoteStore {
+	return &RemoteStore{api: api, server: server}
+}
+
+func (s *RemoteStore) Name() string {
+	return s.server
+}
+
+func (s *RemoteStore) Notes(ctx context.Context) ([]models.Note, error) {
+	return s.api.ListNotes(ctx)
+}
+
+func (s *RemoteStore) Search(ctx context.Context, query string) ([]models.Note, error) {
+	return s.api.SearchNotes(ctx, query)
+}
+
+func (s *RemoteStore) Save(ctx context.Context, note models.Note) (*models.Note, error) {
+	if note.ID == 0 {
+		return s.api.CreateNote(ctx, &note)
+	}
+	return s.api.UpdateNote(ctx, &note)
+}
+
+func (s *RemoteStore) Delete(ctx context.Context, id int64) error {
+	return s.api.DeleteNote(ctx, id)
+}
+
+// prefixQuery turns what was typed so far into an FTS5 query that matches
+// words starting with each term, so results show up while typing
+func prefixQuery(typed string) string {
+	terms := strings.Fields(typed)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,617 @@
+// internal/tui/tui.go
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+	"github.com/gdamore/tcell/v2"
+)
+
+// searchDelay lets a few more keystrokes arrive before the store is queried
+const searchDelay = 150 * time.Millisecond
+
+// mode decides what keys do
+type mode int
+
+const (
+	modeBrowse mode = iota
+	modeSearch
+	modeEdit
+	modeColor
+	modeConfirm
+	modeHelp
+)
+
+// view selects which notes are listed
+type view int
+
+const (
+	viewNotes view = iota
+	viewArchive
+	viewTrash
+)
+
+var viewNames = []string{"Notes", "Archive", "Trash"}
+
+// loadedEvent carries the result of loading or searching notes back to the
+// event loop
+type loadedEvent struct {
+	tcell.EventTime
+	seq   int
+	notes []models.Note
+	err   error
+}
+
+// App is the terminal UI. All fields are owned by the event loop in Run.
+type App struct {
+	ctx    context.Context
+	store  Store
+	screen tcell.Screen
+
+	// loaded is the last result from the store, notes the part of it shown
+	// in the current view
+	loaded   []models.Note
+	notes    []models.Note
+	selected int
+	scroll   int
+	grid     bool
+	view     view
+	mode     mode
+
+	query     string
+	loadSeq   int
+	loadTimer *time.Timer
+	loading   bool
+
+	editor      *editState
+	colorIndex  int
+	confirm     func()
+	lastTrashed *models.Note
+
+	status    string
+	statusErr bool
+}
+
+// editState is the note open in the editor
+type editState struct {
+	note    models.Note
+	title   *textArea
+	content *textArea
+	focus   *textArea
+}
+
+// New creates a terminal UI for the notes in store
+func New(store Store) *App {
+	return &App{store: store}
+}
+
+// Run shows the UI on screen until the user quits or ctx is cancelled. A
+// note open in the editor is saved when ctx is cancelled.
+func (a *App) Run(ctx context.Context, screen tcell.Screen) error {
+	if err := screen.Init(); err != nil {
+		return fmt.Errorf("failed to initialise terminal: %w", err)
+	}
+	defer screen.Fini()
+	a.ctx, a.screen = ctx, screen
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			screen.PostEvent(tcell.NewEventInterrupt(nil))
+		case <-done:
+		}
+	}()
+
+	a.load(0)
+	for {
+		a.draw()
+		switch ev := screen.PollEvent().(type) {
+		case nil:
+			return nil
+		case *tcell.EventInterrupt:
+			if ctx.Err() != nil {
+				if a.mode == modeEdit {
+					a.closeEditor(true)
+				}
+				return nil
+			}
+		case *tcell.EventResize:
+			screen.Sync()
+		case *loadedEvent:
+			a.loadedNotes(ev)
+		case *tcell.EventKey:
+			if a.handleKey(ev) {
+				return nil
+			}
+		}
+	}
+}
+
+// load fetches the notes matching the query after delay. Typing restarts
+// the delay, and results of earlier loads are dropped.
+func (a *App) load(delay time.Duration) {
+	if a.loadTimer != nil {
+		a.loadTimer.Stop()
+	}
+	a.loadSeq++
+	a.loading = true
+	seq, query := a.loadSeq, strings.TrimSpace(a.query)
+
+	a.loadTimer = time.AfterFunc(delay, func() {
+		ev := &loadedEvent{seq: seq}
+		if query == "" {
+			ev.notes, ev.err = a.store.Notes(a.ctx)
+		} else {
+			ev.notes, ev.err = a.store.Search(a.ctx, prefixQuery(query))
+		}
+		ev.SetEventNow()
+		a.screen.PostEvent(ev)
+	})
+}
+
+func (a *App) loadedNotes(ev *loadedEvent) {
+	if ev.seq != a.loadSeq {
+		return
+	}
+	a.loading = false
+	if ev.err != nil {
+		a.setError("Could not load notes: %v", ev.err)
+		return
+	}
+	var keep int64
+	if note := a.current(); note != nil {
+		keep = note.ID
+	}
+	a.loaded = ev.notes
+	a.applyView(keep)
+}
+
+// applyView picks the notes of the current view from the loaded ones and
+// selects the note with ID keep if it is still there
+func (a *App) applyView(keep int64) {
+	a.notes = a.notes[:0]
+	for _, note := range a.loaded {
+		var show bool
+		switch a.view {
+		case viewNotes:
+			show = !note.Archived && !note.Trashed
+		case viewArchive:
+			show = note.Archived && !note.Trashed
+		case viewTrash:
+			show = note.Trashed
+		}
+		if show {
+			a.notes = append(a.notes, note)
+		}
+	}
+	// Search results stay in order of relevance
+	if a.query == "" {
+		sort.SliceStable(a.notes, func(i, j int) bool {
+			if a.notes[i].Pinned != a.notes[j].Pinned {
+				return a.notes[i].Pinned
+			}
+			return a.notes[i].UpdatedAt.After(a.notes[j].UpdatedAt)
+		})
+	}
+
+	for i, note := range a.notes {
+		if note.ID == keep {
+			a.selected = i
+			return
+		}
+	}
+	a.selected = min(a.selected, len(a.notes)-1)
+	a.selected = max(a.selected, 0)
+}
+
+// current returns the selected note
+func (a *App) current() *models.Note {
+	if a.selected < 0 || a.selected >= len(a.notes) {
+		return nil
+	}
+	return &a.notes[a.selected]
+}
+
+// save stores a note, shows it in place and reports success in the status
+// line
+func (a *App) save(note models.Note, done string) (*models.Note, bool) {
+	saved, err := a.store.Save(a.ctx, note)
+	if err != nil {
+		a.setError("Could not save note: %v", err)
+		return nil, false
+	}
+
+	found := false
+	for i := range a.loaded {
+		if a.loaded[i].ID == saved.ID {
+			a.loaded[i] = *saved
+			found = true
+		}
+	}
+	if !found {
+		a.loaded = append([]models.Note{*saved}, a.loaded...)
+	}
+	a.applyView(saved.ID)
+	a.setStatus("%s", done)
+	return saved, true
+}
+
+func (a *App) setStatus(format string, args ...any) {
+	a.status, a.statusErr = fmt.Sprintf(format, args...), false
+}
+
+func (a *App) setError(format string, args ...any) {
+	a.status, a.statusErr = fmt.Sprintf(format, args...), true
+}
+
+// handleKey acts on a key press and reports whether to quit
+func (a *App) handleKey(ev *tcell.EventKey) bool {
+	a.status = ""
+
+	switch a.mode {
+	case modeSearch:
+		a.searchKey(ev)
+	case modeEdit:
+		if ev.Key() == tcell.KeyCtrlC {
+			a.closeEditor(true)
+			return true
+		}
+		a.editKey(ev)
+	case modeColor:
+		a.colorKey(ev)
+	case modeConfirm:
+		if ev.Key() == tcell.KeyRune && (ev.Rune() == 'y' || ev.Rune() == 'Y') {
+			a.confirm()
+		} else {
+			a.setStatus("Cancelled")
+		}
+		a.mode, a.confirm = modeBrowse, nil
+	case modeHelp:
+		a.mode = modeBrowse
+	default:
+		return a.browseKey(ev)
+	}
+	return false
+}
+
+// browseKey handles keys while looking at the notes
+func (a *App) browseKey(ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyCtrlC:
+		return true
+	case tcell.KeyUp:
+		a.move(0, -1)
+	case tcell.KeyDown:
+		a.move(0, 1)
+	case tcell.KeyLeft:
+		a.move(-1, 0)
+	case tcell.KeyRight:
+		a.move(1, 0)
+	case tcell.KeyPgUp:
+		a.move(0, -a.pageRows())
+	case tcell.KeyPgDn:
+		a.move(0, a.pageRows())
+	case tcell.KeyHome:
+		a.selected = 0
+	case tcell.KeyEnd:
+		a.selected = max(len(a.notes)-1, 0)
+	case tcell.KeyEnter:
+		a.openEditor()
+	case tcell.KeyTab:
+		a.switchView((a.view + 1) % 3)
+	case tcell.KeyBacktab:
+		a.switchView((a.view + 2) % 3)
+	case tcell.KeyEscape:
+		if a.query != "" {
+			a.query = ""
+			a.load(0)
+		}
+	case tcell.KeyDelete:
+		a.trash()
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'q':
+			return true
+		case 'k':
+			a.move(0, -1)
+		case 'j':
+			a.move(0, 1)
+		case 'h':
+			a.move(-1, 0)
+		case 'l':
+			a.move(1, 0)
+		case 'e':
+			a.openEditor()
+		case 'n':
+			a.newNote()
+		case '/':
+			a.mode = modeSearch
+		case 'c':
+			if note := a.current(); note != nil {
+				a.colorIndex = 0
+				for i, name := range colorOrder {
+					if name == colorName(note.Color) {
+						a.colorIndex = i
+					}
+				}
+				a.mode = modeColor
+			}
+		case 'p':
+			a.toggle(func(n *models.Note) bool { n.Pinned = !n.Pinned; return n.Pinned }, "Pinned", "Unpinned")
+		case 'a':
+			a.toggle(func(n *models.Note) bool { n.Archived = !n.Archived; return n.Archived }, "Archived", "Unarchived")
+		case 'd':
+			a.trash()
+		case 'u':
+			a.restore()
+		case 'v':
+			a.grid = !a.grid
+			a.scroll = 0
+		case 'r':
+			a.load(0)
+		case '?':
+			a.mode = modeHelp
+		case '1', '2', '3':
+			a.switchView(view(ev.Rune() - '1'))
+		}
+	}
+	return false
+}
+
+// move changes the selection by columns and rows of the current layout
+func (a *App) move(dx, dy int) {
+	if len(a.notes) == 0 {
+		return
+	}
+	columns := 1
+	if a.grid {
+		columns = a.gridColumns()
+	} else {
+		dx = 0
+	}
+	a.selected += dx + dy*columns
+	a.selected = min(max(a.selected, 0), len(a.notes)-1)
+}
+
+func (a *App) switchView(v view) {
+	if v == a.view {
+		return
+	}
+	a.view, a.selected, a.scroll = v, 0, 0
+	a.applyView(0)
+}
+
+// toggle flips a flag of the selected note with change and saves it
+func (a *App) toggle(change func(*models.Note) bool, on, off string) {
+	note := a.current()
+	if note == nil {
+		return
+	}
+	updated := *note
+	message := off
+	if change(&updated) {
+		message = on
+	}
+	a.save(updated, message)
+}
+
+// trash moves the selected note to the trash, or deletes it for good after
+// asking if it already is there
+func (a *App) trash() {
+	note := a.current()
+	if note == nil {
+		return
+	}
+	if note.Trashed {
+		id, title := note.ID, noteTitle(*note)
+		a.confirm = func() {
+			if err := a.store.Delete(a.ctx, id); err != nil {
+				a.setError("Could not delete note: %v", err)
+				return
+			}
+			for i := range a.loaded {
+				if a.loaded[i].ID == id {
+					a.loaded = append(a.loaded[:i], a.loaded[i+1:]...)
+					break
+				}
+			}
+			a.applyView(0)
+			a.setStatus("Deleted %q", title)
+		}
+		a.mode = modeConfirm
+		a.setStatus("Delete %q forever? (y/n)", title)
+		return
+	}
+
+	updated := *note
+	updated.Trashed = true
+	if saved, ok := a.save(updated, "Moved to trash (u to undo)"); ok {
+		a.lastTrashed = saved
+	}
+}
+
+// restore takes the selected note out of the trash, or outside the trash
+// the note trashed last
+func (a *App) restore() {
+	var note *models.Note
+	if a.view == viewTrash {
+		note = a.current()
+	} else {
+		note = a.lastTrashed
+	}
+	if note == nil || !note.Trashed {
+		return
+	}
+	updated := *note
+	updated.Trashed = false
+	if _, ok := a.save(updated, "Restored"); ok {
+		a.lastTrashed = nil
+	}
+}
+
+// searchKey edits the query; results follow as you type
+func (a *App) searchKey(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyEnter, tcell.KeyDown, tcell.KeyTab:
+		a.mode = modeBrowse
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		a.mode = modeBrowse
+		if a.query != "" {
+			a.query = ""
+			a.load(0)
+		}
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if a.query != "" {
+			runes := []rune(a.query)
+			a.query = string(runes[:len(runes)-1])
+			a.selected = 0
+			a.load(searchDelay)
+		}
+	case tcell.KeyCtrlU:
+		a.query = ""
+		a.load(0)
+	case tcell.KeyRune:
+		a.query += string(ev.Rune())
+		a.selected = 0
+		a.load(searchDelay)
+	}
+}
+
+// colorKey moves through the color picker
+func (a *App) colorKey(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyUp, tcell.KeyLeft:
+		a.colorIndex = (a.colorIndex + len(colorOrder) - 1) % len(colorOrder)
+	case tcell.KeyDown, tcell.KeyRight, tcell.KeyTab:
+		a.colorIndex = (a.colorIndex + 1) % len(colorOrder)
+	case tcell.KeyEnter:
+		a.applyColor()
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		a.mode = modeBrowse
+	case tcell.KeyRune:
+		switch r := ev.Rune(); {
+		case r == 'k' || r == 'h':
+			a.colorIndex = (a.colorIndex + len(colorOrder) - 1) % len(colorOrder)
+		case r == 'j' || r == 'l':
+			a.colorIndex = (a.colorIndex + 1) % len(colorOrder)
+		case r >= '1' && r <= '8':
+			a.colorIndex = int(r - '1')
+			a.applyColor()
+		case r == 'c' || r == 'q':
+			a.mode = modeBrowse
+		}
+	}
+}
+
+func (a *App) applyColor() {
+	a.mode = modeBrowse
+	note := a.current()
+	if note == nil {
+		return
+	}
+	updated := *note
+	updated.Color = string(models.ColorNames[colorOrder[a.colorIndex]])
+	a.save(updated, "Color: "+colorOrder[a.colorIndex])
+}
+
+func (a *App) newNote() {
+	if a.view != viewNotes {
+		a.switchView(viewNotes)
+	}
+	note := models.Note{}
+	note.SetDefaults()
+	a.edit(note)
+	a.editor.focus = a.editor.title
+}
+
+func (a *App) openEditor() {
+	if note := a.current(); note != nil {
+		a.edit(*note)
+	}
+}
+
+func (a *App) edit(note models.Note) {
+	a.editor = &editState{
+		note:    note,
+		title:   newTextArea(note.Title, true),
+		content: newTextArea(note.Content, false),
+	}
+	a.editor.focus = a.editor.content
+	a.mode = modeEdit
+}
+
+// editKey handles keys in the editor
+func (a *App) editKey(ev *tcell.EventKey) {
+	e := a.editor
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		a.closeEditor(true)
+	case tcell.KeyCtrlS:
+		a.saveEditor()
+	case tcell.KeyCtrlX:
+		a.closeEditor(false)
+		a.setStatus("Changes discarded")
+	case tcell.KeyTab, tcell.KeyBacktab:
+		if e.focus == e.title {
+			e.focus = e.content
+		} else {
+			e.focus = e.title
+		}
+	case tcell.KeyEnter, tcell.KeyDown:
+		if e.focus == e.title {
+			e.focus = e.content
+			return
+		}
+		e.focus.handleKey(ev)
+	case tcell.KeyUp:
+		// Moving up from the first row reaches the title
+		rows := e.content.rows(e.content.width)
+		if e.focus == e.content && e.content.cursorRow(rows) == 0 {
+			e.focus = e.title
+			return
+		}
+		e.focus.handleKey(ev)
+	default:
+		e.focus.handleKey(ev)
+	}
+}
+
+// changed reports whether the editor holds unsaved changes
+func (e *editState) changed() bool {
+	return e.title.String() != e.note.Title || e.content.String() != e.note.Content
+}
+
+// saveEditor stores the note in the editor, which stays open
+func (a *App) saveEditor() bool {
+	e := a.editor
+	if !e.changed() {
+		return true
+	}
+	note := e.note
+	note.Title, note.Content = e.title.String(), e.content.String()
+	if note.ID == 0 && strings.TrimSpace(note.Title+note.Content) == "" {
+		return true
+	}
+	saved, ok := a.save(note, "Saved")
+	if ok {
+		e.note = *saved
+	}
+	return ok
+}
+
+// closeEditor leaves the editor, saving changes first if save is set. It
+// stays open if saving fails.
+func (a *App) closeEditor(save bool) {
+	if save && !a.saveEditor() {
+		return
+	}
+	a.editor = nil
+	a.mode = modeBrowse
+}
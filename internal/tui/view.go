@@ -0,0 +1,399 @@
+// internal/tui/view.go
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// Layout of the grid; cards stretch to fill the width
+const (
+	minCardWidth = 28
+	cardHeight   = 8
+)
+
+// Below this width lists and the editor go without a preview pane
+const previewMinWidth = 72
+
+// helpText is shown by ?
+var helpText = []string{
+	"↑↓←→ hjkl  move",
+	"⏎ e        edit note",
+	"n          new note",
+	"/          search as you type",
+	"Esc        clear search",
+	"c          pick color",
+	"p          pin / unpin",
+	"a          archive / unarchive",
+	"d Del      move to trash, in trash delete",
+	"u          restore from trash",
+	"⇥ 1 2 3    notes, archive, trash",
+	"v          grid or list",
+	"r          reload",
+	"q          quit",
+	"",
+	"In the editor:",
+	"Esc        save and close",
+	"Ctrl+S     save",
+	"Ctrl+X     discard changes",
+	"⇥          title or content",
+}
+
+// draw renders the whole screen
+func (a *App) draw() {
+	s := a.screen
+	s.HideCursor()
+	s.Clear()
+	width, height := s.Size()
+
+	a.drawHeader(width)
+	top := 1
+	if a.mode != modeEdit && (a.query != "" || a.mode == modeSearch) {
+		a.drawSearch(width, top)
+		top++
+	}
+
+	bodyHeight := height - top - 1
+	switch {
+	case a.mode == modeEdit:
+		a.drawEditor(top, width, bodyHeight)
+	case a.grid:
+		a.drawGrid(top, width, bodyHeight)
+	default:
+		a.drawList(top, width, bodyHeight)
+	}
+	a.drawFooter(width, height-1)
+
+	switch a.mode {
+	case modeColor:
+		a.drawColorPicker(width, height)
+	case modeHelp:
+		a.drawHelp(width, height)
+	}
+	s.Show()
+}
+
+func (a *App) drawHeader(width int) {
+	s := a.screen
+	fill(s, 0, 0, width, 1, styleBar)
+	x := drawText(s, 0, 0, width, " gokeep  ", styleBar.Bold(true))
+	for i, name := range viewNames {
+		style := styleBar
+		if view(i) == a.view {
+			style = style.Bold(true).Underline(true)
+		}
+		x += drawText(s, x, 0, width-x, name, style)
+		x += drawText(s, x, 0, width-x, "  ", styleBar)
+	}
+
+	count := fmt.Sprintf("%d notes", len(a.notes))
+	if len(a.notes) == 1 {
+		count = "1 note"
+	}
+	right := count + " · " + a.store.Name() + " "
+	if a.loading {
+		right = "loading… " + right
+	}
+	if w := runewidth.StringWidth(right); w < width-x {
+		drawText(s, width-w, 0, w, right, styleBar)
+	}
+}
+
+func (a *App) drawSearch(width, y int) {
+	x := drawText(a.screen, 0, y, width, " Search: ", styleDim)
+	x += drawText(a.screen, x, y, width-x, a.query, styleDefault.Bold(true))
+	if a.mode == modeSearch {
+		a.screen.ShowCursor(x, y)
+	}
+}
+
+func (a *App) drawFooter(width, y int) {
+	text, style := a.status, styleDim
+	if a.statusErr {
+		style = styleError
+	}
+	if text == "" {
+		switch a.mode {
+		case modeSearch:
+			text = "type to search  ⏎ done  Esc clear"
+		case modeEdit:
+			text = "Esc save & close  Ctrl+S save  Ctrl+X discard  ⇥ title/content"
+		case modeColor:
+			text = "↑↓ choose  ⏎ apply  1-8 pick  Esc cancel"
+		default:
+			text = "n new  ⏎ edit  / search  c color  p pin  a archive  d trash  ? help  q quit"
+		}
+	}
+	drawText(a.screen, 1, y, width-1, text, style)
+}
+
+// emptyText explains an empty view
+func (a *App) emptyText() string {
+	switch {
+	case a.loading && len(a.loaded) == 0:
+		return "Loading…"
+	case a.query != "":
+		return fmt.Sprintf("Nothing found for %q", a.query)
+	case a.view == viewArchive:
+		return "No archived notes"
+	case a.view == viewTrash:
+		return "Trash is empty"
+	}
+	return "No notes yet. Press n to write one."
+}
+
+// drawList shows two rows per note and the selected note beside them
+func (a *App) drawList(top, width, height int) {
+	listWidth := width
+	if width >= previewMinWidth {
+		listWidth = min(max(width/3, 30), 48)
+	}
+
+	if len(a.notes) == 0 {
+		drawText(a.screen, 2, top+1, listWidth-2, a.emptyText(), styleDim)
+	}
+
+	visible := max(height/2, 1)
+	a.scroll = keepVisible(a.scroll, a.selected, visible)
+	for i := 0; i < visible && a.scroll+i < len(a.notes); i++ {
+		index := a.scroll + i
+		a.drawListEntry(a.notes[index], top+2*i, listWidth, index == a.selected)
+	}
+
+	if listWidth == width {
+		return
+	}
+	for y := top; y < top+height; y++ {
+		a.screen.SetContent(listWidth, y, '│', nil, styleBorder)
+	}
+	if note := a.current(); note != nil {
+		a.drawNote(*note, listWidth+2, top, width-listWidth-3, height)
+	}
+}
+
+func (a *App) drawListEntry(note models.Note, y, width int, selected bool) {
+	s := a.screen
+	style := styleDefault
+	if selected {
+		style = styleSelected
+	}
+	fill(s, 0, y, width, 2, style)
+
+	swatch := styleDefault.Foreground(noteColor(note))
+	s.SetContent(0, y, '▌', nil, swatch)
+	s.SetContent(0, y+1, '▌', nil, swatch)
+
+	x := 2
+	if note.Pinned {
+		x += drawText(s, x, y, width-x, "★ ", style)
+	}
+	drawText(s, x, y, width-x-1, noteTitle(note), style.Bold(true))
+	drawText(s, 2, y+1, width-3, snippet(note), style.Dim(!selected))
+}
+
+// drawNote shows a whole note with its content rendered
+func (a *App) drawNote(note models.Note, x, y, width, height int) {
+	s := a.screen
+	lines := renderMarkdown("# "+escapeTitle(noteTitle(note)), width, styleDefault)
+
+	var info []string
+	if note.Pinned {
+		info = append(info, "pinned")
+	}
+	if note.Archived {
+		info = append(info, "archived")
+	}
+	if note.Trashed {
+		info = append(info, "in trash")
+	}
+	info = append(info, colorName(note.Color), "edited "+note.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	for _, label := range note.Labels {
+		info = append(info, "#"+label)
+	}
+	lines = append(lines, line{{strings.Join(info, " · "), styleDim}}, nil)
+	lines = append(lines, renderMarkdown(note.Content, width, styleDefault)...)
+
+	drawLines(s, x, y, width, height, lines, styleDefault)
+	s.SetContent(x-2, y, '▌', nil, styleDefault.Foreground(noteColor(note)))
+}
+
+// drawGrid shows the notes as cards in their colors
+func (a *App) drawGrid(top, width, height int) {
+	if len(a.notes) == 0 {
+		drawText(a.screen, 2, top+1, width-2, a.emptyText(), styleDim)
+		return
+	}
+
+	columns := a.gridColumns()
+	cardWidth := width / columns
+	visible := max(height/cardHeight, 1)
+	a.scroll = keepVisible(a.scroll, a.selected/columns, visible)
+
+	for row := 0; row < visible; row++ {
+		for column := 0; column < columns; column++ {
+			index := (a.scroll+row)*columns + column
+			if index >= len(a.notes) {
+				return
+			}
+			a.drawCard(a.notes[index], column*cardWidth, top+row*cardHeight, cardWidth, index == a.selected)
+		}
+	}
+}
+
+func (a *App) drawCard(note models.Note, x, y, width int, selected bool) {
+	s := a.screen
+	border := styleBorder
+	if selected {
+		border = styleFocus
+	}
+	drawBox(s, x, y, width, cardHeight, "", border)
+
+	style := cardStyle(note)
+	innerX, innerWidth := x+1, width-2
+	fill(s, innerX, y+1, innerWidth, cardHeight-2, style)
+
+	title := noteTitle(note)
+	if note.Pinned {
+		title = "★ " + title
+	}
+	drawText(s, innerX+1, y+1, innerWidth-2, title, style.Bold(true))
+
+	body := renderMarkdown(note.Content, innerWidth-2, style)
+	for i := 0; i < cardHeight-4 && i < len(body); i++ {
+		drawLine(s, innerX+1, y+2+i, innerWidth-2, body[i])
+	}
+
+	if len(note.Labels) > 0 {
+		labels := "#" + strings.Join(note.Labels, " #")
+		drawText(s, innerX+1, y+cardHeight-2, innerWidth-2, labels, style.Italic(true))
+	}
+}
+
+func (a *App) gridColumns() int {
+	width, _ := a.screen.Size()
+	return max(width/minCardWidth, 1)
+}
+
+// pageRows is how many rows PgUp and PgDn move
+func (a *App) pageRows() int {
+	_, height := a.screen.Size()
+	if a.grid {
+		return max((height-3)/cardHeight, 1)
+	}
+	return max((height-3)/2, 1)
+}
+
+// drawEditor shows the title and content being edited and, if there is
+// room, a live preview
+func (a *App) drawEditor(top, width, height int) {
+	s := a.screen
+	e := a.editor
+	editWidth := width
+	if width >= previewMinWidth {
+		editWidth = width / 2
+	}
+
+	title := "Edit"
+	if e.note.ID == 0 {
+		title = "New note"
+	}
+	if e.changed() {
+		title += " *"
+	}
+	drawBox(s, 0, top, editWidth, height, title, styleBorder)
+	s.SetContent(editWidth-3, top, '█', nil, styleDefault.Foreground(noteColor(e.note)))
+
+	inner := editWidth - 4
+	titleStyle := styleDefault.Bold(true)
+	if e.title.String() == "" && e.focus != e.title {
+		drawText(s, 2, top+1, inner, "Title", styleDim)
+	} else {
+		e.title.draw(s, 2, top+1, inner, 1, titleStyle, e.focus == e.title)
+	}
+	for x := 1; x < editWidth-1; x++ {
+		s.SetContent(x, top+2, '─', nil, styleBorder)
+	}
+	e.content.draw(s, 2, top+3, inner, max(height-4, 1), styleDefault, e.focus == e.content)
+
+	if editWidth == width {
+		return
+	}
+	previewWidth := width - editWidth
+	drawBox(s, editWidth, top, previewWidth, height, "Preview", styleBorder)
+	lines := renderMarkdown("# "+escapeTitle(e.title.String()), previewWidth-4, styleDefault)
+	if e.title.String() == "" {
+		lines = nil
+	}
+	lines = append(lines, renderMarkdown(e.content.String(), previewWidth-4, styleDefault)...)
+	drawLines(s, editWidth+2, top+1, previewWidth-4, height-2, lines, styleDefault)
+}
+
+func (a *App) drawColorPicker(width, height int) {
+	s := a.screen
+	boxWidth, boxHeight := 20, len(colorOrder)+2
+	x, y := (width-boxWidth)/2, (height-boxHeight)/2
+	fill(s, x, y, boxWidth, boxHeight, styleDefault)
+	drawBox(s, x, y, boxWidth, boxHeight, "Color", styleFocus)
+
+	for i, name := range colorOrder {
+		style := styleDefault
+		if i == a.colorIndex {
+			style = styleSelected
+		}
+		fill(s, x+1, y+1+i, boxWidth-2, 1, style)
+		swatch := styleDefault.Background(tcell.GetColor(string(models.ColorNames[name])))
+		drawText(s, x+2, y+1+i, 3, "   ", swatch)
+		drawText(s, x+6, y+1+i, boxWidth-8, fmt.Sprintf("%d %s", i+1, name), style)
+	}
+}
+
+func (a *App) drawHelp(width, height int) {
+	s := a.screen
+	boxWidth, boxHeight := 48, len(helpText)+2
+	x, y := max((width-boxWidth)/2, 0), max((height-boxHeight)/2, 0)
+	fill(s, x, y, boxWidth, boxHeight, styleDefault)
+	drawBox(s, x, y, boxWidth, boxHeight, "Keys", styleFocus)
+	for i, text := range helpText {
+		drawText(s, x+2, y+1+i, boxWidth-4, text, styleDefault)
+	}
+}
+
+// keepVisible returns the first row to show so that row stays in view
+func keepVisible(scroll, row, visible int) int {
+	if row < scroll {
+		return row
+	}
+	if row >= scroll+visible {
+		return row - visible + 1
+	}
+	return scroll
+}
+
+// snippet returns the first line of content that is not the title
+func snippet(note models.Note) string {
+	title := noteTitle(note)
+	for _, text := range strings.Split(note.Content, "\n") {
+		text = strings.TrimSpace(strings.TrimLeft(text, "#>*-+ "))
+		if text != "" && text != title {
+			return text
+		}
+	}
+	return ""
+}
+
+// escapeTitle keeps Markdown in a title from being interpreted when it is
+// rendered as a heading
+func escapeTitle(title string) string {
+	var b strings.Builder
+	for _, c := range title {
+		if strings.ContainsRune("\\`*_[]<>#!~|", c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
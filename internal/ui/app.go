@@ -2,13 +2,11 @@
 package ui
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"net/http"
+	"errors"
+	"sort"
 
-	"github.com/Smil3MoreGH/gokeep/internal/models"
 	"github.com/Smil3MoreGH/gokeep/internal/ui/components"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
 )
 
@@ -22,10 +20,32 @@ type App struct {
 	editingNoteID int64
 	newNote       models.Note
 	showNewNote   bool
+
+	// Fields of the new or the edited note that failed validation
+	newNoteErrors models.FieldErrors
+	editErrors    models.FieldErrors
+
+	eventSource    app.Value
+	eventListeners []app.Func
+
+	online                bool
+	syncing               bool
+	mutations             []mutation
+	conflicts             int
+	reconnectScheduled    bool
+	connectivityListeners []app.Func
 }
 
 func (a *App) OnMount(ctx app.Context) {
+	a.restoreOfflineState(ctx)
+	a.replayMutations(ctx)
 	a.loadNotes(ctx)
+	a.startEventStream(ctx)
+}
+
+func (a *App) OnDismount() {
+	a.stopEventStream()
+	a.stopConnectivityTracking()
 }
 
 func (a *App) Render() app.UI {
@@ -63,6 +83,7 @@ func (a *App) renderHeader() app.UI {
 	return app.Header().Class("app-header").Body(
 		app.Div().Class("header-content").Body(
 			app.H1().Class("app-title").Text("Gokeep"),
+			a.renderSyncStatus(),
 			app.Div().Class("search-container").Body(
 				app.Input().
 					Type("search").
@@ -88,17 +109,19 @@ func (a *App) renderNewNote() app.UI {
 		app.Div().Class("note-card new-note").Body(
 			app.Input().
 				Type("text").
-				Class("note-title-input").
+				Class("note-title-input", components.InvalidClass(a.newNoteErrors.For("title"))).
 				Placeholder("Title").
 				Value(a.newNote.Title).
 				OnInput(a.onNewNoteTitleInput).
 				AutoFocus(true),
+			components.FieldError(a.newNoteErrors.For("title")),
 			app.Textarea().
-				Class("note-content-input").
+				Class("note-content-input", components.InvalidClass(a.newNoteErrors.For("content"))).
 				Placeholder("Take a note...").
 				Rows(3).
 				Text(a.newNote.Content).
 				On("input", a.onNewNoteContentInput),
+			components.FieldError(a.newNoteErrors.For("content")),
 			app.Div().Class("note-actions").Body(
 				app.Button().
 					Class("btn btn-primary").
@@ -120,7 +143,7 @@ func (a *App) renderNotesGrid() app.UI {
 		app.Range(filteredNotes).Slice(func(i int) app.UI {
 			note := filteredNotes[i]
 			// v10: Direkt als *components.NoteCard – ist korrekt
-			return &components.NoteCard{
+			card := &components.NoteCard{
 				Note:      note,
 				IsEditing: note.ID == a.editingNoteID,
 				OnEdit:    a.onEditNote,
@@ -128,6 +151,10 @@ func (a *App) renderNotesGrid() app.UI {
 				OnSave:    a.onSaveNote,
 				OnCancel:  a.onCancelEdit,
 			}
+			if card.IsEditing {
+				card.Errors = a.editErrors
+			}
+			return card
 		}),
 	)
 }
@@ -157,19 +184,30 @@ func (a *App) onSearchInput(ctx app.Context, e app.Event) {
 func (a *App) onNewNoteClick(ctx app.Context, e app.Event) {
 	a.showNewNote = true
 	a.newNote = models.Note{}
+	a.newNoteErrors = nil
 	ctx.Update()
 }
 
 func (a *App) onNewNoteTitleInput(ctx app.Context, e app.Event) {
 	a.newNote.Title = ctx.JSSrc().Get("value").String()
+	a.revalidateNewNote()
 	ctx.Update()
 }
 
 func (a *App) onNewNoteContentInput(ctx app.Context, e app.Event) {
 	a.newNote.Content = ctx.JSSrc().Get("value").String()
+	a.revalidateNewNote()
 	ctx.Update()
 }
 
+// revalidateNewNote clears the errors shown for the new note as soon as
+// the input is fixed
+func (a *App) revalidateNewNote() {
+	if a.newNoteErrors != nil {
+		a.newNoteErrors = fieldErrors(a.newNote.Validate())
+	}
+}
+
 func (a *App) onSaveNewNote(ctx app.Context, e app.Event) {
 	a.createNote(ctx)
 }
@@ -182,6 +220,7 @@ func (a *App) onCancelNewNote(ctx app.Context, e app.Event) {
 
 func (a *App) onEditNote(ctx app.Context, noteID int64) {
 	a.editingNoteID = noteID
+	a.editErrors = nil
 	ctx.Update()
 }
 
@@ -195,6 +234,7 @@ func (a *App) onSaveNote(ctx app.Context, note models.Note) {
 
 func (a *App) onCancelEdit(ctx app.Context) {
 	a.editingNoteID = 0
+	a.editErrors = nil
 	ctx.Update()
 }
 
@@ -205,156 +245,172 @@ func (a *App) onCloseError(ctx app.Context, e app.Event) {
 
 // Helper Methods
 
+// getFilteredNotes returns the notes shown in the grid: archived and trashed
+// notes are hidden and pinned notes come first
 func (a *App) getFilteredNotes() []models.Note {
-	if a.searchTerm == "" {
-		return a.notes
-	}
-
-	filtered := make([]models.Note, 0)
+	filtered := make([]models.Note, 0, len(a.notes))
 	for _, note := range a.notes {
-		if contains(note.Title, a.searchTerm) || contains(note.Content, a.searchTerm) {
+		if note.Archived || note.Trashed {
+			continue
+		}
+		if a.searchTerm == "" || contains(note.Title, a.searchTerm) || contains(note.Content, a.searchTerm) {
 			filtered = append(filtered, note)
 		}
 	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Pinned && !filtered[j].Pinned
+	})
 	return filtered
 }
 
 // API Methods
 
 func (a *App) loadNotes(ctx app.Context) {
-	a.isLoading = true
+	// Cached notes are shown right away and stay visible while offline
+	a.isLoading = len(a.notes) == 0
 	ctx.Update()
 
 	go func() {
-		resp, err := http.Get("/api/notes")
-		if err != nil {
-			a.error = err
-			a.isLoading = false
-			ctx.Update()
-			return
-		}
-		defer resp.Body.Close()
+		notes, err := fetchNotes(ctx)
 
-		var notes []models.Note
-		if err := json.NewDecoder(resp.Body).Decode(&notes); err != nil {
-			a.error = err
+		ctx.Dispatch(func(ctx app.Context) {
 			a.isLoading = false
+			switch {
+			case errors.Is(err, errServerUnavailable):
+				a.markOffline(ctx)
+			case err != nil:
+				a.error = err
+			default:
+				a.notes = a.withPendingMutations(notes)
+				a.saveOfflineState(ctx)
+			}
 			ctx.Update()
-			return
-		}
-
-		a.notes = notes
-		a.isLoading = false
-		ctx.Update()
+		})
 	}()
 }
 
 func (a *App) createNote(ctx app.Context) {
-	noteJSON, err := json.Marshal(a.newNote)
-	if err != nil {
-		a.error = err
+	note := a.newNote
+
+	// Invalid notes are shown with their errors instead of being sent, or
+	// queued to fail later
+	if fields := fieldErrors(note.Validate()); fields != nil {
+		a.newNoteErrors = fields
 		ctx.Update()
 		return
 	}
 
-	go func() {
-		resp, err := http.Post("/api/notes", "application/json", bytes.NewReader(noteJSON))
-		if err != nil {
-			a.error = err
-			ctx.Update()
-			return
-		}
-		defer resp.Body.Close()
+	if !a.online {
+		a.queueMutation(ctx, mutation{Kind: mutationCreate, Note: note})
+		a.closeNewNote(ctx)
+		return
+	}
 
-		var createdNote models.Note
-		if err := json.NewDecoder(resp.Body).Decode(&createdNote); err != nil {
-			a.error = err
-			ctx.Update()
-			return
-		}
+	go func() {
+		createdNote, err := postNote(ctx, note)
 
-		a.notes = append([]models.Note{createdNote}, a.notes...)
-		a.showNewNote = false
-		a.newNote = models.Note{}
-		ctx.Update()
+		ctx.Dispatch(func(ctx app.Context) {
+			switch {
+			case errors.Is(err, errServerUnavailable):
+				a.markOffline(ctx)
+				a.queueMutation(ctx, mutation{Kind: mutationCreate, Note: note})
+			case fieldErrors(err) != nil:
+				a.newNoteErrors = fieldErrors(err)
+				ctx.Update()
+				return
+			case err != nil:
+				a.error = err
+				ctx.Update()
+				return
+			default:
+				// The event stream may already have delivered this note
+				a.upsertNote(*createdNote)
+				a.saveOfflineState(ctx)
+			}
+			a.closeNewNote(ctx)
+		})
 	}()
 }
 
+func (a *App) closeNewNote(ctx app.Context) {
+	a.showNewNote = false
+	a.newNote = models.Note{}
+	a.newNoteErrors = nil
+	ctx.Update()
+}
+
 func (a *App) updateNote(ctx app.Context, note models.Note) {
-	noteJSON, err := json.Marshal(note)
-	if err != nil {
-		a.error = err
-		ctx.Update()
+	// The note still carries the server version the edit started from
+	queued := mutation{Kind: mutationUpdate, Note: note, BaseUpdatedAt: note.UpdatedAt}
+
+	if !a.online || note.ID < 0 {
+		a.editingNoteID = 0
+		a.queueMutation(ctx, queued)
 		return
 	}
 
+	// The editor stays open until the server accepted the note, so that
+	// the input is still there to fix if it did not
 	go func() {
-		req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/api/notes/%d", note.ID), bytes.NewReader(noteJSON))
-		if err != nil {
-			a.error = err
-			a.editingNoteID = 0
-			ctx.Dispatch(func(ctx app.Context) {
-				ctx.Update()
-			})
-			return
-		}
-		req.Header.Set("Content-Type", "application/json")
+		updatedNote, err := putNote(ctx, note)
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			a.error = err
-			a.editingNoteID = 0
-			ctx.Dispatch(func(ctx app.Context) {
+		ctx.Dispatch(func(ctx app.Context) {
+			editing := a.editingNoteID == note.ID
+			if fields := fieldErrors(err); fields != nil && editing {
+				a.editErrors = fields
 				ctx.Update()
-			})
-			return
-		}
-		defer resp.Body.Close()
+				return
+			}
+			if editing {
+				a.editingNoteID = 0
+				a.editErrors = nil
+			}
 
-		// Update local state
-		for i, n := range a.notes {
-			if n.ID == note.ID {
-				a.notes[i] = note
-				break
+			switch {
+			case errors.Is(err, errServerUnavailable):
+				a.markOffline(ctx)
+				a.queueMutation(ctx, queued)
+				return
+			case err != nil:
+				a.error = err
+			default:
+				a.upsertNote(*updatedNote)
+				a.saveOfflineState(ctx)
 			}
-		}
-		a.editingNoteID = 0
-		ctx.Dispatch(func(ctx app.Context) {
 			ctx.Update()
 		})
 	}()
 }
 
 func (a *App) deleteNote(ctx app.Context, noteID int64) {
-	go func() {
-		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/api/notes/%d", noteID), nil)
-		if err != nil {
-			a.error = err
-			ctx.Dispatch(func(ctx app.Context) {
-				ctx.Update()
-			})
-			return
+	queued := mutation{Kind: mutationDelete, Note: models.Note{ID: noteID}}
+	for _, n := range a.notes {
+		if n.ID == noteID {
+			queued.BaseUpdatedAt = n.UpdatedAt
 		}
+	}
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			a.error = err
-			ctx.Dispatch(func(ctx app.Context) {
-				ctx.Update()
-			})
-			return
-		}
-		defer resp.Body.Close()
+	if !a.online || noteID < 0 {
+		a.queueMutation(ctx, queued)
+		return
+	}
+
+	go func() {
+		err := deleteNoteRequest(ctx, noteID)
 
-		// Remove from local state
-		filtered := make([]models.Note, 0)
-		for _, note := range a.notes {
-			if note.ID != noteID {
-				filtered = append(filtered, note)
-			}
-		}
-		a.notes = filtered
 		ctx.Dispatch(func(ctx app.Context) {
+			switch {
+			case errors.Is(err, errServerUnavailable):
+				a.markOffline(ctx)
+				a.queueMutation(ctx, queued)
+				return
+			case err != nil:
+				a.error = err
+			default:
+				a.removeNote(noteID)
+				a.saveOfflineState(ctx)
+			}
 			ctx.Update()
 		})
 	}()
@@ -0,0 +1,335 @@
+// internal/ui/components/collab.go
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/collab"
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+)
+
+// collabSession is the browser side of a collaborative editing session.
+// The socket delivers collab.Message values; collab.Client does the OT.
+type collabSession struct {
+	socket    app.Value
+	listeners []app.Func
+	client    *collab.Client
+	clientID  int
+	peers     map[int]collab.Peer
+	connected bool
+}
+
+// startCollab opens the editing WebSocket for the note being edited. Notes
+// created offline (negative IDs) are edited locally only.
+func (c *NoteCard) startCollab(ctx app.Context) {
+	if c.collab != nil || c.Note.ID <= 0 {
+		return
+	}
+	constructor := app.Window().Get("WebSocket")
+	if !constructor.Truthy() {
+		return
+	}
+
+	location := app.Window().Get("location")
+	scheme := "ws"
+	if location.Get("protocol").String() == "https:" {
+		scheme = "wss"
+	}
+	endpoint := url.URL{
+		Scheme: scheme,
+		Host:   location.Get("host").String(),
+		Path:   fmt.Sprintf("/api/notes/%d/collab", c.Note.ID),
+	}
+
+	session := &collabSession{
+		socket: constructor.New(endpoint.String()),
+		peers:  make(map[int]collab.Peer),
+	}
+	c.collab = session
+
+	onMessage := app.FuncOf(func(this app.Value, args []app.Value) any {
+		var msg collab.Message
+		if err := json.Unmarshal([]byte(args[0].Get("data").String()), &msg); err != nil {
+			return nil
+		}
+		ctx.Dispatch(func(ctx app.Context) {
+			if c.collab == session {
+				c.onCollabMessage(ctx, msg)
+			}
+		})
+		return nil
+	})
+	onClose := app.FuncOf(func(this app.Value, args []app.Value) any {
+		ctx.Dispatch(func(ctx app.Context) {
+			if c.collab == session {
+				// Keep editing locally; Save still persists the whole note
+				session.connected = false
+				session.peers = make(map[int]collab.Peer)
+			}
+		})
+		return nil
+	})
+	session.socket.Call("addEventListener", "message", onMessage)
+	session.socket.Call("addEventListener", "close", onClose)
+	session.listeners = []app.Func{onMessage, onClose}
+}
+
+// stopCollab leaves the editing session
+func (c *NoteCard) stopCollab() {
+	session := c.collab
+	if session == nil {
+		return
+	}
+	c.collab = nil
+
+	session.socket.Call("close")
+	for _, listener := range session.listeners {
+		listener.Release()
+	}
+}
+
+// onCollabMessage applies a message from the server to the local editor
+func (c *NoteCard) onCollabMessage(ctx app.Context, msg collab.Message) {
+	session := c.collab
+
+	switch msg.Type {
+	case collab.MsgInit:
+		session.clientID = msg.ClientID
+		session.client = collab.NewClient(msg.Revision, msg.Content, func(rev int, op *collab.Operation) {
+			c.sendCollab(collab.Message{Type: collab.MsgOp, Revision: rev, Op: op})
+		})
+		session.connected = true
+		for _, peer := range msg.Peers {
+			session.peers[peer.ClientID] = peer
+		}
+		c.setEditorContent(msg.Content, nil)
+
+	case collab.MsgOp:
+		if session.client == nil || msg.Op == nil {
+			return
+		}
+		op, err := session.client.ApplyRemote(msg.Op)
+		if err != nil {
+			c.resyncCollab(ctx)
+			return
+		}
+		for id, peer := range session.peers {
+			if peer.Cursor != nil {
+				moved := peer.Cursor.Transform(op, id == msg.ClientID)
+				peer.Cursor = &moved
+				session.peers[id] = peer
+			}
+		}
+		c.setEditorContent(session.client.Doc, op)
+
+	case collab.MsgAck:
+		if session.client != nil && session.client.Ack() != nil {
+			c.resyncCollab(ctx)
+			return
+		}
+
+	case collab.MsgCursor:
+		if peer, ok := session.peers[msg.ClientID]; ok {
+			peer.Cursor = msg.Cursor
+			session.peers[msg.ClientID] = peer
+		}
+
+	case collab.MsgJoin:
+		if msg.Peer != nil {
+			session.peers[msg.ClientID] = *msg.Peer
+		}
+
+	case collab.MsgLeave:
+		delete(session.peers, msg.ClientID)
+
+	case collab.MsgError:
+		c.resyncCollab(ctx)
+		return
+
+	case collab.MsgConflict:
+		// Keep editing the local text alone, as after a lost connection;
+		// saving the note decides which version stays
+		session.connected = false
+		session.peers = make(map[int]collab.Peer)
+		session.socket.Call("close")
+		c.conflict = "This note was changed elsewhere while you edited it. Saving will replace those changes."
+	}
+
+	ctx.Update()
+}
+
+// resyncCollab reconnects to fetch the authoritative document again
+func (c *NoteCard) resyncCollab(ctx app.Context) {
+	c.stopCollab()
+	c.startCollab(ctx)
+}
+
+// collabInput turns a textarea change into an operation for the server
+func (c *NoteCard) collabInput(value string) {
+	session := c.collab
+	if session == nil || session.client == nil || !session.connected {
+		return
+	}
+	op := collab.Diff(session.client.Doc, value)
+	if op.IsNoop() {
+		return
+	}
+	if err := session.client.ApplyLocal(op); err != nil {
+		return
+	}
+	c.sendCursor()
+}
+
+// sendCursor reports the local selection to the other participants
+func (c *NoteCard) sendCursor() {
+	session := c.collab
+	textarea := c.contentElement()
+	if session == nil || session.client == nil || !textarea.Truthy() {
+		return
+	}
+	value := textarea.Get("value").String()
+	c.sendCollab(collab.Message{
+		Type:     collab.MsgCursor,
+		Revision: session.client.Revision,
+		Cursor: &collab.Cursor{
+			Anchor: utf16ToRunes(value, textarea.Get("selectionStart").Int()),
+			Head:   utf16ToRunes(value, textarea.Get("selectionEnd").Int()),
+		},
+	})
+}
+
+// sendCollab writes msg to the socket if it is open
+func (c *NoteCard) sendCollab(msg collab.Message) {
+	session := c.collab
+	if session == nil || session.socket.Get("readyState").Int() != 1 {
+		return
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	session.socket.Call("send", string(data))
+}
+
+// setEditorContent replaces the textarea content, moving the local selection
+// across op so the caret stays where the user left it
+func (c *NoteCard) setEditorContent(content string, op *collab.Operation) {
+	c.editContent = content
+
+	textarea := c.contentElement()
+	if !textarea.Truthy() {
+		return
+	}
+	old := textarea.Get("value").String()
+	start := utf16ToRunes(old, textarea.Get("selectionStart").Int())
+	end := utf16ToRunes(old, textarea.Get("selectionEnd").Int())
+	if op != nil {
+		start = collab.TransformCursor(start, op, false)
+		end = collab.TransformCursor(end, op, false)
+	}
+
+	textarea.Set("value", content)
+	textarea.Call("setSelectionRange", runesToUTF16(content, start), runesToUTF16(content, end))
+}
+
+// contentElement returns the textarea of the note being edited
+func (c *NoteCard) contentElement() app.Value {
+	return app.Window().GetElementByID(c.contentInputID())
+}
+
+func (c *NoteCard) contentInputID() string {
+	return fmt.Sprintf("note-content-%d", c.Note.ID)
+}
+
+// renderPresence lists everyone else editing the note
+func (c *NoteCard) renderPresence() app.UI {
+	session := c.collab
+	if session == nil || !session.connected || len(session.peers) == 0 {
+		return nil
+	}
+
+	peers := session.sortedPeers()
+	return app.Div().Class("collab-presence").Body(
+		app.Range(peers).Slice(func(i int) app.UI {
+			return app.Span().
+				Class("collab-peer").
+				Style("background-color", peers[i].Color).
+				Text(peers[i].Name)
+		}),
+	)
+}
+
+// renderRemoteCursors mirrors the textarea text with a marker at every
+// remote caret; it is laid over the textarea with transparent text
+func (c *NoteCard) renderRemoteCursors() app.UI {
+	session := c.collab
+	if session == nil || !session.connected {
+		return nil
+	}
+
+	var cursors []collab.Peer
+	for _, peer := range session.sortedPeers() {
+		if peer.Cursor != nil {
+			cursors = append(cursors, peer)
+		}
+	}
+	if len(cursors) == 0 {
+		return nil
+	}
+	sort.SliceStable(cursors, func(i, j int) bool {
+		return cursors[i].Cursor.Head < cursors[j].Cursor.Head
+	})
+
+	runes := []rune(c.editContent)
+	parts := make([]app.UI, 0, 2*len(cursors)+1)
+	pos := 0
+	for _, peer := range cursors {
+		head := min(max(peer.Cursor.Head, pos), len(runes))
+		parts = append(parts,
+			app.Text(string(runes[pos:head])),
+			app.Span().
+				Class("remote-cursor").
+				Style("--cursor-color", peer.Color).
+				DataSet("name", peer.Name),
+		)
+		pos = head
+	}
+	parts = append(parts, app.Text(string(runes[pos:])))
+
+	return app.Div().Class("remote-cursors").Aria("hidden", true).Body(parts...)
+}
+
+// sortedPeers returns the peers ordered by client ID for stable rendering
+func (s *collabSession) sortedPeers() []collab.Peer {
+	peers := make([]collab.Peer, 0, len(s.peers))
+	for _, peer := range s.peers {
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].ClientID < peers[j].ClientID
+	})
+	return peers
+}
+
+// utf16ToRunes converts a JavaScript string offset into a rune offset
+func utf16ToRunes(s string, offset int) int {
+	units := 0
+	for i, r := range []rune(s) {
+		if units >= offset {
+			return i
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return len([]rune(s))
+}
+
+// runesToUTF16 converts a rune offset into a JavaScript string offset
+func runesToUTF16(s string, offset int) int {
+	runes := []rune(s)
+	offset = min(max(offset, 0), len(runes))
+	return len(utf16.Encode(runes[:offset]))
+}
@@ -0,0 +1,23 @@
+// internal/ui/components/field_error.go
+package components
+
+import (
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+)
+
+// FieldError renders the message shown below an invalid input, or nothing
+// if msg is empty
+func FieldError(msg string) app.UI {
+	if msg == "" {
+		return nil
+	}
+	return app.Div().Class("field-error").Aria("live", "polite").Text(msg)
+}
+
+// InvalidClass marks an input whose value was refused
+func InvalidClass(msg string) string {
+	if msg == "" {
+		return ""
+	}
+	return "invalid"
+}
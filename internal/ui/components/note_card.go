@@ -2,9 +2,12 @@
 package components
 
 import (
-	"github.com/Smil3MoreGH/gokeep/internal/models"
+	"errors"
+	"fmt"
+
+	"github.com/Smil3MoreGH/gokeep/internal/markdown"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
-	"github.com/russross/blackfriday/v2"
 )
 
 // NoteCard represents a single note card component
@@ -17,14 +20,43 @@ type NoteCard struct {
 	OnDelete  func(ctx app.Context, noteID int64)
 	OnSave    func(ctx app.Context, note models.Note)
 	OnCancel  func(ctx app.Context)
+	// Errors are the fields of the edited note the server refused
+	Errors models.FieldErrors
 
 	editTitle   string
 	editContent string
+	invalid     models.FieldErrors
+	collab      *collabSession
+	// conflict tells that the note changed elsewhere during the session
+	conflict string
 }
 
 func (c *NoteCard) OnMount(ctx app.Context) {
 	c.editTitle = c.Note.Title
 	c.editContent = c.Note.Content
+	if c.IsEditing {
+		c.startCollab(ctx)
+	}
+}
+
+// OnUpdate joins or leaves the collaborative session as editing toggles
+func (c *NoteCard) OnUpdate(ctx app.Context) {
+	switch {
+	case c.IsEditing && c.collab == nil:
+		c.editTitle = c.Note.Title
+		c.editContent = c.Note.Content
+		c.startCollab(ctx)
+	case !c.IsEditing && c.collab != nil:
+		c.invalid = nil
+		c.stopCollab()
+	}
+	if !c.IsEditing {
+		c.conflict = ""
+	}
+}
+
+func (c *NoteCard) OnDismount() {
+	c.stopCollab()
 }
 
 func (c *NoteCard) Render() app.UI {
@@ -40,6 +72,14 @@ func (c *NoteCard) renderViewMode() app.UI {
 		Class("note-card").
 		Style("background-color", c.Note.Color).
 		Body(
+			// Pin indicator
+			app.If(
+				c.Note.Pinned,
+				func() app.UI {
+					return app.Span().Class("note-pin").Title("Pinned").Text("📌")
+				},
+			),
+
 			// Title
 			app.If(
 				c.Note.Title != "",
@@ -55,6 +95,18 @@ func (c *NoteCard) renderViewMode() app.UI {
 					app.Raw(c.renderMarkdown(c.Note.Content)),
 				),
 
+			// Labels
+			app.If(
+				len(c.Note.Labels) > 0,
+				func() app.UI {
+					return app.Div().Class("note-labels").Body(
+						app.Range(c.Note.Labels).Slice(func(i int) app.UI {
+							return app.Span().Class("note-label").Text(c.Note.Labels[i])
+						}),
+					)
+				},
+			),
+
 			// Actions
 			app.Div().Class("note-actions").Body(
 				app.Button().
@@ -62,6 +114,11 @@ func (c *NoteCard) renderViewMode() app.UI {
 					Title("Edit note").
 					OnClick(c.onEditClick).
 					Text("✏️"),
+				app.A().
+					Class("btn-icon").
+					Title("Export as PDF").
+					Href(fmt.Sprintf("/api/notes/%d/export.pdf", c.Note.ID)).
+					Text("📄"),
 				app.Button().
 					Class("btn-icon").
 					Title("Delete note").
@@ -78,6 +135,7 @@ func (c *NoteCard) renderViewMode() app.UI {
 
 // renderEditMode renders the note in edit mode
 func (c *NoteCard) renderEditMode() app.UI {
+	errs := c.fieldErrors()
 	return app.Div().
 		Class("note-card editing").
 		Style("background-color", c.Note.Color).
@@ -85,22 +143,38 @@ func (c *NoteCard) renderEditMode() app.UI {
 			// Title input
 			app.Input().
 				Type("text").
-				Class("note-title-input").
+				Class("note-title-input", InvalidClass(errs.For("title"))).
 				Value(c.editTitle).
 				Placeholder("Title").
 				OnInput(c.onTitleInput).
 				AutoFocus(true),
+			FieldError(errs.For("title")),
+
+			// Content textarea with the carets of other editors laid over it
+			app.Div().Class("note-editor").Body(
+				app.Textarea().
+					ID(c.contentInputID()).
+					Class("note-content-input", InvalidClass(errs.For("content"))).
+					Placeholder("Take a note...").
+					Rows(5).
+					Text(c.editContent).
+					On("input", c.onContentInput).
+					On("select", c.onSelectionChange).
+					On("keyup", c.onSelectionChange).
+					On("click", c.onSelectionChange).
+					On("scroll", c.onEditorScroll),
+				c.renderRemoteCursors(),
+			),
+			FieldError(errs.For("content")),
+			FieldError(c.conflict),
 
-			// Content textarea
-			app.Textarea().
-				Class("note-content-input").
-				Placeholder("Take a note...").
-				Rows(5).
-				Text(c.editContent).
-				On("input", c.onContentInput),
+			// Who else is editing
+			c.renderPresence(),
 
 			// Color picker
 			c.renderColorPicker(),
+			FieldError(errs.For("color")),
+			FieldError(errs.For("labels")),
 
 			// Actions
 			app.Div().Class("note-actions").Body(
@@ -157,13 +231,8 @@ func (c *NoteCard) renderMarkdown(content string) string {
 		return ""
 	}
 
-	// Configure blackfriday options
-	extensions := blackfriday.CommonExtensions | blackfriday.Autolink
-
-	// Render markdown to HTML
-	html := blackfriday.Run([]byte(content), blackfriday.WithExtensions(extensions))
-
-	return string(html)
+	// Render markdown to HTML without the markup that could run script
+	return markdown.HTML(content)
 }
 
 // Event handlers
@@ -186,27 +255,78 @@ func (c *NoteCard) onDeleteClick(ctx app.Context, e app.Event) {
 func (c *NoteCard) onTitleInput(ctx app.Context, e app.Event) {
 	value := ctx.JSSrc().Get("value").String()
 	c.editTitle = value
+	c.revalidate()
 	ctx.Update()
 }
 
 func (c *NoteCard) onContentInput(ctx app.Context, e app.Event) {
 	value := ctx.JSSrc().Get("value").String()
+	c.collabInput(value)
 	c.editContent = value
+	c.revalidate()
 	ctx.Update()
 }
 
+func (c *NoteCard) onSelectionChange(ctx app.Context, e app.Event) {
+	c.sendCursor()
+}
+
+func (c *NoteCard) onEditorScroll(ctx app.Context, e app.Event) {
+	// Keep the remote carets aligned with the scrolled text
+	overlay := ctx.JSSrc().Get("nextElementSibling")
+	if overlay.Truthy() {
+		overlay.Set("scrollTop", ctx.JSSrc().Get("scrollTop"))
+	}
+}
+
 func (c *NoteCard) onSaveClick(ctx app.Context, e app.Event) {
-	if c.OnSave != nil {
-		c.Note.Title = c.editTitle
-		c.Note.Content = c.editContent
-		c.OnSave(ctx, c.Note)
+	if c.OnSave == nil {
+		return
 	}
+
+	note := c.draft()
+	var fields models.FieldErrors
+	if errors.As(note.Validate(), &fields) {
+		c.invalid = fields
+		ctx.Update()
+		return
+	}
+	c.invalid = nil
+	c.Note = note
+	c.OnSave(ctx, c.Note)
 }
 
 func (c *NoteCard) onCancelClick(ctx app.Context, e app.Event) {
 	if c.OnCancel != nil {
 		c.editTitle = c.Note.Title
 		c.editContent = c.Note.Content
+		c.invalid = nil
 		c.OnCancel(ctx)
 	}
 }
+
+// draft returns the note with the input of the editor
+func (c *NoteCard) draft() models.Note {
+	note := c.Note
+	note.Title = c.editTitle
+	note.Content = c.editContent
+	return note
+}
+
+// fieldErrors returns the errors to show next to the inputs: those found
+// on saving, or else those the server reported
+func (c *NoteCard) fieldErrors() models.FieldErrors {
+	if c.invalid != nil {
+		return c.invalid
+	}
+	return c.Errors
+}
+
+// revalidate clears the errors shown once the input is fixed
+func (c *NoteCard) revalidate() {
+	if c.invalid != nil {
+		note := c.draft()
+		c.invalid = nil
+		errors.As(note.Validate(), &c.invalid)
+	}
+}
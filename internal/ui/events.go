@@ -0,0 +1,105 @@
+// internal/ui/events.go
+package ui
+
+import (
+	"encoding/json"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+)
+
+// startEventStream subscribes to /api/events so changes made elsewhere show up
+// live. The browser's EventSource reconnects on its own and sends
+// Last-Event-ID, so missed events are replayed by the server.
+func (a *App) startEventStream(ctx app.Context) {
+	constructor := app.Window().Get("EventSource")
+	if !constructor.Truthy() {
+		return
+	}
+
+	a.eventSource = constructor.New("/api/events")
+
+	eventTypes := []models.NoteEventType{
+		models.EventNoteCreated,
+		models.EventNoteUpdated,
+		models.EventNoteDeleted,
+		models.EventReset,
+	}
+	for _, eventType := range eventTypes {
+		listener := app.FuncOf(func(this app.Value, args []app.Value) any {
+			if len(args) == 0 {
+				return nil
+			}
+
+			var event models.NoteEvent
+			if err := json.Unmarshal([]byte(args[0].Get("data").String()), &event); err != nil {
+				return nil
+			}
+
+			ctx.Dispatch(func(ctx app.Context) {
+				a.applyEvent(ctx, event)
+			})
+			return nil
+		})
+		a.eventSource.Call("addEventListener", string(eventType), listener)
+		a.eventListeners = append(a.eventListeners, listener)
+	}
+}
+
+// stopEventStream closes the event stream and releases the JS callbacks
+func (a *App) stopEventStream() {
+	if a.eventSource != nil {
+		a.eventSource.Call("close")
+		a.eventSource = nil
+	}
+	for _, listener := range a.eventListeners {
+		listener.Release()
+	}
+	a.eventListeners = nil
+}
+
+// applyEvent merges a single change event into the local notes
+func (a *App) applyEvent(ctx app.Context, event models.NoteEvent) {
+	switch event.Type {
+	case models.EventReset:
+		a.loadNotes(ctx)
+		return
+
+	case models.EventNoteCreated, models.EventNoteUpdated:
+		if event.Note == nil {
+			return
+		}
+		a.upsertNote(*event.Note)
+
+	case models.EventNoteDeleted:
+		a.removeNote(event.NoteID)
+		if a.editingNoteID == event.NoteID {
+			a.editingNoteID = 0
+		}
+	}
+
+	a.saveOfflineState(ctx)
+	ctx.Update()
+}
+
+// upsertNote replaces the local copy of note or prepends it if it is new
+func (a *App) upsertNote(note models.Note) {
+	for i, n := range a.notes {
+		if n.ID == note.ID {
+			a.notes[i] = note
+			return
+		}
+	}
+	a.notes = append([]models.Note{note}, a.notes...)
+}
+
+// removeNote drops the local copy of a note
+func (a *App) removeNote(noteID int64) {
+	filtered := make([]models.Note, 0, len(a.notes))
+	for _, n := range a.notes {
+		if n.ID != noteID {
+			filtered = append(filtered, n)
+		}
+	}
+	a.notes = filtered
+}
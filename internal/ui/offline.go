@@ -0,0 +1,446 @@
+// internal/ui/offline.go
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/client"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+)
+
+// Local storage keys
+const (
+	notesCacheKey    = "gokeep.notes"
+	mutationQueueKey = "gokeep.mutations"
+)
+
+// reconnectInterval is how often the server is probed after it went away
+// while the browser still reported being online
+const reconnectInterval = 15 * time.Second
+
+// conflictSuffix marks the copy of a note that was kept after a conflict
+const conflictSuffix = " (offline copy)"
+
+// errServerUnavailable means the request should be retried later
+var errServerUnavailable = errors.New("server unavailable")
+
+// mutationKind is the kind of change waiting to be sent to the server
+type mutationKind string
+
+const (
+	mutationCreate mutationKind = "create"
+	mutationUpdate mutationKind = "update"
+	mutationDelete mutationKind = "delete"
+)
+
+// mutation is a local change made while offline. Notes created offline get
+// negative temporary IDs until the server assigns real ones.
+type mutation struct {
+	Kind mutationKind `json:"kind"`
+	Note models.Note  `json:"note"`
+	// BaseUpdatedAt is the server version the change was made against
+	BaseUpdatedAt time.Time `json:"base_updated_at"`
+}
+
+// replayResult describes what happened when a mutation was sent
+type replayResult struct {
+	// saved is the server's version of the note after the mutation
+	saved *models.Note
+	// copy is a new note holding local changes that conflicted
+	copy *models.Note
+	// deleted is true if the note no longer exists on the server
+	deleted  bool
+	conflict bool
+	err      error
+}
+
+// restoreOfflineState loads cached notes and pending mutations and starts
+// tracking connectivity
+func (a *App) restoreOfflineState(ctx app.Context) {
+	ctx.LocalStorage().Get(notesCacheKey, &a.notes)
+	ctx.LocalStorage().Get(mutationQueueKey, &a.mutations)
+
+	navigator := app.Window().Get("navigator")
+	a.online = !navigator.Truthy() || navigator.Get("onLine").Bool()
+
+	onOnline := app.FuncOf(func(this app.Value, args []app.Value) any {
+		ctx.Dispatch(func(ctx app.Context) {
+			a.online = true
+			a.replayMutations(ctx)
+			a.loadNotes(ctx)
+		})
+		return nil
+	})
+	onOffline := app.FuncOf(func(this app.Value, args []app.Value) any {
+		ctx.Dispatch(func(ctx app.Context) {
+			a.online = false
+		})
+		return nil
+	})
+	app.Window().Call("addEventListener", "online", onOnline)
+	app.Window().Call("addEventListener", "offline", onOffline)
+	a.connectivityListeners = []app.Func{onOnline, onOffline}
+}
+
+// markOffline switches to offline mode after a request could not reach the
+// server and schedules a reconnect attempt
+func (a *App) markOffline(ctx app.Context) {
+	a.online = false
+	if a.reconnectScheduled {
+		return
+	}
+	a.reconnectScheduled = true
+
+	ctx.After(reconnectInterval, func(ctx app.Context) {
+		a.reconnectScheduled = false
+		if a.online {
+			return
+		}
+		a.online = true
+		a.replayMutations(ctx)
+		a.loadNotes(ctx)
+	})
+}
+
+// stopConnectivityTracking releases the online/offline listeners
+func (a *App) stopConnectivityTracking() {
+	events := []string{"online", "offline"}
+	for i, listener := range a.connectivityListeners {
+		app.Window().Call("removeEventListener", events[i], listener)
+		listener.Release()
+	}
+	a.connectivityListeners = nil
+}
+
+// saveOfflineState persists notes and pending mutations to local storage
+func (a *App) saveOfflineState(ctx app.Context) {
+	ctx.LocalStorage().Set(notesCacheKey, a.notes)
+	ctx.LocalStorage().Set(mutationQueueKey, a.mutations)
+}
+
+// queueMutation applies a change locally and keeps it for later replay
+func (a *App) queueMutation(ctx app.Context, m mutation) {
+	switch m.Kind {
+	case mutationCreate:
+		m.Note.ID = a.nextTempID()
+		m.Note.SetDefaults()
+		a.notes = append([]models.Note{m.Note}, a.notes...)
+	case mutationUpdate:
+		m.Note.UpdatedAt = time.Now()
+		a.upsertNote(m.Note)
+	case mutationDelete:
+		a.removeNote(m.Note.ID)
+	}
+
+	// Fold changes to notes that never reached the server into their create.
+	// Skipped while replaying, since the create may already be in flight.
+	if !a.syncing && m.Note.ID < 0 && m.Kind != mutationCreate {
+		a.foldIntoCreate(m)
+	} else {
+		a.mutations = append(a.mutations, m)
+	}
+
+	a.saveOfflineState(ctx)
+	ctx.Update()
+}
+
+// foldIntoCreate merges an update or delete of a note created offline into
+// the pending create
+func (a *App) foldIntoCreate(m mutation) {
+	for i, pending := range a.mutations {
+		if pending.Kind != mutationCreate || pending.Note.ID != m.Note.ID {
+			continue
+		}
+		if m.Kind == mutationDelete {
+			a.mutations = append(a.mutations[:i], a.mutations[i+1:]...)
+		} else {
+			a.mutations[i].Note = m.Note
+		}
+		return
+	}
+	a.mutations = append(a.mutations, m)
+}
+
+// nextTempID returns an unused negative ID for a note created offline
+func (a *App) nextTempID() int64 {
+	id := int64(-1)
+	for _, n := range a.notes {
+		if n.ID <= id {
+			id = n.ID - 1
+		}
+	}
+	return id
+}
+
+// replayMutations sends pending mutations to the server one by one, in order
+func (a *App) replayMutations(ctx app.Context) {
+	if a.syncing || !a.online || len(a.mutations) == 0 {
+		return
+	}
+	a.syncing = true
+	ctx.Update()
+
+	go func() {
+		defer ctx.Dispatch(func(ctx app.Context) {
+			a.syncing = false
+			ctx.Update()
+		})
+
+		for {
+			next := make(chan *mutation, 1)
+			ctx.Dispatch(func(ctx app.Context) {
+				if !a.online || len(a.mutations) == 0 {
+					next <- nil
+					return
+				}
+				m := a.mutations[0]
+				next <- &m
+			})
+			m := <-next
+			if m == nil {
+				return
+			}
+
+			result := replayMutation(ctx, *m)
+
+			done := make(chan struct{})
+			ctx.Dispatch(func(ctx app.Context) {
+				defer close(done)
+				a.applyReplayResult(ctx, *m, result)
+			})
+			<-done
+
+			if errors.Is(result.err, errServerUnavailable) {
+				return
+			}
+		}
+	}()
+}
+
+// applyReplayResult updates local state after a mutation reached the server
+func (a *App) applyReplayResult(ctx app.Context, m mutation, result replayResult) {
+	if errors.Is(result.err, errServerUnavailable) {
+		a.markOffline(ctx)
+		ctx.Update()
+		return
+	}
+
+	a.mutations = a.mutations[1:]
+
+	switch {
+	case result.err != nil:
+		a.error = fmt.Errorf("could not sync offline change: %w", result.err)
+	case result.conflict:
+		a.conflicts++
+	}
+
+	if result.deleted {
+		a.removeNote(m.Note.ID)
+	}
+	if result.copy != nil {
+		a.upsertNote(*result.copy)
+	}
+	if result.saved != nil {
+		if m.Note.ID != result.saved.ID {
+			a.removeNote(m.Note.ID)
+		}
+		a.upsertNote(*result.saved)
+		a.rebaseMutations(m.Note.ID, *result.saved)
+	}
+
+	a.saveOfflineState(ctx)
+	ctx.Update()
+}
+
+// rebaseMutations points later mutations of a note at its new server version,
+// replacing a temporary ID with the real one
+func (a *App) rebaseMutations(oldID int64, saved models.Note) {
+	for i := range a.mutations {
+		if a.mutations[i].Note.ID == oldID {
+			a.mutations[i].Note.ID = saved.ID
+			a.mutations[i].BaseUpdatedAt = saved.UpdatedAt
+		}
+	}
+}
+
+// replayMutation sends a single mutation, checking the server version first
+// so that offline edits never silently overwrite newer changes
+func replayMutation(ctx context.Context, m mutation) replayResult {
+	if m.Kind == mutationCreate {
+		note := m.Note
+		note.ID = 0
+		created, err := postNote(ctx, note)
+		return replayResult{saved: created, err: err}
+	}
+
+	server, err := fetchNote(ctx, m.Note.ID)
+	if err != nil {
+		return replayResult{err: err}
+	}
+
+	switch m.Kind {
+	case mutationUpdate:
+		if server == nil || !server.UpdatedAt.Equal(m.BaseUpdatedAt) {
+			// Deleted or changed on the server: keep both versions
+			note := m.Note
+			note.ID = 0
+			note.Title += conflictSuffix
+			kept, err := postNote(ctx, note)
+			return replayResult{saved: server, copy: kept, deleted: server == nil, conflict: true, err: err}
+		}
+		saved, err := putNote(ctx, m.Note)
+		return replayResult{saved: saved, err: err}
+
+	case mutationDelete:
+		if server == nil {
+			return replayResult{deleted: true}
+		}
+		if !server.UpdatedAt.Equal(m.BaseUpdatedAt) {
+			// Changed on the server since we deleted it locally: keep it
+			return replayResult{saved: server, conflict: true}
+		}
+		err := deleteNoteRequest(ctx, m.Note.ID)
+		return replayResult{deleted: err == nil, err: err}
+	}
+
+	return replayResult{err: fmt.Errorf("unknown mutation %q", m.Kind)}
+}
+
+// api talks to the server the app was loaded from. It does not retry:
+// failed changes wait in the mutation queue until the server is back. Every
+// call starts a trace, which the server's spans of that call join.
+var api = client.New("", client.WithRetry(client.RetryPolicy{}), client.WithNewTraces())
+
+// fetchNotes loads all notes from the server
+func fetchNotes(ctx context.Context) ([]models.Note, error) {
+	notes, err := api.ListNotes(ctx)
+	return notes, offlineError(err)
+}
+
+// fetchNote loads a note from the server; it returns nil if it does not exist
+func fetchNote(ctx context.Context, id int64) (*models.Note, error) {
+	note, err := api.GetNote(ctx, id)
+	if errors.Is(err, client.ErrNotFound) {
+		return nil, nil
+	}
+	return note, offlineError(err)
+}
+
+func postNote(ctx context.Context, note models.Note) (*models.Note, error) {
+	created, err := api.CreateNote(ctx, &note)
+	return created, offlineError(err)
+}
+
+func putNote(ctx context.Context, note models.Note) (*models.Note, error) {
+	updated, err := api.UpdateNote(ctx, &note)
+	return updated, offlineError(err)
+}
+
+func deleteNoteRequest(ctx context.Context, id int64) error {
+	err := api.DeleteNote(ctx, id)
+	if errors.Is(err, client.ErrNotFound) {
+		return nil
+	}
+	return offlineError(err)
+}
+
+// offlineError turns failures that a later retry may fix, i.e. an
+// unreachable server or a server error, into errServerUnavailable
+func offlineError(err error) error {
+	var apiErr *client.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &apiErr):
+		if apiErr.Temporary() {
+			return errServerUnavailable
+		}
+		return err
+	case errors.As(err, new(*url.Error)):
+		return errServerUnavailable
+	}
+	return err
+}
+
+// fieldErrors returns the invalid fields err reports, whether it came from
+// models.Note.Validate or from the server, or nil if it names none
+func fieldErrors(err error) models.FieldErrors {
+	var fields models.FieldErrors
+	if errors.As(err, &fields) {
+		return fields
+	}
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Fields
+	}
+	return nil
+}
+
+// withPendingMutations overlays mutations that have not reached the server
+// yet onto a freshly loaded list of notes
+func (a *App) withPendingMutations(notes []models.Note) []models.Note {
+	for _, m := range a.mutations {
+		switch m.Kind {
+		case mutationCreate:
+			notes = append([]models.Note{m.Note}, notes...)
+		case mutationUpdate:
+			for i := range notes {
+				if notes[i].ID == m.Note.ID {
+					notes[i] = m.Note
+				}
+			}
+		case mutationDelete:
+			filtered := notes[:0]
+			for _, n := range notes {
+				if n.ID != m.Note.ID {
+					filtered = append(filtered, n)
+				}
+			}
+			notes = filtered
+		}
+	}
+	return notes
+}
+
+// renderSyncStatus shows connectivity and how many changes are waiting
+func (a *App) renderSyncStatus() app.UI {
+	pending := len(a.mutations)
+
+	var class, label string
+	switch {
+	case a.syncing:
+		class, label = "sync-syncing", fmt.Sprintf("Syncing %d…", pending)
+	case !a.online && pending > 0:
+		class, label = "sync-offline", fmt.Sprintf("Offline · %d pending", pending)
+	case !a.online:
+		class, label = "sync-offline", "Offline"
+	case pending > 0:
+		class, label = "sync-pending", fmt.Sprintf("%d pending", pending)
+	default:
+		class, label = "sync-online", "Synced"
+	}
+
+	status := app.Span().Class("sync-status " + class).Text(label)
+	if a.conflicts == 0 {
+		return status
+	}
+
+	return app.Div().Class("sync-container").Body(
+		status,
+		app.Button().
+			Class("sync-status sync-conflict").
+			Title("Conflicting offline changes were kept as separate copies. Click to dismiss.").
+			Text(fmt.Sprintf("%d conflict(s)", a.conflicts)).
+			OnClick(a.onDismissConflicts),
+	)
+}
+
+func (a *App) onDismissConflicts(ctx app.Context, e app.Event) {
+	a.conflicts = 0
+	ctx.Update()
+}
@@ -0,0 +1,170 @@
+// pkg/client/client.go
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Client talks to a gokeep server's REST API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	retry      RetryPolicy
+	newTraces  bool
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient makes the client send its requests through hc, e.g. to
+// trust a private CA. A Timeout on hc also cuts off the event stream.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithRetry replaces the default retry policy; RetryPolicy{} disables
+// retries
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithNewTraces makes every request whose context is not part of a trace
+// start one, so the server's spans of each call share a trace ID. The
+// browser UI uses it, having no tracer of its own.
+func WithNewTraces() Option {
+	return func(c *Client) {
+		c.newTraces = true
+	}
+}
+
+// New creates a client for the server at baseURL, e.g.
+// "http://localhost:8080". An empty baseURL sends requests to the origin the
+// code was loaded from, which is what the browser UI wants.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		retry:      DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// request describes a call before it is sent. A body that is a
+// *bytes.Reader can be rewound, so only such requests are retried.
+type request struct {
+	method      string
+	path        string
+	body        io.Reader
+	contentType string
+	accept      string
+	header      http.Header
+	// raw hands error responses back instead of turning them into an *Error
+	raw bool
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out unless it is nil
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	req := request{method: method, path: path, accept: "application/json"}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		req.body = bytes.NewReader(data)
+		req.contentType = "application/json"
+	}
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send performs a request, retrying idempotent ones that failed in a way
+// worth retrying, and turns error statuses into an *Error unless r.raw is
+// set. The W3C trace context of ctx goes along in the traceparent header,
+// and in the browser the CSRF token in its header.
+// The caller closes the body of the returned response.
+func (c *Client) send(ctx context.Context, r request) (*http.Response, error) {
+	if c.newTraces && !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = newTrace(ctx)
+	}
+	rewindable, _ := r.body.(*bytes.Reader)
+	retryable := idempotent(r.method) && (r.body == nil || rewindable != nil)
+
+	for attempt := 0; ; attempt++ {
+		if rewindable != nil {
+			rewindable.Seek(0, io.SeekStart)
+		}
+		req, err := http.NewRequestWithContext(ctx, r.method, c.baseURL+r.path, r.body)
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range r.header {
+			req.Header[name] = values
+		}
+		if r.accept != "" {
+			req.Header.Set("Accept", r.accept)
+		}
+		if r.contentType != "" {
+			req.Header.Set("Content-Type", r.contentType)
+		}
+		setCSRFToken(req)
+		propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		resp, err := c.httpClient.Do(req)
+		if err == nil && (resp.StatusCode < http.StatusBadRequest || r.raw) {
+			return resp, nil
+		}
+		if err == nil {
+			err = decodeError(resp)
+			resp.Body.Close()
+		}
+
+		if !retryable || attempt >= c.retry.MaxRetries || !shouldRetry(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.retry.delay(attempt, resp)):
+		}
+	}
+}
+
+// idempotent reports whether sending a request twice has the same effect
+// as sending it once
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+// pkg/client/collab.go
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/collab"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+// CollabMessage is a message of the collaborative editing protocol
+type CollabMessage = collab.Message
+
+// CollabConn is a connection to the editing session of a note
+type CollabConn struct {
+	conn *websocket.Conn
+}
+
+// DialCollab joins the editing session of a note under the given display
+// name. The first message received is the collab.MsgInit with the current
+// content. It needs a client with a base URL.
+func (c *Client) DialCollab(ctx context.Context, noteID int64, name string) (*CollabConn, error) {
+	u, err := url.Parse(c.baseURL + notePath(noteID) + "/collab")
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	default:
+		return nil, fmt.Errorf("collaborative editing needs an http or https base URL, not %q", c.baseURL)
+	}
+	if name != "" {
+		u.RawQuery = url.Values{"name": {name}}.Encode()
+	}
+
+	conn, resp, err := websocket.Dial(ctx, u.String(), c.dialOptions())
+	if err != nil {
+		if resp != nil && resp.StatusCode >= 400 {
+			return nil, &Error{StatusCode: resp.StatusCode}
+		}
+		return nil, fmt.Errorf("failed to join editing session: %w", err)
+	}
+	return &CollabConn{conn: conn}, nil
+}
+
+// Send sends a message to the session
+func (cc *CollabConn) Send(ctx context.Context, msg CollabMessage) error {
+	return wsjson.Write(ctx, cc.conn, msg)
+}
+
+// Receive waits for the next message from the session
+func (cc *CollabConn) Receive(ctx context.Context) (CollabMessage, error) {
+	var msg CollabMessage
+	err := wsjson.Read(ctx, cc.conn, &msg)
+	return msg, err
+}
+
+// Close leaves the session
+func (cc *CollabConn) Close() error {
+	return cc.conn.Close(websocket.StatusNormalClosure, "")
+}
@@ -0,0 +1,40 @@
+// pkg/client/csrf_js.go
+package client
+
+import (
+	"net/http"
+	"syscall/js"
+)
+
+// The names of the server's double-submit CSRF token; the cookie is
+// called csrfCookieSecure over HTTPS
+const (
+	csrfCookie       = "gokeep_csrf"
+	csrfCookieSecure = "__Host-gokeep_csrf"
+	csrfHeader       = "X-CSRF-Token"
+)
+
+// setCSRFToken copies the CSRF cookie the server handed out into the
+// header it checks on writes
+func setCSRFToken(req *http.Request) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return
+	}
+	doc := js.Global().Get("document")
+	if !doc.Truthy() {
+		return
+	}
+	cookies, err := http.ParseCookie(doc.Get("cookie").String())
+	if err != nil {
+		return
+	}
+	for _, name := range []string{csrfCookieSecure, csrfCookie} {
+		for _, c := range cookies {
+			if c.Name == name {
+				req.Header.Set(csrfHeader, c.Value)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,12 @@
+// pkg/client/dial.go
+
+//go:build !js
+
+package client
+
+import "github.com/coder/websocket"
+
+// dialOptions makes WebSockets use the client's transport and TLS settings
+func (c *Client) dialOptions() *websocket.DialOptions {
+	return &websocket.DialOptions{HTTPClient: c.httpClient}
+}
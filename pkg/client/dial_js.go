@@ -0,0 +1,9 @@
+// pkg/client/dial_js.go
+package client
+
+import "github.com/coder/websocket"
+
+// dialOptions has nothing to set: the browser opens WebSockets itself
+func (c *Client) dialOptions() *websocket.DialOptions {
+	return nil
+}
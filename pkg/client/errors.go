@@ -0,0 +1,94 @@
+// pkg/client/errors.go
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// Errors an *Error matches with errors.Is, depending on its status
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+)
+
+// Error is returned for responses with an error status
+type Error struct {
+	StatusCode int
+	// Code is the stable code of the server's problem details, e.g.
+	// "not_found"; it is empty if the response carried none
+	Code    string
+	Message string
+	// Fields lists the invalid fields of a validation error
+	Fields models.FieldErrors
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Is lets errors.Is(err, ErrNotFound) and friends classify the response
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict || e.StatusCode == http.StatusPreconditionFailed
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	}
+	return false
+}
+
+// Temporary reports whether the request may succeed when sent again later
+func (e *Error) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// decodeError turns an error response into an *Error, using the RFC 7807
+// problem details of the server where there are some. Older servers sent
+// {"error": ...} instead.
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	var body struct {
+		Title  string             `json:"title"`
+		Detail string             `json:"detail"`
+		Code   string             `json:"code"`
+		Errors models.FieldErrors `json:"errors"`
+		Error  string             `json:"error"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		apiErr.Message = strings.TrimSpace(string(data))
+		return apiErr
+	}
+	apiErr.Code = body.Code
+	apiErr.Fields = body.Errors
+	switch {
+	case body.Detail != "":
+		apiErr.Message = body.Detail
+	case body.Error != "":
+		apiErr.Message = body.Error
+	default:
+		apiErr.Message = body.Title
+	}
+	return apiErr
+}
+
+// isProblem reports whether resp carries RFC 7807 problem details
+func isProblem(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == "application/problem+json"
+}
@@ -0,0 +1,115 @@
+// pkg/client/events.go
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// Event is a change published on /api/events
+type Event = models.NoteEvent
+
+// Events yields changes as they happen, starting after lastEventID; 0 means
+// only new ones. A dropped stream is resumed where it left off after the
+// delay the server asks for. An event of type models.EventReset means
+// events were missed and the caller should reload its notes.
+//
+// Iteration ends when ctx is cancelled, or with an error when the server
+// cannot be reached after the retries of the policy.
+func (c *Client) Events(ctx context.Context, lastEventID uint64) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		reconnect := c.retry.delay(0, nil)
+		for {
+			header := http.Header{}
+			if lastEventID > 0 {
+				header.Set("Last-Event-ID", strconv.FormatUint(lastEventID, 10))
+			}
+			resp, err := c.send(ctx, request{
+				method: http.MethodGet,
+				path:   "/api/events",
+				accept: "text/event-stream",
+				header: header,
+			})
+			if err != nil {
+				if ctx.Err() == nil {
+					yield(Event{}, err)
+				}
+				return
+			}
+
+			stopped, err := readEvents(resp.Body, &reconnect, func(event Event) bool {
+				lastEventID = event.ID
+				return yield(event, nil)
+			})
+			resp.Body.Close()
+			if stopped || ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				yield(Event{}, err)
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(reconnect):
+			}
+		}
+	}
+}
+
+// readEvents parses a Server-Sent Events stream until it ends or handle
+// returns false, in which case stopped is true. A retry field updates
+// reconnect.
+func readEvents(r io.Reader, reconnect *time.Duration, handle func(Event) bool) (stopped bool, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if data.Len() == 0 {
+				continue
+			}
+			var event Event
+			if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+				return false, fmt.Errorf("failed to decode event: %w", err)
+			}
+			data.Reset()
+			if !handle(event) {
+				return true, nil
+			}
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				*reconnect = time.Duration(ms) * time.Millisecond
+			}
+		}
+		// id and event are part of the JSON payload as well; lines starting
+		// with a colon are comments that keep the connection alive
+	}
+	// A stream cut off by the network just ends; the caller reconnects
+	return false, nil
+}
@@ -0,0 +1,65 @@
+// pkg/client/notes.go
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// Note is a note as the API returns it
+type Note = models.Note
+
+// ListNotes returns all notes, including archived and trashed ones
+func (c *Client) ListNotes(ctx context.Context) ([]Note, error) {
+	var notes []Note
+	err := c.do(ctx, http.MethodGet, "/api/notes", nil, &notes)
+	return notes, err
+}
+
+// GetNote returns the note with the given ID
+func (c *Client) GetNote(ctx context.Context, id int64) (*Note, error) {
+	var note Note
+	if err := c.do(ctx, http.MethodGet, notePath(id), nil, &note); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// CreateNote stores a new note and returns it as saved. It is never
+// retried, since a lost response would otherwise create the note twice.
+func (c *Client) CreateNote(ctx context.Context, note *Note) (*Note, error) {
+	var created Note
+	if err := c.do(ctx, http.MethodPost, "/api/notes", note, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateNote replaces the note with note.ID and returns it as saved
+func (c *Client) UpdateNote(ctx context.Context, note *Note) (*Note, error) {
+	var updated Note
+	if err := c.do(ctx, http.MethodPut, notePath(note.ID), note, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteNote deletes the note with the given ID for good
+func (c *Client) DeleteNote(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, notePath(id), nil, nil)
+}
+
+// SearchNotes returns the notes matching a full-text query
+func (c *Client) SearchNotes(ctx context.Context, query string) ([]Note, error) {
+	var notes []Note
+	err := c.do(ctx, http.MethodGet, "/api/notes/search?q="+url.QueryEscape(query), nil, &notes)
+	return notes, err
+}
+
+func notePath(id int64) string {
+	return fmt.Sprintf("/api/notes/%d", id)
+}
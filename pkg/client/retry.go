@@ -0,0 +1,69 @@
+// pkg/client/retry.go
+package client
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides how often and how quickly failed requests are sent
+// again. Only idempotent requests are retried, and only after network
+// errors, 429 Too Many Requests and 502, 503 or 504.
+type RetryPolicy struct {
+	// MaxRetries is the number of attempts after the first one
+	MaxRetries int
+	// BaseDelay is the wait before the first retry; it doubles each time
+	BaseDelay time.Duration
+	// MaxDelay caps the wait, including one asked for with Retry-After
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used unless WithRetry says otherwise
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  250 * time.Millisecond,
+	MaxDelay:   5 * time.Second,
+}
+
+// shouldRetry reports whether err is worth another attempt
+func shouldRetry(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		// The server could not be reached or the connection broke
+		return true
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// delay returns the wait before retry number attempt+1: exponential backoff
+// with full jitter, or what the server asked for with Retry-After
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, p.maxDelay())
+		}
+	}
+
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	backoff := p.BaseDelay << attempt
+	if backoff <= 0 || backoff > p.maxDelay() {
+		backoff = p.maxDelay()
+	}
+	return rand.N(backoff) + 1
+}
+
+func (p RetryPolicy) maxDelay() time.Duration {
+	if p.MaxDelay <= 0 {
+		return DefaultRetryPolicy.MaxDelay
+	}
+	return p.MaxDelay
+}
@@ -0,0 +1,77 @@
+// pkg/client/sync.go
+package client
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// DefaultPageSize is the number of changes Changes and Notes fetch per
+// request unless told otherwise
+const DefaultPageSize = 100
+
+// SyncResult is one page of changes from /api/sync
+type SyncResult = models.SyncResult
+
+// Tombstone records a deleted note
+type Tombstone = models.Tombstone
+
+// Sync returns the changes after the sequence number since, at most limit
+// of them if limit is positive. Pass the result's Seq as since to continue.
+func (c *Client) Sync(ctx context.Context, since int64, limit int) (*SyncResult, error) {
+	path := fmt.Sprintf("/api/sync?since=%d", since)
+	if limit > 0 {
+		path += fmt.Sprintf("&limit=%d", limit)
+	}
+	var result SyncResult
+	if err := c.do(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Changes pages through everything that changed after since, pageSize
+// changes per request. Iteration stops at the first error, which is
+// yielded with a nil page.
+func (c *Client) Changes(ctx context.Context, since int64, pageSize int) iter.Seq2[*SyncResult, error] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return func(yield func(*SyncResult, error) bool) {
+		for {
+			page, err := c.Sync(ctx, since, pageSize)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(page, nil) || !page.HasMore {
+				return
+			}
+			since = page.Seq
+		}
+	}
+}
+
+// Notes yields every note on the server, including archived and trashed
+// ones, fetching them pageSize at a time. Unlike ListNotes it never holds
+// more than one page in memory. A note changed while iterating may be
+// yielded again in its new version.
+func (c *Client) Notes(ctx context.Context, pageSize int) iter.Seq2[Note, error] {
+	return func(yield func(Note, error) bool) {
+		for page, err := range c.Changes(ctx, 0, pageSize) {
+			if err != nil {
+				yield(Note{}, err)
+				return
+			}
+			for _, note := range page.Changed {
+				if !yield(note, nil) {
+					return
+				}
+			}
+		}
+	}
+}
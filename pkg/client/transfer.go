@@ -0,0 +1,176 @@
+// pkg/client/transfer.go
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ImportFormat selects the import endpoint
+type ImportFormat string
+
+const (
+	// ImportMarkdown reads a zip of Markdown files as written by Export
+	ImportMarkdown ImportFormat = "markdown"
+	// ImportKeep reads a Google Takeout zip of Google Keep
+	ImportKeep ImportFormat = "keep"
+	// ImportENEX reads an Evernote .enex export
+	ImportENEX ImportFormat = "enex"
+)
+
+// ImportReport summarises an import
+type ImportReport struct {
+	DryRun      bool          `json:"dry_run"`
+	Imported    int           `json:"imported"`
+	Skipped     int           `json:"skipped"`
+	Attachments int           `json:"attachments"`
+	Notes       []ImportEntry `json:"notes"`
+	Warnings    []string      `json:"warnings,omitempty"`
+}
+
+// ImportEntry describes one note of an imported export
+type ImportEntry struct {
+	File        string   `json:"file"`
+	ID          int64    `json:"id,omitempty"`
+	Title       string   `json:"title"`
+	Labels      []string `json:"labels,omitempty"`
+	Pinned      bool     `json:"pinned,omitempty"`
+	Archived    bool     `json:"archived,omitempty"`
+	Trashed     bool     `json:"trashed,omitempty"`
+	Attachments int      `json:"attachments,omitempty"`
+	// Action is created, updated, unchanged or duplicate
+	Action      string   `json:"action,omitempty"`
+	DuplicateOf int64    `json:"duplicate_of,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// Import uploads an export in the given format. With dryRun the server only
+// reports what it would import. The upload is streamed from r and never
+// retried. When an import fails halfway, both the report of what made it in
+// and the error are returned.
+func (c *Client) Import(ctx context.Context, format ImportFormat, r io.Reader, dryRun bool) (*ImportReport, error) {
+	var path string
+	switch format {
+	case ImportMarkdown:
+		path = "/api/import"
+	case ImportKeep:
+		path = "/api/import/keep"
+	case ImportENEX:
+		path = "/api/import/enex"
+	default:
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+	if dryRun {
+		path += "?dry_run=true"
+	}
+
+	contentType := "application/zip"
+	if format == ImportENEX {
+		contentType = "application/xml"
+	}
+	resp, err := c.send(ctx, request{
+		method:      http.MethodPost,
+		path:        path,
+		body:        r,
+		contentType: contentType,
+		accept:      "application/json",
+		raw:         true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// A partial import is answered with 500 and the report
+	if resp.StatusCode >= http.StatusBadRequest && (resp.StatusCode != http.StatusInternalServerError || isProblem(resp)) {
+		return nil, decodeError(resp)
+	}
+
+	var report ImportReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return nil, &Error{StatusCode: resp.StatusCode}
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		// Part of the export was imported; the last warning says what broke
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		if len(report.Warnings) > 0 {
+			apiErr.Message = report.Warnings[len(report.Warnings)-1]
+		}
+		return &report, apiErr
+	}
+	return &report, nil
+}
+
+// Export writes a zip of all notes as Markdown files with front matter to w
+func (c *Client) Export(ctx context.Context, w io.Writer) error {
+	return c.download(ctx, "/api/export", w)
+}
+
+// ExportPDF writes the notes with the given IDs, or all notes if there are
+// none, to w as a single PDF
+func (c *Client) ExportPDF(ctx context.Context, w io.Writer, ids ...int64) error {
+	path := "/api/export.pdf"
+	if len(ids) > 0 {
+		list := make([]string, len(ids))
+		for i, id := range ids {
+			list[i] = strconv.FormatInt(id, 10)
+		}
+		path += "?ids=" + url.QueryEscape(strings.Join(list, ","))
+	}
+	return c.download(ctx, path, w)
+}
+
+// ExportNotePDF writes a single note to w as a PDF
+func (c *Client) ExportNotePDF(ctx context.Context, id int64, w io.Writer) error {
+	return c.download(ctx, notePath(id)+"/export.pdf", w)
+}
+
+// Attachment describes a downloaded attachment
+type Attachment struct {
+	Filename string
+	MimeType string
+	Size     int64
+}
+
+// DownloadAttachment writes the attachment with the given ID to w
+func (c *Client) DownloadAttachment(ctx context.Context, id string, w io.Writer) (*Attachment, error) {
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: "/api/attachments/" + url.PathEscape(id)})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	info := &Attachment{MimeType: resp.Header.Get("Content-Type")}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		info.Filename = params["filename"]
+	}
+	info.Size, err = io.Copy(w, resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download attachment: %w", err)
+	}
+	return info, nil
+}
+
+// download copies the body of a GET response to w
+func (c *Client) download(ctx context.Context, path string, w io.Writer) error {
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: path})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to download %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// pkg/collab/client.go
+package collab
+
+import (
+	"fmt"
+)
+
+// Client tracks the local side of a collaboration session. It keeps at most
+// one operation in flight and buffers further local edits until the server
+// acknowledges it, transforming both against incoming remote operations.
+type Client struct {
+	Revision int
+	Doc      string
+
+	outstanding *Operation
+	buffer      *Operation
+	send        func(rev int, op *Operation)
+}
+
+// NewClient creates a client for doc at revision rev. send is called
+// whenever an operation is ready to go to the server.
+func NewClient(rev int, doc string, send func(rev int, op *Operation)) *Client {
+	return &Client{Revision: rev, Doc: doc, send: send}
+}
+
+// ApplyLocal records an edit made by the local user and sends it when possible
+func (c *Client) ApplyLocal(op *Operation) error {
+	doc, err := op.Apply(c.Doc)
+	if err != nil {
+		return err
+	}
+	c.Doc = doc
+
+	switch {
+	case c.outstanding == nil:
+		c.outstanding = op
+		c.send(c.Revision, op)
+	case c.buffer == nil:
+		c.buffer = op
+	default:
+		if c.buffer, err = Compose(c.buffer, op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyRemote integrates an operation from another client and returns the
+// transformed operation that was applied to the local document
+func (c *Client) ApplyRemote(op *Operation) (*Operation, error) {
+	var err error
+	if c.outstanding != nil {
+		if c.outstanding, op, err = Transform(c.outstanding, op); err != nil {
+			return nil, err
+		}
+	}
+	if c.buffer != nil {
+		if c.buffer, op, err = Transform(c.buffer, op); err != nil {
+			return nil, err
+		}
+	}
+
+	doc, err := op.Apply(c.Doc)
+	if err != nil {
+		return nil, err
+	}
+	c.Doc = doc
+	c.Revision++
+	return op, nil
+}
+
+// Ack handles the server's confirmation of the outstanding operation
+func (c *Client) Ack() error {
+	if c.outstanding == nil {
+		return fmt.Errorf("unexpected ack: no operation in flight")
+	}
+	c.Revision++
+	c.outstanding, c.buffer = c.buffer, nil
+	if c.outstanding != nil {
+		c.send(c.Revision, c.outstanding)
+	}
+	return nil
+}
+
+// Pending reports whether local edits have not been acknowledged yet
+func (c *Client) Pending() bool {
+	return c.outstanding != nil
+}
@@ -0,0 +1,396 @@
+// pkg/collab/hub.go
+package collab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts a span for every save of a shared document
+var tracer = otel.Tracer("github.com/Smil3MoreGH/gokeep/pkg/collab")
+
+// DefaultSaveDelay is how long a document must be idle before it is persisted
+const DefaultSaveDelay = 2 * time.Second
+
+// sendBuffer is the number of queued messages before a peer counts as stalled
+const sendBuffer = 256
+
+// peerColors are assigned round-robin to participants for their cursors
+var peerColors = []string{
+	"#e53935", "#1e88e5", "#43a047", "#fb8c00",
+	"#8e24aa", "#00897b", "#6d4c41", "#3949ab",
+}
+
+// Store loads and persists notes; NoteRepository satisfies it
+type Store interface {
+	GetByID(ctx context.Context, id int64) (*models.Note, error)
+	Update(ctx context.Context, note *models.Note) error
+}
+
+// Hub keeps one shared document per note that is being edited and relays
+// operations between everyone editing it
+type Hub struct {
+	store     Store
+	saveDelay time.Duration
+
+	mu     sync.Mutex
+	docs   map[int64]*document
+	nextID int
+}
+
+// Participant is one connection editing a note. Outgoing messages are
+// delivered on Send, which is closed when the participant is dropped.
+type Participant struct {
+	ID    int
+	Name  string
+	Color string
+	Send  chan Message
+
+	doc    *document
+	cursor *Cursor
+	closed bool
+}
+
+// document is the authoritative state of a note under collaboration
+type document struct {
+	hub    *Hub
+	noteID int64
+
+	mu      sync.Mutex
+	content string
+	// seq and stored are the change sequence number and content of the
+	// stored note the session is based on
+	seq          int64
+	stored       string
+	history      []*Operation
+	participants map[int]*Participant
+	saveTimer    *time.Timer
+	dirty        bool
+}
+
+// NewHub creates a collaboration hub backed by store
+func NewHub(store Store, saveDelay time.Duration) *Hub {
+	if saveDelay <= 0 {
+		saveDelay = DefaultSaveDelay
+	}
+	return &Hub{
+		store:     store,
+		saveDelay: saveDelay,
+		docs:      make(map[int64]*document),
+	}
+}
+
+// Join adds a participant to the session of noteID, loading the note if
+// nobody is editing it yet. The init message is already queued on Send.
+func (h *Hub) Join(ctx context.Context, noteID int64, name string) (*Participant, error) {
+	h.mu.Lock()
+	doc, ok := h.docs[noteID]
+	h.mu.Unlock()
+
+	if !ok {
+		// Load the note without holding up everyone else joining
+		note, err := h.store.GetByID(ctx, noteID)
+		if err != nil {
+			return nil, err
+		}
+		doc = &document{
+			hub:          h,
+			noteID:       noteID,
+			content:      note.Content,
+			seq:          note.Seq,
+			stored:       note.Content,
+			participants: make(map[int]*Participant),
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Someone else may have started the session in the meantime
+	if existing, ok := h.docs[noteID]; ok {
+		doc = existing
+	} else {
+		h.docs[noteID] = doc
+	}
+
+	h.nextID++
+	if name == "" {
+		name = fmt.Sprintf("Guest %d", h.nextID)
+	}
+	p := &Participant{
+		ID:    h.nextID,
+		Name:  name,
+		Color: peerColors[(h.nextID-1)%len(peerColors)],
+		Send:  make(chan Message, sendBuffer),
+		doc:   doc,
+	}
+
+	doc.mu.Lock()
+	defer doc.mu.Unlock()
+
+	peers := make([]Peer, 0, len(doc.participants))
+	for _, other := range doc.participants {
+		peers = append(peers, other.peer())
+	}
+	doc.participants[p.ID] = p
+
+	self := p.peer()
+	p.deliver(Message{
+		Type:     MsgInit,
+		ClientID: p.ID,
+		Revision: len(doc.history),
+		Content:  doc.content,
+		Peer:     &self,
+		Peers:    peers,
+	})
+	doc.broadcast(p.ID, Message{Type: MsgJoin, ClientID: p.ID, Peer: &self})
+
+	return p, nil
+}
+
+// Handle processes a message received from the participant
+func (p *Participant) Handle(msg Message) {
+	doc := p.doc
+	doc.mu.Lock()
+	defer doc.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	switch msg.Type {
+	case MsgOp:
+		if err := doc.applyOp(p, msg); err != nil {
+			p.deliver(Message{Type: MsgError, Revision: len(doc.history), Error: err.Error()})
+		}
+	case MsgCursor:
+		if msg.Cursor == nil {
+			return
+		}
+		cursor, err := doc.rebaseCursor(*msg.Cursor, msg.Revision)
+		if err != nil {
+			return
+		}
+		p.cursor = &cursor
+		doc.broadcast(p.ID, Message{
+			Type:     MsgCursor,
+			ClientID: p.ID,
+			Revision: len(doc.history),
+			Cursor:   &cursor,
+		})
+	}
+}
+
+// Leave removes the participant from its session. The document is saved and
+// released once the last participant has left.
+func (p *Participant) Leave() {
+	doc := p.doc
+	hub := doc.hub
+
+	hub.mu.Lock()
+	doc.mu.Lock()
+	if !p.closed {
+		doc.drop(p)
+	}
+	empty := len(doc.participants) == 0
+	if empty && hub.docs[doc.noteID] == doc {
+		delete(hub.docs, doc.noteID)
+	}
+	doc.mu.Unlock()
+	hub.mu.Unlock()
+
+	if empty {
+		doc.save()
+	}
+}
+
+// applyOp transforms an operation against everything the client had not seen
+// yet, applies it and relays it to the other participants
+func (doc *document) applyOp(p *Participant, msg Message) error {
+	if msg.Op == nil {
+		return fmt.Errorf("missing operation")
+	}
+	if msg.Revision < 0 || msg.Revision > len(doc.history) {
+		return fmt.Errorf("invalid revision %d", msg.Revision)
+	}
+
+	op := msg.Op
+	for _, concurrent := range doc.history[msg.Revision:] {
+		var err error
+		if op, _, err = Transform(op, concurrent); err != nil {
+			return err
+		}
+	}
+
+	content, err := op.Apply(doc.content)
+	if err != nil {
+		return err
+	}
+	if len(content) > models.MaxContentLength {
+		return fmt.Errorf("note content must be at most %d MiB", models.MaxContentLength>>20)
+	}
+	doc.content = content
+	doc.history = append(doc.history, op)
+	rev := len(doc.history)
+
+	for _, other := range doc.participants {
+		if other.cursor != nil {
+			moved := other.cursor.Transform(op, other.ID == p.ID)
+			other.cursor = &moved
+		}
+	}
+
+	p.deliver(Message{Type: MsgAck, Revision: rev})
+	doc.broadcast(p.ID, Message{Type: MsgOp, ClientID: p.ID, Revision: rev, Op: op})
+	doc.scheduleSave()
+
+	return nil
+}
+
+// rebaseCursor moves a cursor reported at rev to the current revision
+func (doc *document) rebaseCursor(cursor Cursor, rev int) (Cursor, error) {
+	if rev < 0 || rev > len(doc.history) {
+		return cursor, fmt.Errorf("invalid revision %d", rev)
+	}
+	for _, op := range doc.history[rev:] {
+		cursor = cursor.Transform(op, false)
+	}
+	return cursor, nil
+}
+
+// broadcast queues msg for every participant except the one with skipID
+func (doc *document) broadcast(skipID int, msg Message) {
+	for id, p := range doc.participants {
+		if id != skipID {
+			p.deliver(msg)
+		}
+	}
+}
+
+// drop removes p and tells the others; doc.mu must be held
+func (doc *document) drop(p *Participant) {
+	p.closed = true
+	close(p.Send)
+	delete(doc.participants, p.ID)
+	doc.broadcast(p.ID, Message{Type: MsgLeave, ClientID: p.ID})
+}
+
+// deliver queues msg without blocking; a stalled participant is dropped and
+// has to reconnect to resync. doc.mu must be held.
+func (p *Participant) deliver(msg Message) {
+	if p.closed {
+		return
+	}
+	select {
+	case p.Send <- msg:
+	default:
+		p.doc.drop(p)
+	}
+}
+
+// peer describes the participant for other clients
+func (p *Participant) peer() Peer {
+	return Peer{ClientID: p.ID, Name: p.Name, Color: p.Color, Cursor: p.cursor}
+}
+
+// scheduleSave persists the document after it has been idle for a while;
+// doc.mu must be held
+func (doc *document) scheduleSave() {
+	doc.dirty = true
+	if doc.saveTimer != nil {
+		doc.saveTimer.Stop()
+	}
+	doc.saveTimer = time.AfterFunc(doc.hub.saveDelay, doc.save)
+}
+
+// save writes the merged content back through the store. If the content
+// was changed elsewhere since the session loaded or last saved it, nothing
+// is written: the participants are told about the conflict and the session
+// ends, so that they can decide which version to keep.
+func (doc *document) save() {
+	doc.mu.Lock()
+	if !doc.dirty {
+		doc.mu.Unlock()
+		return
+	}
+	if doc.saveTimer != nil {
+		doc.saveTimer.Stop()
+	}
+	content, seq, stored := doc.content, doc.seq, doc.stored
+	doc.dirty = false
+	doc.mu.Unlock()
+
+	// Saves outlive the requests of the participants, so they start a trace
+	// of their own
+	ctx, span := tracer.Start(context.Background(), "collab.save", trace.WithAttributes(attribute.Int64("note.id", doc.noteID)))
+	defer span.End()
+
+	note, err := doc.hub.store.GetByID(ctx, doc.noteID)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "collab: failed to load note for saving", "note", doc.noteID, "error", err)
+		return
+	}
+	// Changes elsewhere to anything but the content are kept as they are
+	if note.Seq != seq && note.Content != stored {
+		span.SetStatus(codes.Error, "note changed outside the session")
+		slog.WarnContext(ctx, "collab: note changed outside the session, not saving", "note", doc.noteID, "seq", seq, "stored_seq", note.Seq)
+		doc.conflict(note.Content)
+		return
+	}
+	if note.Content == content {
+		return
+	}
+	note.Content = content
+	if err := doc.hub.store.Update(ctx, note); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "collab: failed to save note", "note", doc.noteID, "error", err)
+		return
+	}
+
+	doc.mu.Lock()
+	doc.seq, doc.stored = note.Seq, note.Content
+	doc.mu.Unlock()
+}
+
+// conflict ends the session after telling every participant that the
+// stored note, whose content is stored, differs from what they edited
+func (doc *document) conflict(stored string) {
+	hub := doc.hub
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	doc.mu.Lock()
+	defer doc.mu.Unlock()
+
+	if doc.saveTimer != nil {
+		doc.saveTimer.Stop()
+	}
+	doc.dirty = false
+	if hub.docs[doc.noteID] == doc {
+		delete(hub.docs, doc.noteID)
+	}
+	for _, p := range doc.participants {
+		p.deliver(Message{
+			Type:     MsgConflict,
+			Revision: len(doc.history),
+			Content:  stored,
+			Error:    "the note was changed elsewhere; the edits of this session were not saved",
+		})
+	}
+	for _, p := range doc.participants {
+		if !p.closed {
+			p.closed = true
+			close(p.Send)
+		}
+	}
+	doc.participants = make(map[int]*Participant)
+}
@@ -0,0 +1,290 @@
+// pkg/collab/hub_test.go
+package collab
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Smil3MoreGH/gokeep/internal/database"
+	"github.com/Smil3MoreGH/gokeep/pkg/models"
+)
+
+// memoryStore keeps notes in memory, bumping Seq on every write like the
+// repository does
+type memoryStore struct {
+	mu    sync.Mutex
+	notes map[int64]models.Note
+	seq   int64
+	loads int
+}
+
+func newMemoryStore(notes ...models.Note) *memoryStore {
+	s := &memoryStore{notes: make(map[int64]models.Note)}
+	for _, n := range notes {
+		s.seq++
+		n.Seq = s.seq
+		s.notes[n.ID] = n
+	}
+	return s
+}
+
+func (s *memoryStore) GetByID(ctx context.Context, id int64) (*models.Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads++
+	n, ok := s.notes[id]
+	if !ok {
+		return nil, &database.NotFoundError{Resource: "note", ID: "?"}
+	}
+	return &n, nil
+}
+
+func (s *memoryStore) Update(ctx context.Context, note *models.Note) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	note.Seq = s.seq
+	s.notes[note.ID] = *note
+	return nil
+}
+
+func (s *memoryStore) get(id int64) models.Note {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notes[id]
+}
+
+// editor is one participant together with the OT client driving it
+type editor struct {
+	t      *testing.T
+	p      *Participant
+	client *Client
+}
+
+func join(t *testing.T, hub *Hub, noteID int64) *editor {
+	t.Helper()
+	p, err := hub.Join(context.Background(), noteID, "")
+	if err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	init := <-p.Send
+	if init.Type != MsgInit {
+		t.Fatalf("first message is %q, want init", init.Type)
+	}
+	e := &editor{t: t, p: p}
+	e.client = NewClient(init.Revision, init.Content, func(rev int, op *Operation) {
+		p.Handle(Message{Type: MsgOp, Revision: rev, Op: op})
+	})
+	return e
+}
+
+// edit replaces the local document with text
+func (e *editor) edit(text string) {
+	e.t.Helper()
+	if err := e.client.ApplyLocal(Diff(e.client.Doc, text)); err != nil {
+		e.t.Fatalf("ApplyLocal: %v", err)
+	}
+}
+
+// pump hands every queued message to the client and reports whether there
+// was one
+func (e *editor) pump() bool {
+	e.t.Helper()
+	select {
+	case msg, ok := <-e.p.Send:
+		if !ok {
+			return false
+		}
+		switch msg.Type {
+		case MsgOp:
+			if _, err := e.client.ApplyRemote(msg.Op); err != nil {
+				e.t.Fatalf("ApplyRemote: %v", err)
+			}
+		case MsgAck:
+			if err := e.client.Ack(); err != nil {
+				e.t.Fatalf("Ack: %v", err)
+			}
+		case MsgError:
+			e.t.Fatalf("server rejected an operation: %s", msg.Error)
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// settle delivers messages until no editor has any left
+func settle(editors ...*editor) {
+	for busy := true; busy; {
+		busy = false
+		for _, e := range editors {
+			for e.pump() {
+				busy = true
+			}
+		}
+	}
+}
+
+// waitFor returns the next message of type typ on p, skipping others
+func waitFor(t *testing.T, p *Participant, typ MessageType) Message {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case msg, ok := <-p.Send:
+			if !ok {
+				t.Fatalf("participant dropped before %q", typ)
+			}
+			if msg.Type == typ {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("no %q message", typ)
+		}
+	}
+}
+
+func TestHubClientRoundTrip(t *testing.T) {
+	store := newMemoryStore(models.Note{ID: 1, Title: "Plan", Content: "héllo wörld"})
+	hub := NewHub(store, time.Hour)
+
+	alice, bob := join(t, hub, 1), join(t, hub, 1)
+
+	// Concurrent edits at both ends, each with a second edit buffered
+	alice.edit("¡héllo wörld")
+	alice.edit("¡héllo wörld 😀")
+	bob.edit("héllo wörld!")
+	bob.edit("héllo, wörld!")
+	settle(alice, bob)
+
+	if alice.client.Doc != bob.client.Doc {
+		t.Fatalf("documents diverged: %q and %q", alice.client.Doc, bob.client.Doc)
+	}
+	if alice.client.Pending() || bob.client.Pending() {
+		t.Fatal("edits still waiting for an ack")
+	}
+	want := alice.client.Doc
+	for _, s := range []string{"¡", "😀", ",", "!"} {
+		if !strings.Contains(want, s) {
+			t.Errorf("merged document %q lost %q", want, s)
+		}
+	}
+
+	alice.p.Leave()
+	bob.p.Leave()
+	if got := store.get(1); got.Content != want || got.Title != "Plan" {
+		t.Errorf("saved %q titled %q, want %q titled Plan", got.Content, got.Title, want)
+	}
+}
+
+func TestHubSaveConflict(t *testing.T) {
+	store := newMemoryStore(models.Note{ID: 1, Content: "draft"})
+	hub := NewHub(store, 10*time.Millisecond)
+
+	alice := join(t, hub, 1)
+	bob := join(t, hub, 1)
+	waitFor(t, alice.p, MsgJoin)
+
+	// The note is edited through the API while the session is open
+	outside := store.get(1)
+	outside.Content = "changed elsewhere"
+	store.Update(context.Background(), &outside)
+
+	alice.edit("draft, edited together")
+
+	msg := waitFor(t, bob.p, MsgConflict)
+	if msg.Content != "changed elsewhere" {
+		t.Errorf("conflict carries %q, want the stored content", msg.Content)
+	}
+	for range bob.p.Send {
+		// The session is over, so Send is closed
+	}
+	if got := store.get(1).Content; got != "changed elsewhere" {
+		t.Errorf("stored content is %q, the change made elsewhere was overwritten", got)
+	}
+
+	// A new session starts from the stored note
+	carol := join(t, hub, 1)
+	if carol.client.Doc != "changed elsewhere" {
+		t.Errorf("new session has %q", carol.client.Doc)
+	}
+	alice.p.Leave()
+	bob.p.Leave()
+	carol.p.Leave()
+}
+
+func TestHubSaveKeepsOtherChanges(t *testing.T) {
+	store := newMemoryStore(models.Note{ID: 1, Title: "Old", Content: "draft"})
+	hub := NewHub(store, time.Hour)
+
+	alice := join(t, hub, 1)
+
+	// Only the title changes elsewhere, which does not clash with the content
+	outside := store.get(1)
+	outside.Title = "New"
+	store.Update(context.Background(), &outside)
+
+	alice.edit("draft 2")
+	settle(alice)
+	alice.p.Leave()
+
+	if got := store.get(1); got.Content != "draft 2" || got.Title != "New" {
+		t.Errorf("saved %q titled %q, want %q titled New", got.Content, got.Title, "draft 2")
+	}
+}
+
+func TestHubRejectsOversizedDocument(t *testing.T) {
+	store := newMemoryStore(models.Note{ID: 1, Content: "x"})
+	hub := NewHub(store, time.Hour)
+
+	p, err := hub.Join(context.Background(), 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-p.Send
+
+	huge := NewOperation().Retain(1).Insert(strings.Repeat("a", models.MaxContentLength))
+	p.Handle(Message{Type: MsgOp, Revision: 0, Op: huge})
+	if msg := <-p.Send; msg.Type != MsgError {
+		t.Errorf("got %q, want an error", msg.Type)
+	}
+	p.Leave()
+
+	if got := store.get(1).Content; got != "x" {
+		t.Errorf("stored content grew to %d bytes", len(got))
+	}
+}
+
+func TestHubConcurrentJoinsShareDocument(t *testing.T) {
+	store := newMemoryStore(models.Note{ID: 1, Content: "shared"})
+	hub := NewHub(store, time.Hour)
+
+	var wg sync.WaitGroup
+	participants := make([]*Participant, 8)
+	for i := range participants {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := hub.Join(context.Background(), 1, "")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			participants[i] = p
+		}()
+	}
+	wg.Wait()
+
+	doc := participants[0].doc
+	for _, p := range participants {
+		if p.doc != doc {
+			t.Fatal("participants joined different documents")
+		}
+	}
+	for _, p := range participants {
+		p.Leave()
+	}
+}
@@ -0,0 +1,375 @@
+// pkg/collab/ot.go
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Operation errors
+var (
+	ErrBaseLength   = errors.New("operation base length does not match document")
+	ErrComposeOrder = errors.New("operations cannot be composed: lengths differ")
+	ErrConcurrent   = errors.New("operations are not concurrent: base lengths differ")
+)
+
+// Component is one step of an operation. Exactly one field is set.
+// Lengths are counted in runes.
+type Component struct {
+	Retain int
+	Insert string
+	Delete int
+}
+
+// Operation is a sequence of components that walks over the whole document,
+// retaining, inserting and deleting text. It follows the model used by ot.js,
+// so the same JSON encoding can be shared with any client.
+type Operation struct {
+	Components []Component
+	BaseLen    int
+	TargetLen  int
+}
+
+// NewOperation creates an empty operation
+func NewOperation() *Operation {
+	return &Operation{}
+}
+
+// Retain skips n runes
+func (o *Operation) Retain(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+	o.BaseLen += n
+	o.TargetLen += n
+	if last := o.last(); last != nil && last.Retain > 0 {
+		last.Retain += n
+		return o
+	}
+	o.Components = append(o.Components, Component{Retain: n})
+	return o
+}
+
+// Insert inserts s at the current position
+func (o *Operation) Insert(s string) *Operation {
+	if s == "" {
+		return o
+	}
+	o.TargetLen += utf8.RuneCountInString(s)
+
+	n := len(o.Components)
+	switch {
+	case n > 0 && o.Components[n-1].Insert != "":
+		o.Components[n-1].Insert += s
+	case n > 0 && o.Components[n-1].Delete > 0:
+		// Keep inserts before deletes so equivalent operations look the same
+		if n > 1 && o.Components[n-2].Insert != "" {
+			o.Components[n-2].Insert += s
+		} else {
+			o.Components = append(o.Components, o.Components[n-1])
+			o.Components[n-1] = Component{Insert: s}
+		}
+	default:
+		o.Components = append(o.Components, Component{Insert: s})
+	}
+	return o
+}
+
+// Delete removes n runes
+func (o *Operation) Delete(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+	o.BaseLen += n
+	if last := o.last(); last != nil && last.Delete > 0 {
+		last.Delete += n
+		return o
+	}
+	o.Components = append(o.Components, Component{Delete: n})
+	return o
+}
+
+// IsNoop reports whether the operation leaves the document unchanged
+func (o *Operation) IsNoop() bool {
+	return len(o.Components) == 0 ||
+		(len(o.Components) == 1 && o.Components[0].Retain > 0)
+}
+
+func (o *Operation) last() *Component {
+	if len(o.Components) == 0 {
+		return nil
+	}
+	return &o.Components[len(o.Components)-1]
+}
+
+// Apply applies the operation to doc
+func (o *Operation) Apply(doc string) (string, error) {
+	runes := []rune(doc)
+	if len(runes) != o.BaseLen {
+		return "", ErrBaseLength
+	}
+
+	result := make([]rune, 0, o.TargetLen)
+	pos := 0
+	for _, c := range o.Components {
+		switch {
+		case c.Retain > 0:
+			if pos+c.Retain > len(runes) {
+				return "", fmt.Errorf("retain past end of document")
+			}
+			result = append(result, runes[pos:pos+c.Retain]...)
+			pos += c.Retain
+		case c.Insert != "":
+			result = append(result, []rune(c.Insert)...)
+		case c.Delete > 0:
+			pos += c.Delete
+		}
+	}
+	if pos != len(runes) {
+		return "", fmt.Errorf("operation did not cover the whole document")
+	}
+
+	return string(result), nil
+}
+
+// Compose merges a and b into one operation with the same effect as applying
+// a followed by b
+func Compose(a, b *Operation) (*Operation, error) {
+	if a.TargetLen != b.BaseLen {
+		return nil, ErrComposeOrder
+	}
+
+	result := NewOperation()
+	it1, it2 := &iterator{ops: a.Components}, &iterator{ops: b.Components}
+	op1, op2 := it1.next(), it2.next()
+
+	for op1 != nil || op2 != nil {
+		switch {
+		case op1 != nil && op1.Delete > 0:
+			result.Delete(op1.Delete)
+			op1 = it1.next()
+			continue
+		case op2 != nil && op2.Insert != "":
+			result.Insert(op2.Insert)
+			op2 = it2.next()
+			continue
+		case op1 == nil || op2 == nil:
+			return nil, ErrComposeOrder
+		}
+
+		switch {
+		case op1.Retain > 0 && op2.Retain > 0:
+			n := min(op1.Retain, op2.Retain)
+			result.Retain(n)
+			op1, op2 = it1.consume(op1, n), it2.consume(op2, n)
+		case op1.Insert != "" && op2.Delete > 0:
+			l1 := utf8.RuneCountInString(op1.Insert)
+			n := min(l1, op2.Delete)
+			op1, op2 = it1.consume(op1, n), it2.consume(op2, n)
+		case op1.Insert != "" && op2.Retain > 0:
+			l1 := utf8.RuneCountInString(op1.Insert)
+			n := min(l1, op2.Retain)
+			result.Insert(string([]rune(op1.Insert)[:n]))
+			op1, op2 = it1.consume(op1, n), it2.consume(op2, n)
+		case op1.Retain > 0 && op2.Delete > 0:
+			n := min(op1.Retain, op2.Delete)
+			result.Delete(n)
+			op1, op2 = it1.consume(op1, n), it2.consume(op2, n)
+		default:
+			return nil, fmt.Errorf("compose: unexpected components")
+		}
+	}
+
+	return result, nil
+}
+
+// Transform takes two operations a and b that were applied to the same
+// document concurrently and returns a' and b' such that applying a then b'
+// gives the same result as applying b then a'. Inserts from a win ties.
+func Transform(a, b *Operation) (*Operation, *Operation, error) {
+	if a.BaseLen != b.BaseLen {
+		return nil, nil, ErrConcurrent
+	}
+
+	aPrime, bPrime := NewOperation(), NewOperation()
+	it1, it2 := &iterator{ops: a.Components}, &iterator{ops: b.Components}
+	op1, op2 := it1.next(), it2.next()
+
+	for op1 != nil || op2 != nil {
+		if op1 != nil && op1.Insert != "" {
+			aPrime.Insert(op1.Insert)
+			bPrime.Retain(utf8.RuneCountInString(op1.Insert))
+			op1 = it1.next()
+			continue
+		}
+		if op2 != nil && op2.Insert != "" {
+			aPrime.Retain(utf8.RuneCountInString(op2.Insert))
+			bPrime.Insert(op2.Insert)
+			op2 = it2.next()
+			continue
+		}
+		if op1 == nil || op2 == nil {
+			return nil, nil, ErrConcurrent
+		}
+
+		switch {
+		case op1.Retain > 0 && op2.Retain > 0:
+			n := min(op1.Retain, op2.Retain)
+			aPrime.Retain(n)
+			bPrime.Retain(n)
+			op1, op2 = it1.consume(op1, n), it2.consume(op2, n)
+		case op1.Delete > 0 && op2.Delete > 0:
+			// Both deleted the same text
+			n := min(op1.Delete, op2.Delete)
+			op1, op2 = it1.consume(op1, n), it2.consume(op2, n)
+		case op1.Delete > 0 && op2.Retain > 0:
+			n := min(op1.Delete, op2.Retain)
+			aPrime.Delete(n)
+			op1, op2 = it1.consume(op1, n), it2.consume(op2, n)
+		case op1.Retain > 0 && op2.Delete > 0:
+			n := min(op1.Retain, op2.Delete)
+			bPrime.Delete(n)
+			op1, op2 = it1.consume(op1, n), it2.consume(op2, n)
+		default:
+			return nil, nil, fmt.Errorf("transform: unexpected components")
+		}
+	}
+
+	return aPrime, bPrime, nil
+}
+
+// iterator walks the components of an operation, handing out copies that
+// can be consumed piecewise
+type iterator struct {
+	ops []Component
+	i   int
+}
+
+// next returns a copy of the next component, or nil at the end
+func (it *iterator) next() *Component {
+	if it.i >= len(it.ops) {
+		return nil
+	}
+	c := it.ops[it.i]
+	it.i++
+	return &c
+}
+
+// consume uses up n runes of c and returns what is left of it, or the next
+// component once c is exhausted
+func (it *iterator) consume(c *Component, n int) *Component {
+	switch {
+	case c.Retain > 0:
+		c.Retain -= n
+		if c.Retain > 0 {
+			return c
+		}
+	case c.Delete > 0:
+		c.Delete -= n
+		if c.Delete > 0 {
+			return c
+		}
+	case c.Insert != "":
+		rest := []rune(c.Insert)[n:]
+		if len(rest) > 0 {
+			c.Insert = string(rest)
+			return c
+		}
+	}
+	return it.next()
+}
+
+// TransformCursor moves a cursor position (in runes) across op. Inserts
+// before the cursor push it forward. An insert right at the cursor only does
+// if own is true, i.e. the cursor belongs to the author of op and follows
+// the text they typed; anyone else's cursor stays in front of it.
+func TransformCursor(pos int, op *Operation, own bool) int {
+	index := 0
+	newPos := pos
+	for _, c := range op.Components {
+		if index > pos {
+			break
+		}
+		switch {
+		case c.Retain > 0:
+			index += c.Retain
+		case c.Insert != "":
+			if index < pos || own {
+				newPos += utf8.RuneCountInString(c.Insert)
+			}
+		case c.Delete > 0:
+			newPos -= min(pos-index, c.Delete)
+			index += c.Delete
+		}
+	}
+	return max(newPos, 0)
+}
+
+// Diff builds the operation that turns before into after by replacing the
+// span between their common prefix and suffix. This matches how a textarea
+// changes between two input events.
+func Diff(before, after string) *Operation {
+	a, b := []rune(before), []rune(after)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	return NewOperation().
+		Retain(prefix).
+		Insert(string(b[prefix : len(b)-suffix])).
+		Delete(len(a) - prefix - suffix).
+		Retain(suffix)
+}
+
+// MarshalJSON encodes the operation as in ot.js: positive numbers retain,
+// negative numbers delete and strings insert
+func (o *Operation) MarshalJSON() ([]byte, error) {
+	out := make([]any, 0, len(o.Components))
+	for _, c := range o.Components {
+		switch {
+		case c.Retain > 0:
+			out = append(out, c.Retain)
+		case c.Insert != "":
+			out = append(out, c.Insert)
+		case c.Delete > 0:
+			out = append(out, -c.Delete)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes an operation in ot.js format
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*o = Operation{}
+	for _, item := range raw {
+		var n int
+		if err := json.Unmarshal(item, &n); err == nil {
+			if n > 0 {
+				o.Retain(n)
+			} else if n < 0 {
+				o.Delete(-n)
+			}
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(item, &s); err != nil {
+			return fmt.Errorf("invalid operation component %s", item)
+		}
+		o.Insert(s)
+	}
+	return nil
+}
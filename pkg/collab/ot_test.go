@@ -0,0 +1,190 @@
+// pkg/collab/ot_test.go
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// alphabet mixes one-, two-, three- and four-byte runes, so that rune and
+// byte offsets differ
+var alphabet = []rune("ab ñé€😀\n")
+
+func randomString(r *rand.Rand, max int) string {
+	runes := make([]rune, r.Intn(max+1))
+	for i := range runes {
+		runes[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(runes)
+}
+
+// randomOp returns a random operation on doc
+func randomOp(r *rand.Rand, doc string) *Operation {
+	op := NewOperation()
+	left := len([]rune(doc))
+	for left > 0 {
+		n := 1 + r.Intn(left)
+		switch r.Intn(3) {
+		case 0:
+			op.Retain(n)
+			left -= n
+		case 1:
+			op.Delete(n)
+			left -= n
+		default:
+			op.Insert(randomString(r, 4))
+		}
+	}
+	if r.Intn(2) == 0 {
+		op.Insert(randomString(r, 4))
+	}
+	return op
+}
+
+func mustApply(t *testing.T, op *Operation, doc string) string {
+	t.Helper()
+	out, err := op.Apply(doc)
+	if err != nil {
+		t.Fatalf("Apply(%q): %v", doc, err)
+	}
+	return out
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		op   *Operation
+		want string
+	}{
+		{"insert", "", NewOperation().Insert("héllo"), "héllo"},
+		{"retain and insert", "ab", NewOperation().Retain(1).Insert("😀").Retain(1), "a😀b"},
+		{"delete multi-byte", "a€😀b", NewOperation().Retain(1).Delete(2).Retain(1), "ab"},
+		{"replace", "ñandú", NewOperation().Retain(4).Insert("u").Delete(1), "ñandu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustApply(t, tt.op, tt.doc); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyBaseLength(t *testing.T) {
+	// Three runes but more bytes: lengths are counted in runes
+	op := NewOperation().Retain(3)
+	if _, err := op.Apply("€€€"); err != nil {
+		t.Errorf("Apply: %v", err)
+	}
+	if _, err := op.Apply("abcd"); !errors.Is(err, ErrBaseLength) {
+		t.Errorf("Apply to longer document: got %v, want ErrBaseLength", err)
+	}
+}
+
+// TestTransform checks TP1: apply(apply(s, a), b') == apply(apply(s, b), a')
+func TestTransform(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		doc := randomString(r, 12)
+		a, b := randomOp(r, doc), randomOp(r, doc)
+
+		aPrime, bPrime, err := Transform(a, b)
+		if err != nil {
+			t.Fatalf("Transform(%v, %v): %v", a, b, err)
+		}
+		left := mustApply(t, bPrime, mustApply(t, a, doc))
+		right := mustApply(t, aPrime, mustApply(t, b, doc))
+		if left != right {
+			t.Fatalf("doc %q, a %v, b %v: a then b' gives %q, b then a' gives %q", doc, a, b, left, right)
+		}
+	}
+}
+
+func TestTransformConcurrentLengths(t *testing.T) {
+	a, b := NewOperation().Retain(2), NewOperation().Retain(3)
+	if _, _, err := Transform(a, b); !errors.Is(err, ErrConcurrent) {
+		t.Errorf("got %v, want ErrConcurrent", err)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 2000; i++ {
+		doc := randomString(r, 12)
+		a := randomOp(r, doc)
+		middle := mustApply(t, a, doc)
+		b := randomOp(r, middle)
+
+		ab, err := Compose(a, b)
+		if err != nil {
+			t.Fatalf("Compose(%v, %v): %v", a, b, err)
+		}
+		want := mustApply(t, b, middle)
+		if got := mustApply(t, ab, doc); got != want {
+			t.Fatalf("doc %q, a %v, b %v: composed gives %q, applied in turn %q", doc, a, b, got, want)
+		}
+	}
+}
+
+func TestComposeOrder(t *testing.T) {
+	a, b := NewOperation().Insert("x"), NewOperation().Retain(2)
+	if _, err := Compose(a, b); !errors.Is(err, ErrComposeOrder) {
+		t.Errorf("got %v, want ErrComposeOrder", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 1000; i++ {
+		before, after := randomString(r, 10), randomString(r, 10)
+		if got := mustApply(t, Diff(before, after), before); got != after {
+			t.Fatalf("Diff(%q, %q) applied gives %q", before, after, got)
+		}
+	}
+}
+
+func TestTransformCursor(t *testing.T) {
+	tests := []struct {
+		name string
+		pos  int
+		op   *Operation
+		own  bool
+		want int
+	}{
+		{"insert before", 3, NewOperation().Retain(1).Insert("😀€").Retain(4), false, 5},
+		{"insert after", 1, NewOperation().Retain(3).Insert("x").Retain(2), false, 1},
+		{"insert at cursor of another", 2, NewOperation().Retain(2).Insert("xy").Retain(3), false, 2},
+		{"insert at own cursor", 2, NewOperation().Retain(2).Insert("xy").Retain(3), true, 4},
+		{"delete before", 4, NewOperation().Retain(1).Delete(2).Retain(2), false, 2},
+		{"delete around", 2, NewOperation().Retain(1).Delete(3).Retain(1), false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TransformCursor(tt.pos, tt.op, tt.own); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOperationJSON(t *testing.T) {
+	op := NewOperation().Retain(2).Insert("€😀").Delete(3).Retain(1)
+	data, err := json.Marshal(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `[2,"€😀",-3,1]` {
+		t.Errorf("encoded as %s", data)
+	}
+
+	var decoded Operation
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.BaseLen != op.BaseLen || decoded.TargetLen != op.TargetLen || len(decoded.Components) != len(op.Components) {
+		t.Errorf("decoded %+v, want %+v", decoded, *op)
+	}
+}
@@ -0,0 +1,61 @@
+// pkg/collab/protocol.go
+package collab
+
+// MessageType identifies a collaboration message on the wire
+type MessageType string
+
+const (
+	// Server -> client: initial document state and peers
+	MsgInit MessageType = "init"
+	// Client -> server: local edit based on Revision.
+	// Server -> client: remote edit producing Revision.
+	MsgOp MessageType = "op"
+	// Server -> client: the client's last operation became Revision
+	MsgAck MessageType = "ack"
+	// Both directions: caret or selection moved
+	MsgCursor MessageType = "cursor"
+	// Server -> client: a peer connected or disconnected
+	MsgJoin  MessageType = "join"
+	MsgLeave MessageType = "leave"
+	// Server -> client: the last message was rejected
+	MsgError MessageType = "error"
+	// Server -> client: the note was changed outside the session, so the
+	// session's content was not saved and the session is over. Content
+	// holds the stored text.
+	MsgConflict MessageType = "conflict"
+)
+
+// Cursor is a selection in rune offsets; Anchor == Head for a plain caret
+type Cursor struct {
+	Anchor int `json:"anchor"`
+	Head   int `json:"head"`
+}
+
+// Peer is another participant editing the same note
+type Peer struct {
+	ClientID int     `json:"client_id"`
+	Name     string  `json:"name"`
+	Color    string  `json:"color"`
+	Cursor   *Cursor `json:"cursor,omitempty"`
+}
+
+// Message is the envelope for everything sent over the collaboration socket
+type Message struct {
+	Type     MessageType `json:"type"`
+	ClientID int         `json:"client_id,omitempty"`
+	Revision int         `json:"rev"`
+	Op       *Operation  `json:"op,omitempty"`
+	Content  string      `json:"content,omitempty"`
+	Cursor   *Cursor     `json:"cursor,omitempty"`
+	Peer     *Peer       `json:"peer,omitempty"`
+	Peers    []Peer      `json:"peers,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// Transform moves both ends of the selection across op
+func (c Cursor) Transform(op *Operation, own bool) Cursor {
+	return Cursor{
+		Anchor: TransformCursor(c.Anchor, op, own),
+		Head:   TransformCursor(c.Head, op, own),
+	}
+}
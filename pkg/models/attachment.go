@@ -0,0 +1,73 @@
+// pkg/models/attachment.go
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment is a file stored with a note. Data is only loaded when the file
+// itself is requested.
+type Attachment struct {
+	ID        string    `json:"id" db:"id"`
+	NoteID    int64     `json:"note_id" db:"note_id"`
+	Filename  string    `json:"filename" db:"filename"`
+	MimeType  string    `json:"mime_type" db:"mime_type"`
+	Size      int64     `json:"size" db:"size"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	Data      []byte    `json:"-" db:"data"`
+}
+
+// NewAttachment creates an attachment with a fresh ID, so it can be linked
+// from note content before it is stored
+func NewAttachment(filename, mimeType string, data []byte) Attachment {
+	return Attachment{
+		ID:        uuid.NewString(),
+		Filename:  filename,
+		MimeType:  mimeType,
+		Size:      int64(len(data)),
+		CreatedAt: time.Now(),
+		Data:      data,
+	}
+}
+
+// ValidAttachmentID reports whether id has the form NewAttachment gives
+// IDs. Exports use IDs in file paths, so IDs from elsewhere must be
+// checked before they are stored.
+func ValidAttachmentID(id string) bool {
+	if len(id) != 36 {
+		return false
+	}
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+// URL returns the path the attachment is served from
+func (a Attachment) URL() string {
+	return AttachmentURL(a.ID)
+}
+
+// IsImage reports whether the attachment can be shown inline
+func (a Attachment) IsImage() bool {
+	return strings.HasPrefix(a.MimeType, "image/")
+}
+
+// Markdown returns a Markdown reference to the attachment: an image for
+// pictures and a plain link for everything else
+func (a Attachment) Markdown() string {
+	if a.IsImage() {
+		return fmt.Sprintf("![%s](%s)", a.Filename, a.URL())
+	}
+	return fmt.Sprintf("[%s](%s)", a.Filename, a.URL())
+}
+
+// AttachmentURLPrefix is the path under which attachments are served
+const AttachmentURLPrefix = "/api/attachments/"
+
+// AttachmentURL returns the path an attachment with id is served from
+func AttachmentURL(id string) string {
+	return AttachmentURLPrefix + id
+}
@@ -0,0 +1,27 @@
+// pkg/models/event.go
+package models
+
+import (
+	"time"
+)
+
+// NoteEventType identifies what happened to a note
+type NoteEventType string
+
+const (
+	EventNoteCreated NoteEventType = "note.created"
+	EventNoteUpdated NoteEventType = "note.updated"
+	EventNoteDeleted NoteEventType = "note.deleted"
+
+	// EventReset tells a client that events were missed and it has to reload
+	EventReset NoteEventType = "reset"
+)
+
+// NoteEvent describes a single change to a note. Note is nil for deletions.
+type NoteEvent struct {
+	ID     uint64        `json:"id"`
+	Type   NoteEventType `json:"type"`
+	NoteID int64         `json:"note_id"`
+	Note   *Note         `json:"note,omitempty"`
+	Time   time.Time     `json:"time"`
+}
@@ -1,7 +1,8 @@
-// internal/models/note.go
+// pkg/models/note.go
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,14 @@ type Note struct {
 	Color     string    `json:"color" db:"color"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// Seq is the change sequence number of the last write to the note
+	Seq      int64    `json:"seq" db:"seq"`
+	Pinned   bool     `json:"pinned" db:"pinned"`
+	Archived bool     `json:"archived" db:"archived"`
+	Trashed  bool     `json:"trashed" db:"trashed"`
+	Labels   []string `json:"labels"`
+
+	Attachments []Attachment `json:"attachments,omitempty"`
 }
 
 // NoteColor represents available note colors
@@ -29,6 +38,26 @@ const (
 	ColorGray   NoteColor = "#e8eaed"
 )
 
+// ColorNames maps the names of the note colors to their values
+var ColorNames = map[string]NoteColor{
+	"white":  ColorWhite,
+	"yellow": ColorYellow,
+	"orange": ColorOrange,
+	"pink":   ColorPink,
+	"purple": ColorPurple,
+	"blue":   ColorBlue,
+	"green":  ColorGreen,
+	"gray":   ColorGray,
+}
+
+// ParseColor accepts a color name or value and returns the value
+func ParseColor(s string) (string, bool) {
+	if color, ok := ColorNames[strings.ToLower(s)]; ok {
+		return string(color), true
+	}
+	return s, ValidateColor(s)
+}
+
 // ValidateColor checks if the provided color is valid
 func ValidateColor(color string) bool {
 	validColors := []NoteColor{
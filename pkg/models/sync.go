@@ -0,0 +1,24 @@
+// pkg/models/sync.go
+package models
+
+import (
+	"time"
+)
+
+// Tombstone records that a note was deleted
+type Tombstone struct {
+	ID        int64     `json:"id" db:"note_id"`
+	Seq       int64     `json:"seq" db:"seq"`
+	DeletedAt time.Time `json:"deleted_at" db:"deleted_at"`
+}
+
+// SyncResult lists everything that changed after a sequence number. Clients
+// store Seq and pass it as since on their next sync.
+type SyncResult struct {
+	Changed []Note      `json:"changed"`
+	Deleted []Tombstone `json:"deleted"`
+	Seq     int64       `json:"seq"`
+	// HasMore is set when the result was truncated by a limit; sync again
+	// from Seq to get the rest
+	HasMore bool `json:"has_more"`
+}
@@ -0,0 +1,108 @@
+// pkg/models/validate.go
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits on what a note may hold
+const (
+	// MaxTitleLength is counted in characters
+	MaxTitleLength = 500
+	// MaxContentLength is counted in bytes
+	MaxContentLength = 1 << 20
+	MaxLabels        = 50
+	// MaxLabelLength is counted in characters
+	MaxLabelLength = 50
+)
+
+// FieldError says what is wrong with one field of the input. Field is the
+// JSON name of the field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors lists every invalid field of the input
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// For returns the message for field, or "" if the field is valid
+func (e FieldErrors) For(field string) string {
+	for _, fe := range e {
+		if fe.Field == field {
+			return fe.Message
+		}
+	}
+	return ""
+}
+
+// Validate checks the fields a user can edit against the limits above. It
+// returns FieldErrors, or nil if the note is valid. An empty color is
+// allowed; SetDefaults turns it into white.
+func (n *Note) Validate() error {
+	var errs FieldErrors
+	add := func(field, format string, args ...any) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case !utf8.ValidString(n.Title):
+		add("title", "is not valid UTF-8")
+	case utf8.RuneCountInString(n.Title) > MaxTitleLength:
+		add("title", "must be at most %d characters", MaxTitleLength)
+	}
+
+	switch {
+	case !utf8.ValidString(n.Content):
+		add("content", "is not valid UTF-8")
+	case len(n.Content) > MaxContentLength:
+		add("content", "must be at most %d MiB", MaxContentLength>>20)
+	case strings.ContainsRune(n.Content, 0):
+		add("content", "must not contain NUL characters")
+	}
+
+	if n.Color != "" && !ValidateColor(n.Color) {
+		add("color", "%q is not one of the note colors", n.Color)
+	}
+
+	if len(n.Labels) > MaxLabels {
+		add("labels", "a note can have at most %d labels", MaxLabels)
+	}
+	for _, label := range n.Labels {
+		if msg := labelError(label); msg != "" {
+			// One bad label is enough to tell the user
+			add("labels", "label %q %s", label, msg)
+			break
+		}
+	}
+
+	if errs == nil {
+		return nil
+	}
+	return errs
+}
+
+// labelError says what is wrong with label, or "" if nothing is. Blank
+// labels are dropped when stored, so they pass.
+func labelError(label string) string {
+	switch {
+	case !utf8.ValidString(label):
+		return "is not valid UTF-8"
+	case utf8.RuneCountInString(label) > MaxLabelLength:
+		return fmt.Sprintf("must be at most %d characters", MaxLabelLength)
+	case strings.ContainsFunc(label, unicode.IsControl):
+		return "must not contain control characters"
+	}
+	return ""
+}